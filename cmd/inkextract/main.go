@@ -15,20 +15,36 @@ import (
 	"github.com/Jictyvoo/ink_stream/internal/services/filextract/cbxr"
 	"github.com/Jictyvoo/ink_stream/internal/utils"
 	"github.com/Jictyvoo/ink_stream/pkg/bootstrap"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 func main() {
 	var (
 		inputFolder  string
 		outputFolder string
+		exclude      string
 	)
 	flag.StringVar(&inputFolder, "src", "", "Target folder where files are stored")
 	flag.StringVar(&outputFolder, "out", "", "Output folder where files will be saved")
+	flag.StringVar(
+		&exclude, "exclude", bootstrap.DefaultExcludeGlob,
+		"Comma separated globs of the dropped pages",
+	)
 	flag.Parse()
 
 	if inputFolder == "" {
 		log.Fatal("Target folder is required")
 	}
+	var excludeGlobs []string
+	for glob := range strings.SplitSeq(exclude, ",") {
+		if glob = strings.TrimSpace(glob); glob != "" {
+			excludeGlobs = append(excludeGlobs, glob)
+		}
+	}
+	exclusionRules, err := bootstrap.ExclusionOptions{Exclude: excludeGlobs}.ExclusionRules()
+	if err != nil {
+		log.Fatalf("Failed to build page exclusion: %v", err)
+	}
 
 	var (
 		lastFolderName = filepath.Base(inputFolder)
@@ -57,10 +73,14 @@ func main() {
 		wg.Add(1)
 		go func() {
 			fp := filextract.NewFileProcessorWorker(
-				sendChannel, outputFolder,
-				func(outputDir string) (filextract.FileOutputWriter, error) {
+				sendChannel,
+				outputFolder,
+				func(
+					outputDir string, _ inktypes.BookMetadata,
+				) (filextract.FileOutputWriter, error) {
 					return bootstrap.NewFileWriterWrapper(outputDir)
 				},
+				exclusionRules,
 			)
 			defer wg.Done()
 			_ = fp.Run()
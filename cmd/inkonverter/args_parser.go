@@ -3,9 +3,12 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Jictyvoo/ink_stream/pkg/bootstrap"
 	"github.com/Jictyvoo/ink_stream/pkg/deviceprof"
@@ -17,9 +20,30 @@ func parseArgs(cliArgs *bootstrap.Options) {
 	flag.StringVar(&cliArgs.OutputFolder, "out", "", "Output folder where files will be saved")
 	flag.BoolVar(&cliArgs.RotateImage, "rotate", false, "Rotate image files")
 	flag.BoolVar(&cliArgs.ColoredPages, "colored", false, "Colored pages")
+	flag.BoolVar(&cliArgs.Deskew, "deskew", false, "Straighten slightly rotated scanned pages")
+	flag.Float64Var(
+		&cliArgs.DropBlank.MaxInkRatio, "drop-blank-threshold", 0,
+		"Largest fraction of ink pixels (0-1) on the pages dropped as blank, zero disables it",
+	)
+	flag.IntVar(
+		&cliArgs.DropBlank.KeepFirst, "keep-first-pages", 1,
+		"Number of first pages never dropped as blank, so the covers survive",
+	)
 	flag.BoolVar(&cliArgs.AddMargins, "margins", false, "Add margin on image")
-	flag.BoolVar(&cliArgs.StretchImage, "stretch", true, "Stretch image files")
+	flag.IntVar(&cliArgs.Sharpen.Radius, "sharpen-radius", 1, "Radius of the sharpening mask")
+	flag.Float64Var(
+		&cliArgs.Sharpen.Amount, "sharpen-amount", 0,
+		"Strength of the sharpening applied after the rescale, zero disables it",
+	)
+	sharpenThreshold := flag.Uint(
+		"sharpen-threshold", 0, "Minimum pixel difference (0-255) that is sharpened",
+	)
 	cropLevel := flag.Uint("crop-level", uint(bootstrap.CropBasic), "Crop image level")
+	parseAutoCropArgs(&cliArgs.AutoCrop)
+	flag.BoolVar(
+		&cliArgs.ConsistentCrop, "consistent-crop", false,
+		"Crop all the pages of a chapter by the same box, found from all of them",
+	)
 
 	var (
 		targetDevice  string
@@ -27,16 +51,69 @@ func parseArgs(cliArgs *bootstrap.Options) {
 		readDirection string
 		imgOutFormat  string
 		imgOutQuality uint
+		dithering     string
+		binarize      string
+		resampling    string
+		noGutter      string
+		spreadPolicy  string
+		fitMode       string
 	)
 	flag.StringVar(&targetDevice, "profile", "", "Target device name")
 	flag.StringVar(&outFormat, "format", string(bootstrap.FormatEpub), "Output format")
 	flag.StringVar(&imgOutFormat, "img-format", string(bootstrap.ImageJPEG), "Image output format")
 	flag.UintVar(&imgOutQuality, "img-quality", 85, "Image output quality")
+	flag.StringVar(
+		&spreadPolicy, "spread", "",
+		"What to do with double-page spreads (split, rotate, rotate-split, keep), "+
+			"by default they are split, or rotated with -rotate",
+	)
+	flag.StringVar(
+		&noGutter, "no-gutter", string(bootstrap.SpreadKeep),
+		"What to do with spreads without gutter, when they are split (keep, rotate, split)",
+	)
+	flag.StringVar(
+		&fitMode, "fit", string(bootstrap.FitStretch),
+		"How pages fit the screen (fit, fill, stretch, fit-width, no-upscale)",
+	)
+	stretchImage := flag.Bool(
+		"stretch", true, "Deprecated: use -fit stretch, or -fit fit when false",
+	)
+	flag.StringVar(
+		&resampling, "resample", "",
+		"Resampling filter (nearest, bilinear, catmull-rom, lanczos3), when empty "+
+			"nearest on colored pixel art and a fast bilinear approximation on the other pages",
+	)
+	flag.StringVar(
+		&dithering, "dither", string(bootstrap.DitherNone),
+		"Dithering to the device palette (none, floyd-steinberg, atkinson, bayer)",
+	)
+	flag.StringVar(
+		&binarize, "binarize", string(bootstrap.ThresholdNone),
+		"Adaptive threshold making the text pages bitonal (none, sauvola, niblack), "+
+			"the illustrations keep their grayscale",
+	)
+	flag.IntVar(
+		&cliArgs.Binarize.Window, "binarize-window", 25,
+		"Side of the region around each pixel giving its binarization threshold",
+	)
+	flag.Float64Var(
+		&cliArgs.Binarize.K, "binarize-k", 0,
+		"k parameter of the binarization threshold, zero uses 0.34 on sauvola and -0.2 on niblack",
+	)
 	flag.StringVar(
 		&readDirection, "read-direction",
-		inktypes.ReadLeftToRight.String(), "Read direction used as epub PPD",
+		inktypes.ReadLeftToRight.String(),
+		"Read direction (ltr, rtl, vertical) used when the book metadata does not tell it",
 	)
+	flag.BoolVar(
+		&cliArgs.ForceReadDirection, "force-read-direction", false,
+		"Use the read direction on all books, ignoring their metadata",
+	)
+	authors := parseBookMetadataArgs(&cliArgs.BookMetadata)
+	parseExclusion := parsePageExclusionArgs(&cliArgs.PageExclusion)
 	flag.Parse()
+	cliArgs.BookMetadata.Writers = splitList(*authors)
+	parseExclusion()
 
 	cliArgs.CropLevel = bootstrap.CropBasic
 	if cropLevel != nil {
@@ -46,6 +123,19 @@ func parseArgs(cliArgs *bootstrap.Options) {
 	cliArgs.OutputFormat = bootstrap.OutputFormat(outFormat)
 	cliArgs.ImageQuality = uint8(imgOutQuality)
 	cliArgs.ImageFormat = bootstrap.ImageFormat(imgOutFormat)
+	cliArgs.Dithering = bootstrap.DitherStyle(dithering)
+	cliArgs.Binarize.Method = bootstrap.ThresholdStyle(binarize)
+	cliArgs.Resampling = bootstrap.ResampleStyle(resampling)
+	cliArgs.FitMode = bootstrap.FitMode(fitMode)
+	if isFlagSet("stretch") && !isFlagSet("fit") {
+		cliArgs.FitMode = bootstrap.FitLetterbox
+		if *stretchImage {
+			cliArgs.FitMode = bootstrap.FitStretch
+		}
+	}
+	cliArgs.NoGutterPolicy = bootstrap.SpreadPolicy(noGutter)
+	cliArgs.SpreadPolicy = bootstrap.SpreadPolicy(spreadPolicy)
+	cliArgs.Sharpen.Threshold = uint8(min(*sharpenThreshold, 255))
 	if cliArgs.ImageFormat == bootstrap.ImagePNG {
 		cliArgs.ImageQuality = 100
 	}
@@ -67,6 +157,128 @@ func parseArgs(cliArgs *bootstrap.Options) {
 	if cliArgs.OutputFormat == "" {
 		cliErr(errors.New("output format is required"))
 	}
+	if err := validateBookMetadata(&cliArgs.BookMetadata); err != nil {
+		cliErr(err)
+	}
+}
+
+// parseBookMetadataArgs registers the flags of the book metadata, which apply to every
+// converted book. The authors are returned to be split after parsing.
+func parseBookMetadataArgs(metadata *inktypes.BookMetadata) (authors *string) {
+	flag.StringVar(&metadata.Title, "title", "", "Book title")
+	authors = flag.String("authors", "", "Comma separated list of the book authors")
+	flag.StringVar(&metadata.Series, "series", "", "Series the book belongs to")
+	flag.StringVar(&metadata.Number, "series-index", "", "Book position on the series")
+	flag.StringVar(&metadata.Language, "language", "", "Book language, as an ISO 639 code")
+	flag.StringVar(&metadata.Publisher, "publisher", "", "Book publisher")
+	flag.StringVar(&metadata.Summary, "description", "", "Book description")
+	flag.StringVar(
+		&metadata.PublishDate, "publish-date", "",
+		"Book publication date (YYYY, YYYY-MM or YYYY-MM-DD)",
+	)
+	flag.StringVar(&metadata.CoverImage, "cover", "", "Image file used as the book cover")
+	return authors
+}
+
+// parseAutoCropArgs registers the flags tuning the autocrop of the page borders.
+func parseAutoCropArgs(autoCrop *bootstrap.AutoCropOptions) {
+	flag.IntVar(
+		&autoCrop.BlurRadius, "crop-blur", 5,
+		"Radius of the blur hiding the scan noise before the page borders are searched",
+	)
+	flag.Float64Var(
+		&autoCrop.MinRetainedSize, "crop-min-size", 0.8,
+		"Smallest fraction (0-1) of each page side kept by the autocrop, bigger crops are skipped",
+	)
+	autoCrop.MarginRatio = flag.Float64(
+		"crop-margin", 1.6e-2,
+		"Fraction of the cropped box added back around it as margin, zero crops to the art edge",
+	)
+	flag.BoolVar(
+		&autoCrop.TrimTransparent, "crop-transparent", false,
+		"Crop the transparent borders, instead of the paper colored ones",
+	)
+	flag.BoolVar(
+		&autoCrop.IgnorePageNumbers, "crop-ignore-page-numbers", false,
+		"Ignore the page numbers and scan specks near the edges, cropping to the art edge",
+	)
+}
+
+// parsePageExclusionArgs registers the flags of the excluded pages, returning the function
+// that fills the options from them after parsing.
+func parsePageExclusionArgs(exclusion *bootstrap.ExclusionOptions) (fillOptions func()) {
+	include := flag.String(
+		"include", "", "Comma separated globs, when set only the matching pages are kept",
+	)
+	exclude := flag.String(
+		"exclude", bootstrap.DefaultExcludeGlob, "Comma separated globs of the dropped pages",
+	)
+	referenceImages := flag.String(
+		"exclude-images", "",
+		"Comma separated image files of credit pages, the pages looking alike are dropped",
+	)
+	maxHashDistance := flag.Int(
+		"exclude-distance", -1,
+		"Largest perceptual hash distance (0-64) to the excluded images, "+
+			"negative uses the default",
+	)
+	flag.BoolVar(
+		&exclusion.DropDuplicates, "drop-duplicates", false,
+		"Keep only the first occurrence, in name order, of the pages repeated on a book",
+	)
+	return func() {
+		exclusion.Include = splitList(*include)
+		exclusion.Exclude = splitList(*exclude)
+		exclusion.ReferenceImages = splitList(*referenceImages)
+		if *maxHashDistance >= 0 {
+			exclusion.MaxHashDistance = maxHashDistance
+		}
+	}
+}
+
+func validateBookMetadata(metadata *inktypes.BookMetadata) error {
+	if metadata.PublishDate != "" {
+		var validDate bool
+		for _, layout := range []string{time.DateOnly, "2006-01", "2006"} {
+			if _, err := time.Parse(layout, metadata.PublishDate); err == nil {
+				validDate = true
+				break
+			}
+		}
+		if !validDate {
+			return fmt.Errorf("invalid publish date `%s`", metadata.PublishDate)
+		}
+	}
+
+	if metadata.CoverImage != "" {
+		coverPath, err := filepath.Abs(metadata.CoverImage)
+		if err != nil {
+			return err
+		}
+		if _, err = os.Stat(coverPath); err != nil {
+			return fmt.Errorf("cover image not found: %w", err)
+		}
+		metadata.CoverImage = coverPath
+	}
+	return nil
+}
+
+// isFlagSet reports whether the flag was given on the command line.
+func isFlagSet(name string) (isSet bool) {
+	flag.Visit(func(setFlag *flag.Flag) {
+		isSet = isSet || setFlag.Name == name
+	})
+	return isSet
+}
+
+func splitList(value string) []string {
+	var values []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
 }
 
 func defaultOutputFolder(srcDir string) string {
@@ -13,13 +13,16 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
 	"github.com/Jictyvoo/ink_stream/internal/services/filextract"
 	"github.com/Jictyvoo/ink_stream/internal/services/filextract/cbxr"
 	"github.com/Jictyvoo/ink_stream/internal/services/imgprocessor"
 	"github.com/Jictyvoo/ink_stream/internal/services/mkbook"
+	"github.com/Jictyvoo/ink_stream/internal/services/mkbook/kf8mobi"
 	"github.com/Jictyvoo/ink_stream/internal/services/outdirwriter"
 	"github.com/Jictyvoo/ink_stream/internal/utils"
 	"github.com/Jictyvoo/ink_stream/pkg/bootstrap"
+	"github.com/Jictyvoo/ink_stream/pkg/deviceprof"
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
@@ -43,14 +46,29 @@ func main() {
 		sendChannel = make(chan filextract.FileInfo)
 	)
 
-	imgPipeline, err := bootstrap.BuildPipeline(cliArgs)
+	if inktypes.NewReadDirection(string(cliArgs.ReadDirection)) == inktypes.ReadUnknown {
+		slog.Error(
+			"Unknown read direction",
+			slog.String("direction", string(cliArgs.ReadDirection)),
+		)
+		os.Exit(1)
+	}
+	imgPipelines, err := buildPipelines(cliArgs)
 	if err != nil {
 		slog.Error("Failed to build pipeline", slog.String("error", err.Error()))
 		return
 	}
 
+	targetProfile, _ := deviceprof.Profile(cliArgs.TargetDevice)
+	exclusionRules, exclusionErr := cliArgs.PageExclusion.ExclusionRules()
+	if exclusionErr != nil {
+		slog.Error("Failed to build page exclusion", slog.String("error", exclusionErr.Error()))
+		os.Exit(1)
+	}
+
 	outWriterFactory, newWriterErr := fileWriterGenerator(
-		cliArgs.OutputFormat, cliArgs.ReadDirection,
+		cliArgs.OutputFormat,
+		cliArgs.TargetDevice,
 	)
 	if newWriterErr != nil {
 		slog.Error("Failed to create output writer", slog.String("error", newWriterErr.Error()))
@@ -62,17 +80,29 @@ func main() {
 		go func() {
 			fp := filextract.NewFileProcessorWorker(
 				sendChannel, cliArgs.OutputFolder,
-				func(outputDir string) (filextract.FileOutputWriter, error) {
-					fileWriter, constructErr := outWriterFactory(outputDir)
+				func(
+					outputDir string, metadata inktypes.BookMetadata,
+				) (filextract.FileOutputWriter, error) {
+					metadata = cliArgs.MergeBookMetadata(metadata)
+					direction := cliArgs.BookReadDirection(metadata)
+					fileWriter, constructErr := outWriterFactory(outputDir, direction)
 					imageProcessor := imgprocessor.NewMultiThreadImageProcessor(
-						imgPipeline,
+						imgPipelines[direction],
 						fileWriter, inktypes.NewImageEncodingOptions(
 							cliArgs.ImageQuality,
 							inktypes.ImageFormat(cliArgs.ImageFormat),
 						),
 					)
+					if direction == inktypes.ReadVertical {
+						imageProcessor.EnableStripSlicing(targetProfile.Resolution)
+					}
+					if cliArgs.ConsistentCrop {
+						imageProcessor.EnableSharedCrop()
+					}
+					imageProcessor.SetMetadata(metadata)
 					return imageProcessor, constructErr
 				},
+				exclusionRules,
 			)
 			defer wg.Done()
 			if processErr := fp.Run(); processErr != nil {
@@ -104,24 +134,84 @@ func main() {
 	log.Printf("Sent %d files", len(filenameList))
 }
 
-func fileWriterGenerator(
-	format bootstrap.OutputFormat, readDirection bootstrap.ReadDirection,
-) (func(outputDir string) (imgprocessor.FileWriter, error), error) {
-	direction := inktypes.NewReadDirection(string(readDirection))
-	if direction == inktypes.ReadUnknown {
-		return nil, fmt.Errorf("unknown read direction `%s`", readDirection)
+// buildPipelines builds one pipeline for each read direction, as it
+// sets the order of the pages split from a spread.
+func buildPipelines(
+	opts bootstrap.Options,
+) (map[inktypes.ReadDirection]imageparser.ImagePipeline, error) {
+	pipelines := make(map[inktypes.ReadDirection]imageparser.ImagePipeline, 3)
+	for _, direction := range []inktypes.ReadDirection{
+		inktypes.ReadLeftToRight, inktypes.ReadRightToLeft, inktypes.ReadVertical,
+	} {
+		opts.ReadDirection = bootstrap.ReadDirection(direction.String())
+		pipeline, err := bootstrap.BuildPipeline(opts)
+		if err != nil {
+			return nil, err
+		}
+		pipelines[direction] = pipeline
 	}
+
+	return pipelines, nil
+}
+
+type bookWriterFactory func(
+	outputDir string, direction inktypes.ReadDirection,
+) (imgprocessor.FileWriter, error)
+
+func fileWriterGenerator(
+	format bootstrap.OutputFormat, device deviceprof.DeviceType,
+) (bookWriterFactory, error) {
+	profile, _ := deviceprof.Profile(device)
 	switch format {
 	case bootstrap.FormatFolder:
-		return func(outputDir string) (imgprocessor.FileWriter, error) {
+		return func(
+			outputDir string, _ inktypes.ReadDirection,
+		) (imgprocessor.FileWriter, error) {
 			return outdirwriter.NewWriterHandle(outputDir)
 		}, nil
 	case bootstrap.FormatEpub:
-		return func(outputDir string) (imgprocessor.FileWriter, error) {
-			return mkbook.NewEpubMounter(outputDir, direction)
+		if device.IsKindle() {
+			return func(
+				outputDir string, direction inktypes.ReadDirection,
+			) (imgprocessor.FileWriter, error) {
+				return mkbook.NewKindleEpubMounter(outputDir, direction, profile.Resolution)
+			}, nil
+		}
+		return func(
+			outputDir string, direction inktypes.ReadDirection,
+		) (imgprocessor.FileWriter, error) {
+			return mkbook.NewEpubMounter(outputDir, direction, profile.Resolution)
+		}, nil
+	case bootstrap.FormatKepub:
+		return func(
+			outputDir string, direction inktypes.ReadDirection,
+		) (imgprocessor.FileWriter, error) {
+			return mkbook.NewKepubMounter(outputDir, direction, profile.Resolution)
 		}, nil
 	case bootstrap.FormatMobi:
-		return nil, errors.New("mobi format not supported yet")
+		return func(
+			outputDir string, direction inktypes.ReadDirection,
+		) (imgprocessor.FileWriter, error) {
+			return mkbook.NewMobiMounter(outputDir, direction, kf8mobi.LayoutJoint)
+		}, nil
+	case bootstrap.FormatAZW3:
+		return func(
+			outputDir string, direction inktypes.ReadDirection,
+		) (imgprocessor.FileWriter, error) {
+			return mkbook.NewMobiMounter(outputDir, direction, kf8mobi.LayoutKF8)
+		}, nil
+	case bootstrap.FormatPDF:
+		return func(
+			outputDir string, direction inktypes.ReadDirection,
+		) (imgprocessor.FileWriter, error) {
+			return mkbook.NewPDFMounter(outputDir, direction, profile.Resolution)
+		}, nil
+	case bootstrap.FormatCBZ:
+		return func(
+			outputDir string, direction inktypes.ReadDirection,
+		) (imgprocessor.FileWriter, error) {
+			return mkbook.NewCBZMounter(outputDir, direction)
+		}, nil
 	default:
 		return nil, errors.New("unknown output format")
 	}
@@ -6,14 +6,34 @@ import (
 	"slices"
 
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 type (
+	// PageContext is what is known about the page from the rest of its book.
+	PageContext struct {
+		// PageIndex is the position of the page on the book, in the order it was received
+		PageIndex int
+		// CropMargins is the crop shared by the pages of the book, as the fraction cut from
+		// each side of the page. When nil, each page is cropped on its own.
+		CropMargins *imgutils.Margins[float64]
+		// PixelArt tells if the page is pixel art, found on the page as it was received,
+		// before any step changes its colors. It's nil when the page wasn't analyzed.
+		PixelArt *bool
+	}
 	PipeState struct {
-		name      string
+		name string
+		PageContext
+		// Img is set to nil by the steps that drop the page
 		Img       image.Image
 		SubImages []image.Image
 	}
+	// CropAnalysis is the crop found on a page, before it is applied.
+	CropAnalysis struct {
+		Margins imgutils.Margins[float64]
+		// Orientation of the page when it's cropped
+		Orientation inktypes.ImageOrientation
+	}
 	ProcessOptions struct {
 		Gamma float64
 	}
@@ -61,9 +81,9 @@ func NewImagePipelineSplitStep(palette color.Palette, steps ...PipeStep) ImagePi
 }
 
 func (imgPipe ImagePipeline) processImage(
-	img image.Image, skipSteps []string,
+	img image.Image, page PageContext, skipSteps []string,
 ) (resultImg image.Image, subImages []image.Image, executedSteps []string, err error) {
-	state := PipeState{Img: img}
+	state := PipeState{Img: img, PageContext: page}
 	executedSteps = make([]string, 0, len(imgPipe.fullProcessSteps))
 	for _, step := range imgPipe.fullProcessSteps {
 		if slices.Contains(skipSteps, step.StepID()) {
@@ -73,6 +93,9 @@ func (imgPipe ImagePipeline) processImage(
 			return resultImg, subImages, executedSteps, err
 		}
 		executedSteps = append(executedSteps, step.StepID())
+		if state.Img == nil { // The page was dropped
+			return resultImg, subImages, executedSteps, err
+		}
 		if len(state.SubImages) > 0 {
 			subImages = append(subImages, state.SubImages...)
 			return resultImg, subImages, executedSteps, err
@@ -99,11 +122,25 @@ func (imgPipe ImagePipeline) processImage(
 }
 
 func (imgPipe ImagePipeline) Process(img image.Image) (outputImgs []image.Image, err error) {
+	return imgPipe.ProcessPage(img, PageContext{})
+}
+
+// ProcessPage runs the pipeline on a page of a book. It returns no images when the
+// page is dropped.
+func (imgPipe ImagePipeline) ProcessPage(
+	img image.Image, page PageContext,
+) (outputImgs []image.Image, err error) {
+	for _, step := range imgPipe.fullProcessSteps {
+		if analyzer, ok := step.(SourceAnalyzer); ok {
+			analyzer.AnalyzeSource(img, &page)
+		}
+	}
+
 	imgSlice := []image.Image{img}
 	var skipSteps []string
 	for index := 0; index < len(imgSlice); index++ {
 		singleImage, subImages, executedSteps, processErr := imgPipe.processImage(
-			imgSlice[index], skipSteps,
+			imgSlice[index], page, skipSteps,
 		)
 		if processErr != nil {
 			return nil, err
@@ -126,6 +163,33 @@ func (imgPipe ImagePipeline) Process(img image.Image) (outputImgs []image.Image,
 	return outputImgs, err
 }
 
+// AnalyzeCrop returns the crop that the cropping step of the pipeline would apply on the
+// page, as the fraction cut from each side, with the page orientation when it's cropped.
+// The steps before the cropping one run first, so the page is analyzed as it's cropped.
+// It returns false when the pipeline doesn't crop the page: it has no cropping step,
+// the page is dropped or split before it, or it has no content to find the crop from.
+func (imgPipe ImagePipeline) AnalyzeCrop(
+	img image.Image, page PageContext,
+) (analysis CropAnalysis, found bool, err error) {
+	page.CropMargins = nil
+	state := PipeState{Img: img, PageContext: page}
+	for _, step := range imgPipe.fullProcessSteps {
+		if analyzer, ok := step.(CropAnalyzer); ok {
+			analysis.Orientation = imgutils.NewOrientation(state.Img.Bounds())
+			analysis.Margins, found, err = analyzer.CropMargins(state.Img)
+			return analysis, found, err
+		}
+		if err = step.PerformExec(&state, imgPipe.opts); err != nil {
+			return analysis, false, err
+		}
+		if state.Img == nil || len(state.SubImages) > 0 {
+			return analysis, false, err
+		}
+	}
+
+	return analysis, false, err
+}
+
 func (imgPipe ImagePipeline) PipeSteps() []PipeStep {
 	return imgPipe.fullProcessSteps
 }
@@ -26,6 +26,17 @@ type (
 		paletteFactoryStep
 		stepIdentifier
 	}
+	// CropAnalyzer is implemented by the steps that crop the pages, telling the crop of
+	// a page without applying it. It returns false when the page has no content to find
+	// its crop from, like the blank pages.
+	CropAnalyzer interface {
+		CropMargins(img image.Image) (margins imgutils.Margins[float64], found bool, err error)
+	}
+	// SourceAnalyzer is implemented by the steps that need to know about the page as it was
+	// received. The pipeline gives it the page before running any step on it.
+	SourceAnalyzer interface {
+		AnalyzeSource(img image.Image, page *PageContext)
+	}
 )
 
 type BaseImageStep struct {
@@ -10,18 +10,54 @@ import (
 
 var _ imageparser.PipeStep = (*StepAutoCropImage)(nil)
 
+// blankCropMaxInkRatio is the largest fraction of ink on the pages without a crop to analyze
+const blankCropMaxInkRatio = 0.002
+
+// AutoCropOptions tunes how the crop box is found, the zero fields use the defaults.
+type AutoCropOptions struct {
+	// BlurRadius of the gaussian blur applied before the analysis, hiding the scan noise
+	BlurRadius int
+	// MinRetainedSize is the smallest fraction of each side kept, bigger crops are skipped
+	MinRetainedSize float64
+	// MarginRatio of the cropped box added back around it, nil uses the default and
+	// zero crops to the art edge
+	MarginRatio *float64
+	// BoxOptions tells what is cut from the page borders
+	BoxOptions imgutils.BoxOptions
+}
+
+func (opts AutoCropOptions) withDefaults() AutoCropOptions {
+	if opts.BlurRadius <= 0 {
+		opts.BlurRadius = 5
+	}
+	if opts.MinRetainedSize <= 0 {
+		opts.MinRetainedSize = 0.8
+	}
+	if opts.MarginRatio == nil {
+		marginRatio := 1.6e-2
+		opts.MarginRatio = &marginRatio
+	}
+	if opts.BoxOptions == 0 {
+		opts.BoxOptions = imgutils.BoxEliminateMinimumColor
+	}
+	return opts
+}
+
 type StepAutoCropImage struct {
 	palette     imgutils.ColorConverter
 	blurSubstep *StepApplyGaussianBlurImage
+	opts        AutoCropOptions
 	imageparser.BaseImageStep
 }
 
 func NewStepAutoCrop(
-	palette color.Palette,
+	palette color.Palette, opts AutoCropOptions,
 ) *StepAutoCropImage {
+	opts = opts.withDefaults()
 	return &StepAutoCropImage{
 		palette:       palette,
-		blurSubstep:   NewStepGaussianBlur(5),
+		blurSubstep:   NewStepGaussianBlur(opts.BlurRadius),
+		opts:          opts,
 		BaseImageStep: imageparser.NewBaseImageStep(palette),
 	}
 }
@@ -34,41 +70,66 @@ func (step StepAutoCropImage) PerformExec(
 	state *imageparser.PipeState,
 	_ imageparser.ProcessOptions,
 ) (err error) {
-	// Step 1: Crop the image to exclude unnecessary parts and add margins
-	originalImage := state.Img
-	originalBox := originalImage.Bounds()
-	desiredBox := originalBox
-	// Use gaussian blur to perform image crop
-	if err = step.blurSubstep.PerformExec(state, imageparser.ProcessOptions{}); err != nil {
-		state.Img = originalImage
+	// Step 1: Find the box to crop, shared by the book pages or from the page alone
+	originalBox := state.Img.Bounds()
+	var desiredBox image.Rectangle
+	if state.CropMargins != nil {
+		desiredBox = imgutils.ApplyCropMargins(originalBox, *state.CropMargins)
+	} else if desiredBox, err = step.cropBox(state.Img); err != nil {
 		return err
 	}
 
-	blurredImg := state.Img
-	state.Img = originalImage
-	croppedBox := imgutils.CropBox(blurredImg, step.palette, imgutils.BoxEliminateMinimumColor)
+	// Step 2: Check dimensions and apply logic based on width and height
+	if desiredBox != originalBox {
+		state.Img = imgutils.CropImage(state.Img, desiredBox)
+	}
+
+	return err
+}
+
+// CropMargins returns the fraction cut from each side of the page when it is cropped alone.
+// Blank pages have no content to find the crop from, so they aren't analyzed.
+func (step StepAutoCropImage) CropMargins(
+	img image.Image,
+) (margins imgutils.Margins[float64], found bool, err error) {
+	if imgutils.IsBlankPage(img, blankCropMaxInkRatio) {
+		return margins, false, err
+	}
+
+	desiredBox, err := step.cropBox(img)
+	return imgutils.CropMargins(img.Bounds(), desiredBox), err == nil, err
+}
+
+// cropBox finds the box excluding the unnecessary parts of the image, with a little margin.
+func (step StepAutoCropImage) cropBox(img image.Image) (desiredBox image.Rectangle, err error) {
+	originalBox := img.Bounds()
+	desiredBox = originalBox
+	// Use gaussian blur to perform image crop
+	blurState := imageparser.PipeState{Img: img}
+	if err = step.blurSubstep.PerformExec(&blurState, imageparser.ProcessOptions{}); err != nil {
+		return desiredBox, err
+	}
+
+	croppedBox := imgutils.CropBox(blurState.Img, step.palette, step.opts.BoxOptions)
 	if croppedBox != desiredBox {
-		// Prevent cropping if new dimensions are lower than 80% the original size
+		// Prevent cropping if new dimensions are lower than the minimum retained size
+		minRetained := int(step.opts.MinRetainedSize * 100)
 		originalSize := [2]int{desiredBox.Dx(), desiredBox.Dy()}
 		newSize := [2]int{croppedBox.Dx(), croppedBox.Dy()}
-		if (newSize[0]*100)/originalSize[0] >= 80 && (newSize[1]*100)/originalSize[1] >= 80 {
+		if (newSize[0]*100)/originalSize[0] >= minRetained &&
+			(newSize[1]*100)/originalSize[1] >= minRetained {
 			// Include a little margin on croppedBox
 			desiredBox = step.wrapInMargin(croppedBox, originalBox.Max)
 		}
 	}
 
-	// Step 2: Check dimensions and apply logic based on width and height
-	if desiredBox != originalBox {
-		state.Img = imgutils.CropImage(state.Img, desiredBox)
-	}
-
-	return err
+	return desiredBox, err
 }
 
 func (step StepAutoCropImage) wrapInMargin(
 	croppedBox image.Rectangle, limits image.Point,
 ) image.Rectangle {
-	desiredBox := imgutils.MarginBox(croppedBox, 1.6e-2)
+	desiredBox := imgutils.MarginBox(croppedBox, *step.opts.MarginRatio)
 	if desiredBox.Max.X > limits.X {
 		desiredBox.Max.X = limits.X
 	}
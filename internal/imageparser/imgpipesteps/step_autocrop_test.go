@@ -3,18 +3,24 @@ package imgpipesteps
 import (
 	"image"
 	"image/color"
+	"image/draw"
 	"testing"
 
 	"github.com/Jictyvoo/ink_stream/internal/imageparser"
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 func TestStepAutoCropImage_PerformExec(t *testing.T) {
+	marginRatio := func(ratio float64) *float64 { return &ratio }
 	tests := []struct {
 		name           string
 		imgSize        image.Rectangle
 		margins        imgutils.Margins[int]
+		extraInk       image.Rectangle
+		opts           AutoCropOptions
+		sharedCrop     *imgutils.Margins[float64]
 		expectedBounds image.Rectangle
 	}{
 		{
@@ -44,6 +50,81 @@ func TestStepAutoCropImage_PerformExec(t *testing.T) {
 			}, // content area too small
 			expectedBounds: image.Rect(0, 0, 100, 100),
 		},
+		{
+			name:    "Smaller minimum retained size allows the crop",
+			imgSize: image.Rect(0, 0, 100, 100),
+			margins: imgutils.Margins[int]{
+				Top:    10,
+				Bottom: 40,
+				Left:   10,
+				Right:  40,
+			},
+			opts:           AutoCropOptions{MinRetainedSize: 0.5},
+			expectedBounds: image.Rect(10, 10, 60, 60),
+		},
+		{
+			name:    "Bigger margin ratio",
+			imgSize: image.Rect(0, 0, 100, 100),
+			margins: imgutils.Margins[int]{
+				Top:    10,
+				Bottom: 10,
+				Left:   10,
+				Right:  10,
+			},
+			opts:           AutoCropOptions{MarginRatio: marginRatio(0.05)},
+			expectedBounds: image.Rect(6, 6, 94, 94),
+		},
+		{
+			name:    "Zero margin ratio crops to the art edge",
+			imgSize: image.Rect(0, 0, 100, 100),
+			margins: imgutils.Margins[int]{
+				Top:    10,
+				Bottom: 10,
+				Left:   10,
+				Right:  10,
+			},
+			opts:           AutoCropOptions{MarginRatio: marginRatio(0)},
+			expectedBounds: image.Rect(10, 10, 90, 90),
+		},
+		{
+			name:    "Page number blocks the crop",
+			imgSize: image.Rect(0, 0, 300, 400),
+			margins: imgutils.Margins[int]{
+				Top:    30,
+				Bottom: 30,
+				Left:   30,
+				Right:  30,
+			},
+			extraInk:       image.Rect(145, 383, 155, 391),
+			expectedBounds: image.Rect(27, 27, 273, 394),
+		},
+		{
+			name:    "Page number ignored by the crop",
+			imgSize: image.Rect(0, 0, 300, 400),
+			margins: imgutils.Margins[int]{
+				Top:    30,
+				Bottom: 30,
+				Left:   30,
+				Right:  30,
+			},
+			extraInk: image.Rect(145, 383, 155, 391),
+			opts: AutoCropOptions{
+				BoxOptions: imgutils.BoxEliminateMinimumColor | imgutils.BoxIgnoreEdgeBlobs,
+			},
+			expectedBounds: image.Rect(27, 27, 273, 373),
+		},
+		{
+			name:    "Shared crop replaces the page crop",
+			imgSize: image.Rect(0, 0, 100, 200),
+			margins: imgutils.Margins[int]{
+				Top:    10,
+				Bottom: 10,
+				Left:   10,
+				Right:  10,
+			},
+			sharedCrop:     &imgutils.Margins[float64]{Top: 0.1, Bottom: 0.05, Left: 0.2},
+			expectedBounds: image.Rect(20, 20, 100, 190),
+		},
 	}
 
 	palette := color.Palette{
@@ -59,9 +140,29 @@ func TestStepAutoCropImage_PerformExec(t *testing.T) {
 				tt.margins.Top, tt.margins.Bottom, tt.margins.Left, tt.margins.Right,
 				color.White, color.Black,
 			)
+			if !tt.extraInk.Empty() {
+				draw.Draw(img.(draw.Image), tt.extraInk, image.Black, image.Point{}, draw.Src)
+			}
 
-			state := &imageparser.PipeState{Img: img}
-			step := NewStepAutoCrop(palette)
+			state := &imageparser.PipeState{
+				Img:         img,
+				PageContext: imageparser.PageContext{CropMargins: tt.sharedCrop},
+			}
+			step := NewStepAutoCrop(palette, tt.opts)
+			if tt.sharedCrop == nil {
+				// The analyzed crop must be the one applied on the page
+				margins, found, err := step.CropMargins(img)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				box := img.Bounds() // Pages without content aren't analyzed, nor cropped
+				if found {
+					box = imgutils.ApplyCropMargins(img.Bounds(), margins)
+				}
+				if box != tt.expectedBounds {
+					t.Errorf("unexpected analyzed bounds: got %v, want %v", box, tt.expectedBounds)
+				}
+			}
 
 			if err := step.PerformExec(state, imageparser.ProcessOptions{}); err != nil {
 				t.Fatalf("unexpected error: %v", err)
@@ -74,3 +175,71 @@ func TestStepAutoCropImage_PerformExec(t *testing.T) {
 		})
 	}
 }
+
+func TestImagePipeline_AnalyzeCrop(t *testing.T) {
+	palette := color.Palette{color.White, color.Black}
+	spread := testimgs.NewBorderedImage(
+		image.Rect(0, 0, 200, 100), 10, 5, 20, 30, color.White, color.Black,
+	)
+	blankPage := testimgs.NewSolidImage(image.Rect(0, 0, 100, 100), color.White)
+	rotateSpreads := NewStepCropOrRotate(
+		SpreadLayout{Policy: SpreadRotate}, palette,
+		inktypes.ReadLeftToRight, inktypes.OrientationPortrait,
+	)
+
+	t.Run("Steps before the crop run first", func(t *testing.T) {
+		autocrop := NewStepAutoCrop(palette, AutoCropOptions{})
+		pipeline := imageparser.NewImagePipeline(palette, rotateSpreads, autocrop)
+		analysis, found, err := pipeline.AnalyzeCrop(spread, imageparser.PageContext{})
+		if err != nil || !found {
+			t.Fatalf("expected the crop to be found, got %v (error: %v)", found, err)
+		}
+		if analysis.Orientation != inktypes.OrientationPortrait {
+			t.Errorf("expected the rotated page to be portrait")
+		}
+
+		rotated := imgutils.RotateImage(spread, imgutils.Rotation90Degrees)
+		expected, _, _ := autocrop.CropMargins(rotated)
+		if analysis.Margins != expected {
+			t.Errorf("expected margins %+v, got %+v", expected, analysis.Margins)
+		}
+	})
+
+	testCases := []struct {
+		name     string
+		pipeline imageparser.ImagePipeline
+		img      image.Image
+	}{
+		{
+			name: "Dropped page",
+			pipeline: imageparser.NewImagePipeline(
+				palette, NewStepDropBlank(0.005, 0), NewStepAutoCrop(palette, AutoCropOptions{}),
+			),
+			img: blankPage,
+		},
+		{
+			name: "Blank page",
+			pipeline: imageparser.NewImagePipeline(
+				palette,
+				NewStepAutoCrop(palette, AutoCropOptions{}),
+			),
+			img: blankPage,
+		},
+		{
+			name:     "Pipeline without crop",
+			pipeline: imageparser.NewImagePipeline(palette, NewStepGrayScale()),
+			img:      spread,
+		},
+	}
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			_, found, err := tCase.pipeline.AnalyzeCrop(tCase.img, imageparser.PageContext{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if found {
+				t.Errorf("expected no crop to be found")
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package imgpipesteps
+
+import (
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+var _ imageparser.PipeStep = (*StepBinarizeImage)(nil)
+
+const (
+	// defaultBinarizeWindow is the side of the window of the local threshold, which must
+	// be wider than the text strokes
+	defaultBinarizeWindow = 25
+	defaultSauvolaK       = 0.34
+	defaultNiblackK       = -0.2
+)
+
+// StepBinarizeImage makes the text pages bitonal with an adaptive threshold, which is more
+// legible and smaller than the grays. The illustrations keep their grayscale.
+type StepBinarizeImage struct {
+	method imgutils.ThresholdMethod
+	window int
+	k      float64
+	imageparser.BaseImageStep
+}
+
+// NewStepBinarize creates the step with the given threshold window and k parameter,
+// when they are zero the defaults of the method are used.
+func NewStepBinarize(method imgutils.ThresholdMethod, window int, k float64) *StepBinarizeImage {
+	if window <= 0 {
+		window = defaultBinarizeWindow
+	}
+	if k == 0 {
+		k = defaultSauvolaK
+		if method == imgutils.ThresholdNiblack {
+			k = defaultNiblackK
+		}
+	}
+	return &StepBinarizeImage{method: method, window: window, k: k}
+}
+
+func (step StepBinarizeImage) StepID() string {
+	return "binarize"
+}
+
+func (step StepBinarizeImage) PerformExec(
+	state *imageparser.PipeState,
+	_ imageparser.ProcessOptions,
+) (err error) {
+	if imgutils.ClassifyPage(state.Img) != imgutils.PageText {
+		return err
+	}
+
+	state.Img = imgutils.AdaptiveThreshold(state.Img, step.method, step.window, step.k)
+	return err
+}
@@ -0,0 +1,74 @@
+package imgpipesteps
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+)
+
+func TestStepBinarizeImage_PerformExec(t *testing.T) {
+	// Gray text strokes over a gray paper
+	textPage := image.NewGray(image.Rect(0, 0, 120, 160))
+	for x, y := range imgutils.Iterator(textPage) {
+		textPage.SetGray(x, y, color.Gray{Y: 0xd0})
+		if y%16 >= 3 && y%16 < 13 && x%6 < 2 {
+			textPage.SetGray(x, y, color.Gray{Y: 0x30})
+		}
+	}
+	mangaPage := testimgs.ImageGenericMangaPage()
+
+	testCases := []struct {
+		name         string
+		inputImg     image.Image
+		method       imgutils.ThresholdMethod
+		expectBitone bool
+	}{
+		{
+			name:         "Text page with Sauvola",
+			inputImg:     textPage,
+			method:       imgutils.ThresholdSauvola,
+			expectBitone: true,
+		},
+		{
+			name:         "Text page with Niblack",
+			inputImg:     textPage,
+			method:       imgutils.ThresholdNiblack,
+			expectBitone: true,
+		},
+		{
+			name:     "Illustration keeps the grayscale",
+			inputImg: mangaPage,
+			method:   imgutils.ThresholdSauvola,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			state := imageparser.PipeState{Img: tCase.inputImg}
+			step := NewStepBinarize(tCase.method, 0, 0)
+			if err := step.PerformExec(&state, imageparser.ProcessOptions{}); err != nil {
+				t.Fatalf("PerformExec: %v", err.Error())
+			}
+
+			if !tCase.expectBitone {
+				if !imgutils.IsImageEqual(state.Img, tCase.inputImg) {
+					t.Error("expected the illustration to be unchanged")
+				}
+				return
+			}
+			for x, y := range imgutils.Iterator(state.Img) {
+				expected := color.Gray{Y: imgutils.MaxPixelValue}
+				if textPage.GrayAt(x, y).Y < 0x80 {
+					expected = color.Gray{}
+				}
+				if pixel := color.GrayModel.Convert(state.Img.At(x, y)); pixel != expected {
+					t.Fatalf("pixel (%d, %d): expected %v, got %v", x, y, expected, pixel)
+				}
+			}
+		})
+	}
+}
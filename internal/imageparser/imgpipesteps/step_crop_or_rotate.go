@@ -12,19 +12,61 @@ import (
 
 var _ imageparser.PipeStep = (*StepCropOrRotateImage)(nil)
 
+// SpreadPolicy tells what is done with a double-page spread that doesn't fit the device.
+type SpreadPolicy uint8
+
+const (
+	SpreadSplit SpreadPolicy = iota
+	SpreadRotate
+	SpreadKeep
+	// SpreadRotateSplit emits the full rotated spread, followed by both halves
+	SpreadRotateSplit
+)
+
+// SpreadLayout is how the spreads are laid out on the device pages.
+type SpreadLayout struct {
+	Policy SpreadPolicy
+	// NoGutterPolicy is applied on spreads that can't be split, as the art bleeds across the gutter
+	NoGutterPolicy SpreadPolicy
+}
+
+// KeepsSpreads tells if some spreads are kept whole, either by the policy or by the
+// NoGutterPolicy of a split layout.
+func (layout SpreadLayout) KeepsSpreads() bool {
+	return layout.Policy == SpreadKeep ||
+		(layout.Policy == SpreadSplit && layout.NoGutterPolicy == SpreadKeep)
+}
+
+// splitPosition returns where the spread is split, which is its gutter, or its center when
+// it has no gutter and the NoGutterPolicy still splits it.
+func (layout SpreadLayout) splitPosition(
+	img image.Image, imgOrientation inktypes.ImageOrientation,
+) (position int, split bool) {
+	if gutter, found := imgutils.FindGutter(img, imgOrientation); found {
+		return gutter, true
+	}
+
+	bounds := img.Bounds()
+	position = bounds.Min.X + bounds.Dx()/2
+	if imgOrientation == inktypes.OrientationPortrait {
+		position = bounds.Min.Y + bounds.Dy()/2
+	}
+	return position, layout.NoGutterPolicy == SpreadSplit
+}
+
 type StepCropOrRotateImage struct {
-	rotateImage   bool
+	layout        SpreadLayout
 	orientation   inktypes.ImageOrientation
 	readDirection inktypes.ReadDirection
 	imageparser.BaseImageStep
 }
 
 func NewStepCropOrRotate(
-	rotate bool, palette color.Palette,
+	layout SpreadLayout, palette color.Palette,
 	readDirection inktypes.ReadDirection, orientation inktypes.ImageOrientation,
 ) *StepCropOrRotateImage {
 	return &StepCropOrRotateImage{
-		rotateImage:   rotate,
+		layout:        layout,
 		orientation:   orientation,
 		readDirection: readDirection,
 		BaseImageStep: imageparser.NewBaseImageStep(palette),
@@ -38,36 +80,63 @@ func (step StepCropOrRotateImage) StepID() string {
 func (step StepCropOrRotateImage) PerformExec(
 	state *imageparser.PipeState, _ imageparser.ProcessOptions,
 ) (err error) {
-	originalBounds := state.Img.Bounds()
-	imgOrientation := imgutils.NewOrientation(originalBounds)
-
-	if step.orientation != imgOrientation {
-		if step.rotateImage {
-			// Rotate the image if rotateImage is true
-			state.Img = imgutils.RotateImage(state.Img, imgutils.Rotation90Degrees)
-		} else {
-			// Cut the image in half horizontally
-			halfBounds := imgutils.HalfSplit(originalBounds, imgOrientation)
-
-			originalImg := state.Img
-			switch step.orientation {
-			case inktypes.OrientationLandscape:
-				state.SubImages = []image.Image{
-					imgutils.CropImage(originalImg, halfBounds.Top),
-					imgutils.CropImage(originalImg, halfBounds.Bottom),
-				}
-			case inktypes.OrientationPortrait:
-				state.SubImages = []image.Image{
-					imgutils.CropImage(originalImg, halfBounds.Left),
-					imgutils.CropImage(originalImg, halfBounds.Right),
-				}
-
-				if step.readDirection == inktypes.ReadRightToLeft {
-					slices.Reverse(state.SubImages)
-				}
-			}
+	imgOrientation := imgutils.NewOrientation(state.Img.Bounds())
+	if step.orientation == imgOrientation {
+		return err
+	}
+
+	switch step.layout.Policy {
+	case SpreadKeep:
+		return err
+	case SpreadRotate:
+		state.Img = imgutils.RotateImage(state.Img, imgutils.Rotation90Degrees)
+		return err
+	case SpreadRotateSplit:
+		halves := step.splitHalves(state.Img, imgOrientation)
+		state.Img = imgutils.RotateImage(state.Img, imgutils.Rotation90Degrees)
+		if len(halves) > 0 {
+			state.SubImages = append([]image.Image{state.Img}, halves...)
 		}
+		return err
+	}
+
+	// Cut the image on its gutter, when it has no gutter the NoGutterPolicy is applied
+	if halves := step.splitHalves(state.Img, imgOrientation); len(halves) > 0 {
+		state.SubImages = halves
+	} else if step.layout.NoGutterPolicy == SpreadRotate {
+		state.Img = imgutils.RotateImage(state.Img, imgutils.Rotation90Degrees)
 	}
 
 	return err
 }
+
+// splitHalves splits the spread on its reading order, it returns no halves when
+// the spread must not be split.
+func (step StepCropOrRotateImage) splitHalves(
+	img image.Image, imgOrientation inktypes.ImageOrientation,
+) []image.Image {
+	position, split := step.layout.splitPosition(img, imgOrientation)
+	if !split {
+		return nil
+	}
+
+	halfBounds := imgutils.SplitAt(img.Bounds(), imgOrientation, position)
+	switch step.orientation {
+	case inktypes.OrientationLandscape:
+		return []image.Image{
+			imgutils.CropImage(img, halfBounds.Top),
+			imgutils.CropImage(img, halfBounds.Bottom),
+		}
+	case inktypes.OrientationPortrait:
+		halves := []image.Image{
+			imgutils.CropImage(img, halfBounds.Left),
+			imgutils.CropImage(img, halfBounds.Right),
+		}
+		if step.readDirection == inktypes.ReadRightToLeft {
+			slices.Reverse(halves)
+		}
+		return halves
+	}
+
+	return nil
+}
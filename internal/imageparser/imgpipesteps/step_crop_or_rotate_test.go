@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
@@ -15,14 +16,16 @@ func TestStepCropOrRotateImage_PerformExec(t *testing.T) {
 	tests := []struct {
 		name                string
 		initialBounds       image.Rectangle
-		rotateImage         bool
+		layout              SpreadLayout
+		readDirection       inktypes.ReadDirection
+		inputImg            image.Image
 		expectedOrientation inktypes.ImageOrientation
 		expectedBounds      []image.Rectangle
 	}{
 		{
 			name:                "Rotate Portrait Image",
 			initialBounds:       image.Rect(0, 0, 200, 100),
-			rotateImage:         true,
+			layout:              SpreadLayout{Policy: SpreadRotate},
 			expectedOrientation: inktypes.OrientationPortrait,
 			expectedBounds: []image.Rectangle{
 				image.Rect(0, 0, 100, 200),
@@ -31,25 +34,99 @@ func TestStepCropOrRotateImage_PerformExec(t *testing.T) {
 		{
 			name:                "Crop Portrait Image without Rotation",
 			initialBounds:       image.Rect(0, 0, 200, 100),
-			rotateImage:         false,
+			layout:              SpreadLayout{Policy: SpreadSplit},
 			expectedOrientation: inktypes.OrientationPortrait,
 			expectedBounds: []image.Rectangle{
 				image.Rect(0, 0, 100, 100),
 				image.Rect(100, 0, 200, 100),
 			}, // Cropped to square dimensions
 		},
+		{
+			name:                "Split on an off-center gutter",
+			inputImg:            spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{84, 90}),
+			expectedOrientation: inktypes.OrientationPortrait,
+			expectedBounds: []image.Rectangle{
+				image.Rect(0, 0, 87, 100),
+				image.Rect(87, 0, 200, 100),
+			},
+		},
+		{
+			name:                "Split on the gutter in right to left order",
+			inputImg:            spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{84, 90}),
+			readDirection:       inktypes.ReadRightToLeft,
+			expectedOrientation: inktypes.OrientationPortrait,
+			expectedBounds: []image.Rectangle{
+				image.Rect(87, 0, 200, 100),
+				image.Rect(0, 0, 87, 100),
+			},
+		},
+		{
+			name:                "Rotate and split the spread",
+			inputImg:            spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{84, 90}),
+			layout:              SpreadLayout{Policy: SpreadRotateSplit},
+			expectedOrientation: inktypes.OrientationPortrait,
+			expectedBounds: []image.Rectangle{
+				image.Rect(0, 0, 100, 200),
+				image.Rect(0, 0, 87, 100),
+				image.Rect(87, 0, 200, 100),
+			},
+		},
+		{
+			name:     "Rotate and keep the spread without gutter",
+			inputImg: spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{}),
+			layout: SpreadLayout{
+				Policy:         SpreadRotateSplit,
+				NoGutterPolicy: SpreadKeep,
+			},
+			expectedOrientation: inktypes.OrientationPortrait,
+			expectedBounds:      []image.Rectangle{image.Rect(0, 0, 100, 200)},
+		},
+		{
+			name:                "Keep the whole spread",
+			inputImg:            spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{84, 90}),
+			layout:              SpreadLayout{Policy: SpreadKeep},
+			expectedOrientation: inktypes.OrientationPortrait,
+			expectedBounds:      []image.Rectangle{image.Rect(0, 0, 200, 100)},
+		},
+		{
+			name:                "Rotate spread without gutter",
+			inputImg:            spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{}),
+			layout:              SpreadLayout{NoGutterPolicy: SpreadRotate},
+			expectedOrientation: inktypes.OrientationPortrait,
+			expectedBounds:      []image.Rectangle{image.Rect(0, 0, 100, 200)},
+		},
+		{
+			name:                "Keep spread without gutter",
+			inputImg:            spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{}),
+			layout:              SpreadLayout{NoGutterPolicy: SpreadKeep},
+			expectedOrientation: inktypes.OrientationPortrait,
+			expectedBounds:      []image.Rectangle{image.Rect(0, 0, 200, 100)},
+		},
+		{
+			name:                "Split spread without gutter in half",
+			inputImg:            spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{}),
+			layout:              SpreadLayout{NoGutterPolicy: SpreadSplit},
+			expectedOrientation: inktypes.OrientationPortrait,
+			expectedBounds: []image.Rectangle{
+				image.Rect(0, 0, 100, 100),
+				image.Rect(100, 0, 200, 100),
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a solid-colored test image
-			img := testimgs.NewSolidImage(tt.initialBounds, color.White)
+			// Create a solid-colored test image, when there is no input image
+			img := tt.inputImg
+			if img == nil {
+				img = testimgs.NewSolidImage(tt.initialBounds, color.White)
+			}
 			state := &imageparser.PipeState{Img: img}
 
 			// Instantiate the StepCropOrRotateImage step
 			step := NewStepCropOrRotate(
-				tt.rotateImage, color.Palette{color.Black, color.White},
-				inktypes.ReadLeftToRight, tt.expectedOrientation,
+				tt.layout, color.Palette{color.Black, color.White},
+				tt.readDirection, tt.expectedOrientation,
 			)
 
 			// Execute the step
@@ -60,10 +137,13 @@ func TestStepCropOrRotateImage_PerformExec(t *testing.T) {
 			// Validate the resulting image bounds
 			resultBounds := state.Img.Bounds()
 			compareImgs := state.SubImages
-			if len(tt.expectedBounds) < 2 {
+			if len(compareImgs) == 0 {
 				compareImgs = append(compareImgs, state.Img)
 			}
 
+			if len(compareImgs) != len(tt.expectedBounds) {
+				t.Fatalf("expected %d images, got %d", len(tt.expectedBounds), len(compareImgs))
+			}
 			for index, expected := range tt.expectedBounds {
 				if compareImgs[index].Bounds() != expected {
 					t.Errorf(
@@ -75,3 +155,16 @@ func TestStepCropOrRotateImage_PerformExec(t *testing.T) {
 		})
 	}
 }
+
+// spreadWithGutter draws art on the whole spread, except on the gutter columns
+func spreadWithGutter(bounds image.Rectangle, gutter [2]int) image.Image {
+	img := image.NewGray(bounds)
+	for x, y := range imgutils.Iterator(img) {
+		luminance := uint8((x*37 + y*91) % 256)
+		if x >= gutter[0] && x < gutter[1] {
+			luminance = 0xff
+		}
+		img.SetGray(x, y, color.Gray{Y: luminance})
+	}
+	return img
+}
@@ -0,0 +1,47 @@
+package imgpipesteps
+
+import (
+	"image"
+	"math"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+var _ imageparser.PipeStep = (*StepDeskewImage)(nil)
+
+// minDeskewAngle is the lowest skew angle, in degrees, that is worth a rotation
+const minDeskewAngle = 0.2
+
+// StepDeskewImage straightens scanned pages that are slightly rotated, which is required
+// to let the autocrop remove the slanted borders.
+type StepDeskewImage struct {
+	maxAngle float64
+	imageparser.BaseImageStep
+}
+
+func NewStepDeskew(maxAngle float64) *StepDeskewImage {
+	return &StepDeskewImage{maxAngle: maxAngle}
+}
+
+func (step StepDeskewImage) StepID() string {
+	return "deskew"
+}
+
+func (step StepDeskewImage) PerformExec(
+	state *imageparser.PipeState,
+	_ imageparser.ProcessOptions,
+) (err error) {
+	skewAngle := imgutils.EstimateSkewAngle(state.Img, step.maxAngle)
+	if math.Abs(skewAngle) < minDeskewAngle {
+		return err
+	}
+
+	// The uncovered corners are painted with the color of the page top border
+	bounds := state.Img.Bounds()
+	topBorder := image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Min.Y+1)
+	fillColor := imgutils.DominantColorInRegion(state.Img, topBorder, true)
+
+	state.Img = imgutils.RotateImageAngle(state.Img, -skewAngle, fillColor)
+	return err
+}
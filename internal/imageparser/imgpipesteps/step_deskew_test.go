@@ -0,0 +1,61 @@
+package imgpipesteps
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+func TestStepDeskewImage_PerformExec(t *testing.T) {
+	textPage := image.NewGray(image.Rect(0, 0, 600, 400))
+	draw.Draw(textPage, textPage.Bounds(), image.White, image.Point{}, draw.Src)
+	for lineY := 40; lineY < 360; lineY += 20 {
+		line := image.Rect(30, lineY, 570, lineY+4)
+		draw.Draw(textPage, line, image.Black, image.Point{}, draw.Src)
+	}
+
+	testCases := []struct {
+		name          string
+		inputImg      image.Image
+		expectChanged bool
+	}{
+		{
+			name:          "Straight page is kept",
+			inputImg:      textPage,
+			expectChanged: false,
+		},
+		{
+			name:          "Skewed page is straightened",
+			inputImg:      imgutils.RotateImageAngle(textPage, 1.5, color.White),
+			expectChanged: true,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			step := NewStepDeskew(3)
+			state := imageparser.PipeState{Img: tCase.inputImg}
+			if err := step.PerformExec(&state, imageparser.ProcessOptions{}); err != nil {
+				t.Fatalf("PerformExec: %v", err.Error())
+			}
+
+			if changed := state.Img != tCase.inputImg; changed != tCase.expectChanged {
+				t.Fatalf("expected image change to be %v", tCase.expectChanged)
+			}
+			if !state.Img.Bounds().Eq(tCase.inputImg.Bounds()) {
+				t.Errorf(
+					"bounds changed from %v to %v",
+					tCase.inputImg.Bounds(),
+					state.Img.Bounds(),
+				)
+			}
+			if angle := imgutils.EstimateSkewAngle(state.Img, 3); angle != 0 {
+				t.Errorf("expected a straight page, found skew of %.1f degrees", angle)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package imgpipesteps
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+var _ imageparser.PipeStep = (*StepDitherImage)(nil)
+
+type DitherMode uint8
+
+const (
+	DitherNone DitherMode = iota
+	DitherFloydSteinberg
+	DitherAtkinson
+	DitherBayer
+)
+
+type diffusionWeight struct {
+	dx, dy int
+	weight float64
+}
+
+var (
+	floydSteinbergKernel = [...]diffusionWeight{
+		{dx: 1, dy: 0, weight: 7.0 / 16},
+		{dx: -1, dy: 1, weight: 3.0 / 16},
+		{dx: 0, dy: 1, weight: 5.0 / 16},
+		{dx: 1, dy: 1, weight: 1.0 / 16},
+	}
+	// Atkinson only spreads 6/8 of the error, which keeps flat regions clean
+	atkinsonKernel = [...]diffusionWeight{
+		{dx: 1, dy: 0, weight: 1.0 / 8},
+		{dx: 2, dy: 0, weight: 1.0 / 8},
+		{dx: -1, dy: 1, weight: 1.0 / 8},
+		{dx: 0, dy: 1, weight: 1.0 / 8},
+		{dx: 1, dy: 1, weight: 1.0 / 8},
+		{dx: 0, dy: 2, weight: 1.0 / 8},
+	}
+	bayerMatrix = [4][4]float64{
+		{0, 8, 2, 10},
+		{12, 4, 14, 6},
+		{3, 11, 1, 9},
+		{15, 7, 13, 5},
+	}
+)
+
+// StepDitherImage quantizes the image to the device palette, spreading the
+// quantization error to avoid the banding on gradients and screentones.
+type StepDitherImage struct {
+	mode    DitherMode
+	palette color.Palette
+	imageparser.BaseImageStep
+}
+
+func NewStepDither(mode DitherMode, palette color.Palette) *StepDitherImage {
+	return &StepDitherImage{mode: mode, palette: palette}
+}
+
+func (step StepDitherImage) StepID() string {
+	return "dither"
+}
+
+func (step StepDitherImage) PerformExec(
+	state *imageparser.PipeState,
+	_ imageparser.ProcessOptions,
+) (err error) {
+	if step.mode == DitherNone || len(step.palette) == 0 {
+		return err
+	}
+
+	newImg := step.DrawImage(state.Img.ColorModel(), state.Img.Bounds())
+	switch step.mode {
+	case DitherAtkinson:
+		step.diffuseError(state.Img, newImg, atkinsonKernel[:])
+	case DitherBayer:
+		step.orderedDither(state.Img, newImg)
+	default:
+		step.diffuseError(state.Img, newImg, floydSteinbergKernel[:])
+	}
+
+	state.Img = newImg
+	return err
+}
+
+func (step StepDitherImage) diffuseError(
+	img image.Image, newImg draw.Image, kernel []diffusionWeight,
+) {
+	var (
+		bounds = img.Bounds()
+		width  = bounds.Dx()
+		// Only the current row and the next two ones receive the error
+		rowErrors [3][][3]float64
+	)
+	for index := range rowErrors {
+		rowErrors[index] = make([][3]float64, width)
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			column := x - bounds.Min.X
+			rgb, alpha := step.pixelChannels(img.At(x, y))
+			for channel := range rgb {
+				rgb[channel] += rowErrors[0][column][channel]
+			}
+
+			quantized := step.nearestColor(rgb, alpha)
+			quantizedRGB, _ := step.pixelChannels(quantized)
+			for _, diffusion := range kernel {
+				target := column + diffusion.dx
+				if target < 0 || target >= width {
+					continue
+				}
+				for channel := range rgb {
+					quantErr := rgb[channel] - quantizedRGB[channel]
+					rowErrors[diffusion.dy][target][channel] += quantErr * diffusion.weight
+				}
+			}
+
+			newImg.Set(x, y, quantized)
+		}
+
+		rowErrors[0], rowErrors[1], rowErrors[2] = rowErrors[1], rowErrors[2], rowErrors[0]
+		clear(rowErrors[2])
+	}
+}
+
+func (step StepDitherImage) orderedDither(img image.Image, newImg draw.Image) {
+	// The threshold offset covers the distance between two palette levels
+	spread := float64(imgutils.MaxPixelValue)
+	if len(step.palette) > 1 {
+		spread /= float64(len(step.palette) - 1)
+	}
+
+	for x, y := range imgutils.Iterator(img) {
+		rgb, alpha := step.pixelChannels(img.At(x, y))
+		threshold := (bayerMatrix[y&3][x&3]+0.5)/16 - 0.5
+		for channel := range rgb {
+			rgb[channel] += threshold * spread
+		}
+
+		newImg.Set(x, y, step.nearestColor(rgb, alpha))
+	}
+}
+
+func (step StepDitherImage) pixelChannels(imgColor color.Color) ([3]float64, uint8) {
+	r, g, b, a := imgColor.RGBA()
+	return [3]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)}, uint8(a >> 8)
+}
+
+func (step StepDitherImage) nearestColor(rgb [3]float64, alpha uint8) color.Color {
+	return step.palette.Convert(color.RGBA{
+		R: imgutils.NormalizePixel(math.Round(rgb[0])),
+		G: imgutils.NormalizePixel(math.Round(rgb[1])),
+		B: imgutils.NormalizePixel(math.Round(rgb[2])),
+		A: alpha,
+	})
+}
@@ -0,0 +1,98 @@
+package imgpipesteps
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+func TestStepDitherImage_PerformExec(t *testing.T) {
+	var (
+		blackWhite = color.Palette{color.Black, color.White}
+		fourGrays  = color.Palette{
+			color.Gray{Y: 0x00}, color.Gray{Y: 0x55}, color.Gray{Y: 0xaa}, color.Gray{Y: 0xff},
+		}
+		flatGray = func(width, height int) *image.Gray {
+			img := image.NewGray(image.Rect(0, 0, width, height))
+			for index := range img.Pix {
+				img.Pix[index] = 0x80
+			}
+			return img
+		}
+	)
+
+	testCases := []struct {
+		name        string
+		mode        DitherMode
+		palette     color.Palette
+		inputImg    image.Image
+		expectedImg image.Image
+	}{
+		{
+			name:        "Disabled dithering keeps the image",
+			mode:        DitherNone,
+			palette:     blackWhite,
+			inputImg:    flatGray(2, 2),
+			expectedImg: flatGray(2, 2),
+		},
+		{
+			name:     "Floyd-Steinberg on flat gray",
+			mode:     DitherFloydSteinberg,
+			palette:  blackWhite,
+			inputImg: flatGray(2, 2),
+			expectedImg: &image.Gray{
+				Pix: []uint8{0xff, 0x0, 0x0, 0xff}, Stride: 2, Rect: image.Rect(0, 0, 2, 2),
+			},
+		},
+		{
+			name:     "Atkinson on flat gray",
+			mode:     DitherAtkinson,
+			palette:  blackWhite,
+			inputImg: flatGray(2, 2),
+			expectedImg: &image.Gray{
+				Pix: []uint8{0xff, 0x0, 0x0, 0xff}, Stride: 2, Rect: image.Rect(0, 0, 2, 2),
+			},
+		},
+		{
+			name:     "Bayer on flat gray",
+			mode:     DitherBayer,
+			palette:  blackWhite,
+			inputImg: flatGray(4, 4),
+			expectedImg: &image.Gray{
+				Pix: []uint8{
+					0x0, 0xff, 0x0, 0xff,
+					0xff, 0x0, 0xff, 0x0,
+					0x0, 0xff, 0x0, 0xff,
+					0xff, 0x0, 0xff, 0x0,
+				},
+				Stride: 4, Rect: image.Rect(0, 0, 4, 4),
+			},
+		},
+		{
+			name:     "Floyd-Steinberg on multi-level palette",
+			mode:     DitherFloydSteinberg,
+			palette:  fourGrays,
+			inputImg: flatGray(1, 1),
+			expectedImg: &image.Gray{
+				Pix: []uint8{0xaa}, Stride: 1, Rect: image.Rect(0, 0, 1, 1),
+			},
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			step := NewStepDither(tCase.mode, tCase.palette)
+			state := imageparser.PipeState{Img: tCase.inputImg}
+			if err := step.PerformExec(&state, imageparser.ProcessOptions{}); err != nil {
+				t.Fatalf("PerformExec: %v", err.Error())
+			}
+
+			if !imgutils.IsImageEqual(state.Img, tCase.expectedImg) {
+				t.Errorf("expected: %#v, actual: %#v", tCase.expectedImg, state.Img)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package imgpipesteps
+
+import (
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+var _ imageparser.PipeStep = (*StepDropBlankImage)(nil)
+
+// StepDropBlankImage drops the blank and nearly blank pages, like blank versos and
+// separator pages. The first pages are always kept, so the covers survive.
+type StepDropBlankImage struct {
+	maxInkRatio float64
+	keepFirst   int
+	imageparser.BaseImageStep
+}
+
+func NewStepDropBlank(maxInkRatio float64, keepFirst int) *StepDropBlankImage {
+	return &StepDropBlankImage{maxInkRatio: maxInkRatio, keepFirst: keepFirst}
+}
+
+func (step StepDropBlankImage) StepID() string {
+	return "drop_blank"
+}
+
+func (step StepDropBlankImage) PerformExec(
+	state *imageparser.PipeState,
+	_ imageparser.ProcessOptions,
+) (err error) {
+	if state.PageIndex < step.keepFirst {
+		return err
+	}
+
+	if imgutils.IsBlankPage(state.Img, step.maxInkRatio) {
+		state.Img = nil
+	}
+	return err
+}
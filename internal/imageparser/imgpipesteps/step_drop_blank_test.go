@@ -0,0 +1,94 @@
+package imgpipesteps
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+)
+
+func TestStepDropBlankImage_PerformExec(t *testing.T) {
+	blankPage := testimgs.NewSolidImage(image.Rect(0, 0, 40, 60), color.White)
+	drawnPage := image.NewGray(image.Rect(0, 0, 40, 60))
+	draw.Draw(drawnPage, drawnPage.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(drawnPage, image.Rect(5, 5, 30, 40), image.Black, image.Point{}, draw.Src)
+
+	testCases := []struct {
+		name        string
+		inputImg    image.Image
+		pageIndex   int
+		keepFirst   int
+		expectDrop  bool
+		maxInkRatio float64
+	}{
+		{
+			name:        "Blank page is dropped",
+			inputImg:    blankPage,
+			pageIndex:   4,
+			keepFirst:   1,
+			maxInkRatio: 0.005,
+			expectDrop:  true,
+		},
+		{
+			name:        "Blank cover is kept",
+			inputImg:    blankPage,
+			pageIndex:   0,
+			keepFirst:   1,
+			maxInkRatio: 0.005,
+			expectDrop:  false,
+		},
+		{
+			name:        "Blank page inside the guard is kept",
+			inputImg:    blankPage,
+			pageIndex:   2,
+			keepFirst:   3,
+			maxInkRatio: 0.005,
+			expectDrop:  false,
+		},
+		{
+			name:        "Drawn page is kept",
+			inputImg:    drawnPage,
+			pageIndex:   4,
+			keepFirst:   1,
+			maxInkRatio: 0.005,
+			expectDrop:  false,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			step := NewStepDropBlank(tCase.maxInkRatio, tCase.keepFirst)
+			state := imageparser.PipeState{
+				Img:         tCase.inputImg,
+				PageContext: imageparser.PageContext{PageIndex: tCase.pageIndex},
+			}
+			if err := step.PerformExec(&state, imageparser.ProcessOptions{}); err != nil {
+				t.Fatalf("PerformExec: %v", err.Error())
+			}
+
+			if dropped := state.Img == nil; dropped != tCase.expectDrop {
+				t.Errorf("expected dropped to be %v, got %v", tCase.expectDrop, dropped)
+			}
+		})
+	}
+
+	t.Run("Pipeline returns no image for dropped pages", func(t *testing.T) {
+		pipeline := imageparser.NewImagePipeline(
+			nil,
+			NewStepDropBlank(0.005, 1),
+			NewStepGrayScale(),
+		)
+		outputImgs, err := pipeline.ProcessPage(
+			blankPage, imageparser.PageContext{PageIndex: 3},
+		)
+		if err != nil {
+			t.Fatalf("ProcessPage: %v", err.Error())
+		}
+		if len(outputImgs) != 0 {
+			t.Errorf("expected no output images, got %d", len(outputImgs))
+		}
+	})
+}
@@ -15,17 +15,36 @@ var _ imageparser.PipeStep = (*StepMarginWrapImage)(nil)
 
 type StepMarginWrapImage struct {
 	resolution  inktypes.ImageDimensions
+	fitMode     FitMode
 	marginColor color.Color
+	// spreadLayout tells if the spreads are split after the margin wrap, or kept whole
+	spreadLayout SpreadLayout
+	// keptSpreadsOnly wraps only the spreads kept whole, leaving the other pages as they are
+	keptSpreadsOnly bool
 	imageparser.BaseImageStep
 }
 
-func NewStepMarginWrap(resolution inktypes.ImageDimensions) *StepMarginWrapImage {
+func NewStepMarginWrap(
+	resolution inktypes.ImageDimensions, fitMode FitMode, spreadLayout SpreadLayout,
+) *StepMarginWrapImage {
 	return &StepMarginWrapImage{
-		resolution:  resolution,
-		marginColor: color.White,
+		resolution:   resolution,
+		fitMode:      fitMode,
+		spreadLayout: spreadLayout,
+		marginColor:  color.White,
 	}
 }
 
+// NewStepKeptSpreadWrap creates a StepMarginWrapImage that only wraps the spreads kept
+// whole, so they keep their aspect on the screen when the pages don't receive margins.
+func NewStepKeptSpreadWrap(
+	resolution inktypes.ImageDimensions, fitMode FitMode, spreadLayout SpreadLayout,
+) *StepMarginWrapImage {
+	step := NewStepMarginWrap(resolution, fitMode, spreadLayout)
+	step.keptSpreadsOnly = true
+	return step
+}
+
 func (step StepMarginWrapImage) StepID() string {
 	return "wrap_in_margin"
 }
@@ -38,18 +57,31 @@ func (step StepMarginWrapImage) PerformExec(
 	sttImg := state.Img
 	imgBounds := sttImg.Bounds()
 	origBounds := imgBounds
+	isKeptSpread := false
 	{
 		expectedOrientation := step.resolution.Orientation()
-		if imgOrientation := imgutils.NewOrientation(imgBounds); expectedOrientation != imgOrientation {
-			halfBounds := imgutils.HalfSplit(imgBounds, imgOrientation)
-			switch expectedOrientation { // Temporarily split
-			case inktypes.OrientationPortrait:
-				imgBounds = halfBounds.Left
-			case inktypes.OrientationLandscape:
-				imgBounds = halfBounds.Top
+		imgOrientation := imgutils.NewOrientation(imgBounds)
+		if expectedOrientation != imgOrientation {
+			policy := step.spreadPolicy(sttImg, imgOrientation)
+			isKeptSpread = policy == SpreadKeep
+			switch policy {
+			case SpreadSplit:
+				halfBounds := imgutils.HalfSplit(imgBounds, imgOrientation)
+				switch expectedOrientation { // Temporarily split
+				case inktypes.OrientationPortrait:
+					imgBounds = halfBounds.Left
+				case inktypes.OrientationLandscape:
+					imgBounds = halfBounds.Top
+				}
+			case SpreadRotate: // The margins must fit the spread once it gets rotated
+				resolution := step.resolution
+				step.resolution.Width, step.resolution.Height = resolution.Height, resolution.Width
 			}
 		}
 	}
+	if step.keptSpreadsOnly && !isKeptSpread {
+		return err
+	}
 	margins := step.calculateNewDimensions(imgBounds.Bounds())
 	if margins.w == 0 && margins.h == 0 {
 		return err
@@ -94,6 +126,22 @@ func (step StepMarginWrapImage) PerformExec(
 	return err
 }
 
+// spreadPolicy tells what is going to be done with a spread that doesn't fit the device.
+// Only SpreadSplit layouts wrap the spreads before they are split, the other ones
+// receive the spread after the rotation, so they are kept whole.
+func (step StepMarginWrapImage) spreadPolicy(
+	img image.Image, imgOrientation inktypes.ImageOrientation,
+) SpreadPolicy {
+	if step.spreadLayout.Policy != SpreadSplit {
+		return SpreadKeep
+	}
+
+	if _, split := step.spreadLayout.splitPosition(img, imgOrientation); split {
+		return SpreadSplit
+	}
+	return step.spreadLayout.NoGutterPolicy
+}
+
 func drawMargins(
 	dstImg draw.Image, offsets image.Point,
 	originalBounds image.Rectangle,
@@ -137,6 +185,16 @@ func (step StepMarginWrapImage) calculateNewDimensions(
 			return margins
 		}
 	}
+	switch step.fitMode {
+	case FitFill: // The page covers all the screen, without margins
+		return margins
+	case FitNoUpscale: // Smaller pages are centered on the screen, keeping their size
+		if actualWidth <= uint(desiredWidth) && actualHeight <= uint(desiredHeight) {
+			margins.w = uint(desiredWidth) - actualWidth
+			margins.h = uint(desiredHeight) - actualHeight
+			return margins
+		}
+	}
 
 	actualAspect := float64(actualWidth) / float64(actualHeight)
 	desiredAspect := float64(desiredWidth) / float64(desiredHeight)
@@ -147,6 +205,9 @@ func (step StepMarginWrapImage) calculateNewDimensions(
 		margins.h = uint(newHeight - float64(actualHeight))
 	} else if actualAspect < desiredAspect {
 		// Image is too tall, need to add width (left/right padding)
+		if step.fitMode == FitWidth { // The overflowing height goes to the scroll pages
+			return margins
+		}
 		newWidth := float64(actualHeight) * desiredAspect
 		margins.w = uint(newWidth - float64(actualWidth))
 	}
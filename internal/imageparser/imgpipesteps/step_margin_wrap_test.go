@@ -4,6 +4,7 @@ import (
 	"image"
 	"testing"
 
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
@@ -54,7 +55,7 @@ func TestCalculateNewDimensions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			step := NewStepMarginWrap(tt.targetResolution)
+			step := NewStepMarginWrap(tt.targetResolution, FitLetterbox, SpreadLayout{})
 			result := step.calculateNewDimensions(
 				image.Rect(0, 0, tt.inputWidth, tt.inputHeight),
 			)
@@ -70,3 +71,150 @@ func TestCalculateNewDimensions(t *testing.T) {
 		})
 	}
 }
+
+func TestStepMarginWrapImage_spreadLayout(t *testing.T) {
+	resolution := inktypes.ImageDimensions{Width: 600, Height: 800}
+	tests := []struct {
+		name            string
+		layout          SpreadLayout
+		keptSpreadsOnly bool
+		inputImg        image.Image
+		expectedBounds  image.Rectangle
+	}{
+		{
+			name:           "Split spread receives margins on each half",
+			layout:         SpreadLayout{Policy: SpreadSplit},
+			inputImg:       spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{96, 104}),
+			expectedBounds: image.Rect(0, 0, 200, 166),
+		},
+		{
+			name:           "Spread without gutter is kept whole",
+			layout:         SpreadLayout{Policy: SpreadSplit, NoGutterPolicy: SpreadKeep},
+			inputImg:       spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{}),
+			expectedBounds: image.Rect(0, 0, 200, 266),
+		},
+		{
+			name:           "Spread without gutter receives margins to be rotated",
+			layout:         SpreadLayout{Policy: SpreadSplit, NoGutterPolicy: SpreadRotate},
+			inputImg:       spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{}),
+			expectedBounds: image.Rect(0, 0, 200, 150),
+		},
+		{
+			name:           "Kept spread receives margins as a whole",
+			layout:         SpreadLayout{Policy: SpreadKeep},
+			inputImg:       spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{96, 104}),
+			expectedBounds: image.Rect(0, 0, 200, 266),
+		},
+		{
+			name:            "Only the kept spreads wrap the spread without gutter",
+			layout:          SpreadLayout{Policy: SpreadSplit, NoGutterPolicy: SpreadKeep},
+			keptSpreadsOnly: true,
+			inputImg:        spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{}),
+			expectedBounds:  image.Rect(0, 0, 200, 266),
+		},
+		{
+			name:            "Only the kept spreads leave the split spread",
+			layout:          SpreadLayout{Policy: SpreadSplit, NoGutterPolicy: SpreadKeep},
+			keptSpreadsOnly: true,
+			inputImg:        spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{96, 104}),
+			expectedBounds:  image.Rect(0, 0, 200, 100),
+		},
+		{
+			name:            "Only the kept spreads leave the rotated spread",
+			layout:          SpreadLayout{Policy: SpreadSplit, NoGutterPolicy: SpreadRotate},
+			keptSpreadsOnly: true,
+			inputImg:        spreadWithGutter(image.Rect(0, 0, 200, 100), [2]int{}),
+			expectedBounds:  image.Rect(0, 0, 200, 100),
+		},
+		{
+			name:            "Only the kept spreads leave the single page",
+			layout:          SpreadLayout{Policy: SpreadKeep},
+			keptSpreadsOnly: true,
+			inputImg:        spreadWithGutter(image.Rect(0, 0, 100, 200), [2]int{}),
+			expectedBounds:  image.Rect(0, 0, 100, 200),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := NewStepMarginWrap(resolution, FitLetterbox, tt.layout)
+			if tt.keptSpreadsOnly {
+				step = NewStepKeptSpreadWrap(resolution, FitLetterbox, tt.layout)
+			}
+			state := imageparser.PipeState{Img: tt.inputImg}
+			if err := step.PerformExec(&state, imageparser.ProcessOptions{}); err != nil {
+				t.Fatalf("PerformExec: %v", err.Error())
+			}
+
+			if bounds := state.Img.Bounds(); bounds != tt.expectedBounds {
+				t.Errorf("expected bounds %v, got %v", tt.expectedBounds, bounds)
+			}
+		})
+	}
+}
+
+func TestStepMarginWrapImage_fitModes(t *testing.T) {
+	resolution := inktypes.ImageDimensions{Width: 600, Height: 800}
+	tests := []struct {
+		name           string
+		fitMode        FitMode
+		inputBounds    image.Rectangle
+		expectedResult [2]uint
+	}{
+		{
+			name:           "Fit pads the narrow page",
+			fitMode:        FitLetterbox,
+			inputBounds:    image.Rect(0, 0, 300, 800),
+			expectedResult: [2]uint{300, 0},
+		},
+		{
+			name:           "Fill has no margins",
+			fitMode:        FitFill,
+			inputBounds:    image.Rect(0, 0, 300, 800),
+			expectedResult: [2]uint{0, 0},
+		},
+		{
+			name:           "Stretch pads to the screen aspect",
+			fitMode:        FitStretch,
+			inputBounds:    image.Rect(0, 0, 800, 300),
+			expectedResult: [2]uint{0, 766},
+		},
+		{
+			name:           "Fit width pads the wide page",
+			fitMode:        FitWidth,
+			inputBounds:    image.Rect(0, 0, 600, 400),
+			expectedResult: [2]uint{0, 400},
+		},
+		{
+			name:           "Fit width leaves the tall page to the scroll pages",
+			fitMode:        FitWidth,
+			inputBounds:    image.Rect(0, 0, 300, 800),
+			expectedResult: [2]uint{0, 0},
+		},
+		{
+			name:           "No upscale centers the small page",
+			fitMode:        FitNoUpscale,
+			inputBounds:    image.Rect(0, 0, 400, 500),
+			expectedResult: [2]uint{200, 300},
+		},
+		{
+			name:           "No upscale pads the big page",
+			fitMode:        FitNoUpscale,
+			inputBounds:    image.Rect(0, 0, 1200, 800),
+			expectedResult: [2]uint{0, 800},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := NewStepMarginWrap(resolution, tt.fitMode, SpreadLayout{})
+			result := step.calculateNewDimensions(tt.inputBounds)
+			if result.w != tt.expectedResult[0] || result.h != tt.expectedResult[1] {
+				t.Errorf(
+					"Expected [w:%d h:%d], but got [w:%d h:%d]",
+					tt.expectedResult[0], tt.expectedResult[1], result.w, result.h,
+				)
+			}
+		})
+	}
+}
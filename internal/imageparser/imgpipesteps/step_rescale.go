@@ -2,33 +2,106 @@ package imgpipesteps
 
 import (
 	"image"
+	"math"
 
 	"golang.org/x/image/draw"
 
 	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
-var _ imageparser.PipeStep = (*StepRescaleImage)(nil)
+var (
+	_ imageparser.PipeStep       = (*StepRescaleImage)(nil)
+	_ imageparser.SourceAnalyzer = (*StepRescaleImage)(nil)
+)
+
+type ResampleFilter uint8
+
+const (
+	// ResampleApproxBilinear is a fast mix of nearest neighbor and bilinear
+	ResampleApproxBilinear ResampleFilter = iota
+	ResampleNearest
+	ResampleBilinear
+	ResampleCatmullRom
+	ResampleLanczos3
+	// ResampleAuto uses nearest neighbor on pixel art, keeping its hard edges, and
+	// ResampleApproxBilinear on the other images
+	ResampleAuto
+)
+
+// FitMode tells how the pages are fitted on the device screen.
+type FitMode uint8
+
+const (
+	// FitLetterbox scales the page to fit inside the screen, keeping its aspect ratio
+	FitLetterbox FitMode = iota
+	// FitFill scales the page to cover the screen, cropping what overflows it
+	FitFill
+	// FitStretch scales the page to the screen resolution, ignoring its aspect ratio. Only
+	// the pages padded to the screen aspect by a margin wrap before it are not distorted.
+	FitStretch
+	// FitWidth scales the page to the screen width, splitting the overflowing height
+	// into scroll pages
+	FitWidth
+	// FitNoUpscale works as FitLetterbox, but the pages smaller than the screen keep their size
+	FitNoUpscale
+)
+
+// lanczos3Kernel is the windowed sinc kernel with 3 lobes, it keeps the line art
+// sharper than Catmull-Rom on downscaling, at the cost of some ringing.
+var lanczos3Kernel = &draw.Kernel{
+	Support: 3,
+	At: func(t float64) float64 {
+		t = math.Abs(t)
+		if t < 1e-9 {
+			return 1
+		} else if t >= 3 {
+			return 0
+		}
+
+		piT := math.Pi * t
+		return 3 * math.Sin(piT) * math.Sin(piT/3) / (piT * piT)
+	},
+}
+
+func (filter ResampleFilter) Interpolator() draw.Interpolator {
+	switch filter {
+	case ResampleNearest:
+		return draw.NearestNeighbor
+	case ResampleBilinear:
+		return draw.BiLinear
+	case ResampleCatmullRom:
+		return draw.CatmullRom
+	case ResampleLanczos3:
+		return lanczos3Kernel
+	}
+
+	return draw.ApproxBiLinear
+}
 
 type StepRescaleImage struct {
-	resolution   inktypes.ImageDimensions
-	isPixelArt   bool
-	allowStretch bool
+	resolution inktypes.ImageDimensions
+	fitMode    FitMode
+	filter     ResampleFilter
 	imageparser.BaseImageStep
 }
 
-func NewStepRescale(resolution inktypes.ImageDimensions, allowStretch bool) *StepRescaleImage {
+func NewStepRescale(
+	resolution inktypes.ImageDimensions, fitMode FitMode, filter ResampleFilter,
+) *StepRescaleImage {
 	return &StepRescaleImage{
-		resolution:   resolution,
-		allowStretch: allowStretch,
+		resolution: resolution,
+		fitMode:    fitMode,
+		filter:     filter,
 	}
 }
 
 func NewStepThumbnail() StepRescaleImage {
 	return StepRescaleImage{
-		allowStretch: true,
-		resolution:   inktypes.ImageDimensions{Width: 300, Height: 470},
+		fitMode:    FitStretch,
+		filter:     ResampleAuto,
+		resolution: inktypes.ImageDimensions{Width: 300, Height: 470},
 	}
 }
 
@@ -41,21 +114,24 @@ func (step StepRescaleImage) PerformExec(
 	_ imageparser.ProcessOptions,
 ) (err error) {
 	inputImage := state.Img
-	if !step.allowStretch {
-		step.resolution = step.updateTargetResolution(
-			inktypes.ImageDimensions{
-				Width:  uint16(inputImage.Bounds().Dx()),
-				Height: uint16(inputImage.Bounds().Dy()),
-			},
-		)
-	}
+	targetSize := step.updateTargetResolution(
+		inktypes.ImageDimensions{
+			Width:  uint16(inputImage.Bounds().Dx()),
+			Height: uint16(inputImage.Bounds().Dy()),
+		},
+	)
 
-	bounds := image.Rect(0, 0, int(step.resolution.Width), int(step.resolution.Height))
+	bounds := image.Rect(0, 0, int(targetSize.Width), int(targetSize.Height))
 	resized := step.DrawImage(state.Img.ColorModel(), bounds)
 
-	drawInterpolator := draw.ApproxBiLinear
-	if step.isPixelArt {
-		drawInterpolator = draw.NearestNeighbor
+	drawInterpolator := step.filter.Interpolator()
+	// Pixel art must keep its hard edges, which any smoothing filter would blur. A filter
+	// chosen by the user is always kept.
+	if step.filter == ResampleAuto {
+		step.AnalyzeSource(inputImage, &state.PageContext) // When not run by the pipeline
+		if *state.PixelArt {
+			drawInterpolator = draw.NearestNeighbor
+		}
 	}
 
 	drawInterpolator.Scale(
@@ -65,25 +141,87 @@ func (step StepRescaleImage) PerformExec(
 	)
 
 	state.Img = resized
+	switch step.fitMode {
+	case FitFill: // Keep the center of the page, which covers the screen
+		screen := image.Rect(0, 0, int(step.resolution.Width), int(step.resolution.Height))
+		overflow := bounds.Size().Sub(screen.Size()).Div(2)
+		state.Img = imgutils.CropImage(resized, screen.Add(overflow).Intersect(bounds))
+	case FitWidth:
+		if bounds.Dy() > int(step.resolution.Height) {
+			state.SubImages = step.scrollPages(resized)
+		}
+	}
 	return err
 }
 
+// AnalyzeSource finds if the page is pixel art, when the filter is chosen from it. It's
+// found on the received page, as the grayscale conversion removes the colors that tell it.
+func (step StepRescaleImage) AnalyzeSource(img image.Image, page *imageparser.PageContext) {
+	if step.filter == ResampleAuto && page.PixelArt == nil {
+		isPixelArt := imgutils.IsPixelArt(img)
+		page.PixelArt = &isPixelArt
+	}
+}
+
+// scrollPages splits a page taller than the screen into pages of the screen height,
+// cutting on the whitespace between panels when it's found.
+func (step StepRescaleImage) scrollPages(img image.Image) (pages []image.Image) {
+	bounds := img.Bounds()
+	pageHeight := int(step.resolution.Height)
+	for top := bounds.Min.Y; top < bounds.Max.Y; {
+		window := image.Rect(bounds.Min.X, top, bounds.Max.X, min(top+pageHeight+1, bounds.Max.Y))
+		cut := min(
+			imgutils.FindStripCut(imgutils.CropImage(img, window), pageHeight),
+			top+pageHeight,
+		)
+		pages = append(
+			pages, imgutils.CropImage(img, image.Rect(bounds.Min.X, top, bounds.Max.X, cut)),
+		)
+		top = cut
+	}
+	return pages
+}
+
+// updateTargetResolution returns the size the image is scaled to, on the step fit mode.
 func (step StepRescaleImage) updateTargetResolution(
 	imgDimensions inktypes.ImageDimensions,
 ) inktypes.ImageDimensions {
+	switch step.fitMode {
+	case FitStretch:
+		return step.resolution
+	case FitWidth:
+		widthProportion := float64(step.resolution.Width) / float64(max(imgDimensions.Width, 1))
+		return inktypes.ImageDimensions{
+			Width:  step.resolution.Width,
+			Height: uint16(min(float64(imgDimensions.Height)*widthProportion, math.MaxUint16)),
+		}
+	}
+
 	actualAspect := float64(imgDimensions.Width) / float64(imgDimensions.Height)
 	desiredAspect := float64(step.resolution.Width) / float64(step.resolution.Height)
 
+	// The fitted side is the one that overflows the screen, or the other one to cover it
+	fitWidth, fitHeight := actualAspect > desiredAspect, actualAspect < desiredAspect
+	if step.fitMode == FitFill {
+		fitWidth, fitHeight = fitHeight, fitWidth
+	}
+
 	newWidth, newHeight := float64(step.resolution.Width), float64(step.resolution.Height)
-	if actualAspect > desiredAspect {
-		// Image has a bigger width than target, need to adjust height
+	if fitWidth {
+		// The width fits the target, need to adjust height
 		widthProportion := float64(step.resolution.Width) / float64(imgDimensions.Width)
 		newHeight = float64(imgDimensions.Height) * widthProportion
-	} else if actualAspect < desiredAspect {
-		// Image has a bigger height than target, need to adjust width
+	} else if fitHeight {
+		// The height fits the target, need to adjust width
 		heightProportion := float64(step.resolution.Height) / float64(imgDimensions.Height)
 		newWidth = float64(imgDimensions.Width) * heightProportion
 	}
 
-	return inktypes.ImageDimensions{Width: uint16(newWidth), Height: uint16(newHeight)}
+	if step.fitMode == FitNoUpscale && newWidth > float64(imgDimensions.Width) {
+		return imgDimensions
+	}
+	return inktypes.ImageDimensions{
+		Width:  uint16(min(newWidth, math.MaxUint16)),
+		Height: uint16(min(newHeight, math.MaxUint16)),
+	}
 }
@@ -6,6 +6,8 @@ import (
 	"image/color"
 	"testing"
 
+	"golang.org/x/image/draw"
+
 	"github.com/Jictyvoo/ink_stream/internal/imageparser"
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
@@ -73,7 +75,7 @@ func TestStepRescaleImage_PerformExec(t *testing.T) {
 	}
 	for _, tCase := range testCases {
 		t.Run(tCase.name, func(t *testing.T) {
-			step := NewStepRescale(tCase.targetSize, true)
+			step := NewStepRescale(tCase.targetSize, FitStretch, ResampleApproxBilinear)
 			mockImage := func(size inktypes.ImageDimensions, fillValue color.Color) image.Image {
 				img := image.NewRGBA(
 					image.Rect(0, 0, int(size.Width), int(size.Height)),
@@ -201,3 +203,175 @@ func TestStepRescaleImage_updateTargetResolution(t *testing.T) {
 		})
 	}
 }
+
+func TestStepRescaleImage_filters(t *testing.T) {
+	pixelArt := &image.RGBA{
+		Pix: []uint8{
+			0xff, 0x0, 0x0, 0xff, 0x0, 0xff, 0x0, 0xff,
+			0x0, 0x0, 0xff, 0xff, 0xff, 0xff, 0x0, 0xff,
+		},
+		Stride: 8, Rect: image.Rect(0, 0, 2, 2),
+	}
+	upscaledPixelArt := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x, y := range imgutils.Iterator(upscaledPixelArt) {
+		upscaledPixelArt.Set(x, y, pixelArt.At(x/2, y/2))
+	}
+	scaledWith := func(interpolator draw.Interpolator, src image.Image, size int) image.Image {
+		scaled := imgutils.NewDrawFromImgColorModel(src.ColorModel(), image.Rect(0, 0, size, size))
+		interpolator.Scale(scaled, scaled.Bounds(), src, src.Bounds(), draw.Over, nil)
+		return scaled
+	}
+	grayPixelArt := image.NewGray(image.Rect(0, 0, 2, 2))
+	copy(grayPixelArt.Pix, []uint8{0x00, 0x55, 0xaa, 0xff})
+	solidColor := color.RGBA{R: 0x30, G: 0x60, B: 0x90, A: 0xff}
+
+	testCases := []struct {
+		name                  string
+		filter                ResampleFilter
+		targetSize            inktypes.ImageDimensions
+		inputImg, expectedImg image.Image
+	}{
+		{
+			name:        "Pixel art keeps the hard edges",
+			filter:      ResampleAuto,
+			targetSize:  inktypes.ImageDimensions{Width: 4, Height: 4},
+			inputImg:    pixelArt,
+			expectedImg: upscaledPixelArt,
+		},
+		{
+			name:        "Chosen filter is kept on pixel art",
+			filter:      ResampleLanczos3,
+			targetSize:  inktypes.ImageDimensions{Width: 4, Height: 4},
+			inputImg:    pixelArt,
+			expectedImg: scaledWith(lanczos3Kernel, pixelArt, 4),
+		},
+		{
+			name:        "Gray levels are not pixel art",
+			filter:      ResampleAuto,
+			targetSize:  inktypes.ImageDimensions{Width: 4, Height: 4},
+			inputImg:    grayPixelArt,
+			expectedImg: scaledWith(draw.ApproxBiLinear, grayPixelArt, 4),
+		},
+		{
+			name:        "Bilinear on solid image",
+			filter:      ResampleBilinear,
+			targetSize:  inktypes.ImageDimensions{Width: 3, Height: 5},
+			inputImg:    testimgs.NewSolidImage(image.Rect(0, 0, 9, 15), solidColor),
+			expectedImg: testimgs.NewSolidImage(image.Rect(0, 0, 3, 5), solidColor),
+		},
+		{
+			name:        "Catmull-Rom on solid image",
+			filter:      ResampleCatmullRom,
+			targetSize:  inktypes.ImageDimensions{Width: 3, Height: 5},
+			inputImg:    testimgs.NewSolidImage(image.Rect(0, 0, 9, 15), solidColor),
+			expectedImg: testimgs.NewSolidImage(image.Rect(0, 0, 3, 5), solidColor),
+		},
+		{
+			name:        "Lanczos3 on solid image",
+			filter:      ResampleLanczos3,
+			targetSize:  inktypes.ImageDimensions{Width: 12, Height: 20},
+			inputImg:    testimgs.NewSolidImage(image.Rect(0, 0, 6, 10), solidColor),
+			expectedImg: testimgs.NewSolidImage(image.Rect(0, 0, 12, 20), solidColor),
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			step := NewStepRescale(tCase.targetSize, FitStretch, tCase.filter)
+			state := imageparser.PipeState{Img: tCase.inputImg}
+			if err := step.PerformExec(&state, imageparser.ProcessOptions{}); err != nil {
+				t.Fatalf("PerformExec: %v", err.Error())
+			}
+
+			if !imgutils.IsImageEqual(state.Img, tCase.expectedImg) {
+				t.Errorf("expected: %#v, actual: %#v", tCase.expectedImg, state.Img)
+			}
+		})
+	}
+}
+
+func TestStepRescaleImage_fitModes(t *testing.T) {
+	resolution := inktypes.ImageDimensions{Width: 60, Height: 80}
+	testCases := []struct {
+		name          string
+		fitMode       FitMode
+		original      inktypes.ImageDimensions
+		expectedSize  inktypes.ImageDimensions
+		expectedPages []image.Rectangle
+	}{
+		{
+			name:          "Fit keeps the aspect inside the screen",
+			fitMode:       FitLetterbox,
+			original:      inktypes.ImageDimensions{Width: 200, Height: 100},
+			expectedSize:  inktypes.ImageDimensions{Width: 60, Height: 30},
+			expectedPages: []image.Rectangle{image.Rect(0, 0, 60, 30)},
+		},
+		{
+			name:          "Fill crops the overflowing width",
+			fitMode:       FitFill,
+			original:      inktypes.ImageDimensions{Width: 200, Height: 100},
+			expectedSize:  inktypes.ImageDimensions{Width: 160, Height: 80},
+			expectedPages: []image.Rectangle{image.Rect(50, 0, 110, 80)},
+		},
+		{
+			name:          "Stretch ignores the aspect",
+			fitMode:       FitStretch,
+			original:      inktypes.ImageDimensions{Width: 200, Height: 100},
+			expectedSize:  resolution,
+			expectedPages: []image.Rectangle{image.Rect(0, 0, 60, 80)},
+		},
+		{
+			name:         "Fit width splits the overflow into scroll pages",
+			fitMode:      FitWidth,
+			original:     inktypes.ImageDimensions{Width: 30, Height: 100},
+			expectedSize: inktypes.ImageDimensions{Width: 60, Height: 200},
+			expectedPages: []image.Rectangle{
+				image.Rect(0, 0, 60, 80), image.Rect(0, 80, 60, 160), image.Rect(0, 160, 60, 200),
+			},
+		},
+		{
+			name:          "No upscale keeps the small pages size",
+			fitMode:       FitNoUpscale,
+			original:      inktypes.ImageDimensions{Width: 30, Height: 20},
+			expectedSize:  inktypes.ImageDimensions{Width: 30, Height: 20},
+			expectedPages: []image.Rectangle{image.Rect(0, 0, 30, 20)},
+		},
+		{
+			name:          "No upscale fits the big pages",
+			fitMode:       FitNoUpscale,
+			original:      inktypes.ImageDimensions{Width: 120, Height: 320},
+			expectedSize:  inktypes.ImageDimensions{Width: 30, Height: 80},
+			expectedPages: []image.Rectangle{image.Rect(0, 0, 30, 80)},
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			step := NewStepRescale(resolution, tCase.fitMode, ResampleNearest)
+			if size := step.updateTargetResolution(tCase.original); size != tCase.expectedSize {
+				t.Errorf("expected size: %+v, actual: %+v", tCase.expectedSize, size)
+			}
+
+			inputImg := image.NewGray(
+				image.Rect(0, 0, int(tCase.original.Width), int(tCase.original.Height)),
+			)
+			state := imageparser.PipeState{Img: inputImg}
+			if err := step.PerformExec(&state, imageparser.ProcessOptions{}); err != nil {
+				t.Fatalf("PerformExec: %v", err.Error())
+			}
+
+			pages := state.SubImages
+			if len(pages) == 0 {
+				pages = []image.Image{state.Img}
+			}
+			if len(pages) != len(tCase.expectedPages) {
+				t.Fatalf("expected %d pages, actual: %d", len(tCase.expectedPages), len(pages))
+			}
+			for index, page := range pages {
+				if page.Bounds() != tCase.expectedPages[index] {
+					t.Errorf("expected: %v, actual: %v", tCase.expectedPages[index], page.Bounds())
+				}
+			}
+		})
+	}
+}
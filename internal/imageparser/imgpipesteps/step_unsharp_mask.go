@@ -0,0 +1,68 @@
+package imgpipesteps
+
+import (
+	"image/color"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+var _ imageparser.PipeStep = (*StepUnsharpMaskImage)(nil)
+
+// StepUnsharpMaskImage sharpens the image by adding back the difference between
+// the image and its blurred version. Differences lower than the threshold are kept
+// untouched, to avoid enhancing the noise on flat regions.
+type StepUnsharpMaskImage struct {
+	amount    float64
+	threshold uint8
+	blur      StepApplyGaussianBlurImage
+	imageparser.BaseImageStep
+}
+
+func NewStepUnsharpMask(radius int, amount float64, threshold uint8) *StepUnsharpMaskImage {
+	return &StepUnsharpMaskImage{
+		amount:    amount,
+		threshold: threshold,
+		blur:      StepApplyGaussianBlurImage{kernel: createBlurKernel(radius)},
+	}
+}
+
+func (step StepUnsharpMaskImage) StepID() string {
+	return "unsharp_mask"
+}
+
+func (step StepUnsharpMaskImage) PerformExec(
+	state *imageparser.PipeState, _ imageparser.ProcessOptions,
+) error {
+	if step.amount <= 0 || step.blur.kernel.radius <= 0 || step.blur.kernel.radius > 200 {
+		return nil
+	}
+
+	img := state.Img
+	bounds := img.Bounds()
+	sharpenedImg := step.DrawImage(img.ColorModel(), bounds)
+
+	for x, y := range imgutils.Iterator(img) {
+		r, g, b, a := img.At(x, y).RGBA()
+		br, bg, bb, _ := step.blur.applyKernel(img, x, y, bounds).RGBA()
+
+		sharpenedImg.Set(x, y, color.RGBA{
+			R: step.sharpenChannel(uint8(r>>8), uint8(br>>8)),
+			G: step.sharpenChannel(uint8(g>>8), uint8(bg>>8)),
+			B: step.sharpenChannel(uint8(b>>8), uint8(bb>>8)),
+			A: uint8(a >> 8),
+		})
+	}
+
+	state.Img = sharpenedImg
+	return nil
+}
+
+func (step StepUnsharpMaskImage) sharpenChannel(original, blurred uint8) uint8 {
+	diff := int(original) - int(blurred)
+	if max(diff, -diff) < int(step.threshold) {
+		return original
+	}
+
+	return imgutils.NormalizePixel(float64(original) + step.amount*float64(diff) + 0.5)
+}
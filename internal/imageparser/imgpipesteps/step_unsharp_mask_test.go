@@ -0,0 +1,73 @@
+package imgpipesteps
+
+import (
+	"image"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+func TestStepUnsharpMaskImage_PerformExec(t *testing.T) {
+	edgeImg := &image.Gray{
+		Pix: []uint8{0x40, 0x40, 0xc0, 0xc0}, Stride: 4, Rect: image.Rect(0, 0, 4, 1),
+	}
+
+	testCases := []struct {
+		name        string
+		radius      int
+		amount      float64
+		threshold   uint8
+		inputImg    image.Image
+		expectedImg image.Image
+	}{
+		{
+			name:     "Sharpen an edge",
+			radius:   1,
+			amount:   1,
+			inputImg: edgeImg,
+			expectedImg: &image.Gray{
+				Pix: []uint8{0x40, 0x20, 0xe0, 0xc0}, Stride: 4, Rect: image.Rect(0, 0, 4, 1),
+			},
+		},
+		{
+			name:        "Differences under the threshold are kept",
+			radius:      1,
+			amount:      1,
+			threshold:   0x40,
+			inputImg:    edgeImg,
+			expectedImg: edgeImg,
+		},
+		{
+			name:        "Zero amount disables the step",
+			radius:      1,
+			inputImg:    edgeImg,
+			expectedImg: edgeImg,
+		},
+		{
+			name:   "Flat image is not changed",
+			radius: 2,
+			amount: 1.5,
+			inputImg: &image.Gray{
+				Pix: []uint8{0x80, 0x80, 0x80, 0x80}, Stride: 2, Rect: image.Rect(0, 0, 2, 2),
+			},
+			expectedImg: &image.Gray{
+				Pix: []uint8{0x80, 0x80, 0x80, 0x80}, Stride: 2, Rect: image.Rect(0, 0, 2, 2),
+			},
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			step := NewStepUnsharpMask(tCase.radius, tCase.amount, tCase.threshold)
+			state := imageparser.PipeState{Img: tCase.inputImg}
+			if err := step.PerformExec(&state, imageparser.ProcessOptions{}); err != nil {
+				t.Fatalf("PerformExec: %v", err.Error())
+			}
+
+			if !imgutils.IsImageEqual(state.Img, tCase.expectedImg) {
+				t.Errorf("expected: %#v, actual: %#v", tCase.expectedImg, state.Img)
+			}
+		})
+	}
+}
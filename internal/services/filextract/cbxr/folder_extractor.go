@@ -1,19 +1,25 @@
 package cbxr
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"iter"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 
+	"github.com/Jictyvoo/ink_stream/pkg/comicinfo"
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 type FolderExtractor struct {
 	folderPointer *os.File
+	metadata      inktypes.BookMetadata
 }
 
 func NewFolderExtractor(folderPointer *os.File) (*FolderExtractor, error) {
@@ -28,7 +34,46 @@ func NewFolderExtractor(folderPointer *os.File) (*FolderExtractor, error) {
 	if !fileStat.IsDir() {
 		return nil, fmt.Errorf("folderPointer is not a directory")
 	}
-	return &FolderExtractor{folderPointer: folderPointer}, nil
+
+	extractor := &FolderExtractor{folderPointer: folderPointer}
+	if err = extractor.readMetadata(); err != nil {
+		slog.Warn(
+			"Failed to read "+comicinfo.FileName,
+			slog.String("folder", folderPointer.Name()),
+			slog.String("error", err.Error()),
+		)
+	}
+	return extractor, nil
+}
+
+// readMetadata loads the ComicInfo.xml placed on the folder root.
+func (e *FolderExtractor) readMetadata() error {
+	root := e.folderPointer.Name()
+	data, err := os.ReadFile(filepath.Join(root, comicinfo.FileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var filenames []string
+	err = filepath.WalkDir(root, func(path string, dirEntry os.DirEntry, err error) error {
+		if err == nil && !dirEntry.IsDir() {
+			filenames = append(filenames, path)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	e.metadata, err = comicInfoMetadata(data, filenames)
+	return err
+}
+
+func (e *FolderExtractor) Metadata() inktypes.BookMetadata {
+	return e.metadata
 }
 
 func (e *FolderExtractor) FileSeq() iter.Seq2[FileName, FileResult] {
@@ -4,10 +4,12 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
 	"slices"
 	"testing"
 
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 func TestFolderExtractor_FileSeq(t *testing.T) {
@@ -65,3 +67,46 @@ func TestFolderExtractor_FileSeq(t *testing.T) {
 		suite.Run(t, extractor)
 	})
 }
+
+func TestFolderExtractor_Metadata(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"ComicInfo.xml": `<ComicInfo><Title>Title</Title><Pages>` +
+			`<Page Image="0" Type="FrontCover"/><Page Image="1" Bookmark="Extra"/>` +
+			`</Pages></ComicInfo>`,
+		"ch01/001.jpg": "jpg content",
+		"ch02/001.jpg": "jpg content",
+		"readme.txt":   "txt content",
+	}
+	for filename, content := range testFiles {
+		path := filepath.Join(tempDir, filename)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	folder, err := os.Open(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to open folder: %v", err)
+	}
+	defer folder.Close()
+
+	extractor, err := NewFolderExtractor(folder)
+	if err != nil {
+		t.Fatalf("Failed to create FolderExtractor: %v", err)
+	}
+
+	expected := inktypes.BookMetadata{
+		Title: "Title",
+		Pages: map[string]inktypes.PageInfo{
+			filepath.Join(tempDir, "ch01/001"): {Type: inktypes.PageFrontCover},
+			filepath.Join(tempDir, "ch02/001"): {Bookmark: "Extra"},
+		},
+	}
+	if metadata := extractor.Metadata(); !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("Metadata() = %+v, want %+v", metadata, expected)
+	}
+}
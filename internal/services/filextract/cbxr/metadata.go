@@ -0,0 +1,57 @@
+package cbxr
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Jictyvoo/ink_stream/pkg/comicinfo"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// MetadataExtractor is implemented by the extractors able to read the book metadata
+// stored along the pages. The metadata is read when the extractor is created.
+type MetadataExtractor interface {
+	Metadata() inktypes.BookMetadata
+}
+
+func isComicInfoFile(filename string) bool {
+	return strings.EqualFold(filepath.Base(filename), comicinfo.FileName)
+}
+
+func isImageFile(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return slices.Contains(imgutils.SupportedImageFormats(), ext)
+}
+
+// comicInfoMetadata decodes the ComicInfo.xml content. Its pages are indexed
+// following the images order by name, which is the order used by the readers.
+func comicInfoMetadata(data []byte, filenames []string) (inktypes.BookMetadata, error) {
+	info, err := comicinfo.Decode(bytes.NewReader(data))
+	if err != nil {
+		return inktypes.BookMetadata{}, err
+	}
+	return info.BookMetadata(sortedImageNames(filenames)), nil
+}
+
+// sortedImageNames returns the image files sorted by name, the order of the ComicInfo pages.
+func sortedImageNames(filenames []string) []string {
+	imageNames := make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		if isImageFile(filename) {
+			imageNames = append(imageNames, filename)
+		}
+	}
+	slices.Sort(imageNames)
+	return imageNames
+}
+
+// hasPageInfo reports whether the ComicInfo pages carry any information, which is
+// only resolved to the page names once all the images are known.
+func hasPageInfo(info comicinfo.ComicInfo) bool {
+	return slices.ContainsFunc(info.Pages, func(page comicinfo.Page) bool {
+		return page.Type != "" || page.Bookmark != ""
+	})
+}
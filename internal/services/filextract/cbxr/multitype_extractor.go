@@ -3,10 +3,15 @@ package cbxr
 import (
 	"context"
 	"io"
+	"io/fs"
 	"iter"
+	"log/slog"
 	"time"
 
 	"github.com/mholt/archives"
+
+	"github.com/Jictyvoo/ink_stream/pkg/comicinfo"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 func checkFileFormat(filename string, file io.Reader) (io.Reader, archives.Extractor, error) {
@@ -31,6 +36,7 @@ type (
 		format     archives.Extractor
 		fileReader io.Reader
 		timeout    time.Duration
+		metadata   inktypes.BookMetadata
 	}
 	archivesExtractInteract struct {
 		yield          func(FileName, FileResult) bool
@@ -47,11 +53,75 @@ func NewMultiZipRarExtractor(
 		return nil, err
 	}
 
-	return &MultiZipRarExtractor{
+	extractor := &MultiZipRarExtractor{
 		fileReader: reader,
 		format:     format,
 		timeout:    9000 * time.Second,
-	}, nil
+	}
+	if err = extractor.readMetadata(fileReader); err != nil {
+		return nil, err
+	}
+	return extractor, nil
+}
+
+// readMetadata scans the archive for the ComicInfo.xml before the extraction, so the
+// metadata is known before the pages. Entries are listed without being decompressed,
+// except the metadata file, but RAR archives can only be read sequentially, so the scan
+// stops once the ComicInfo.xml is read. It only goes on when the ComicInfo pages need
+// the names of the following images. The file is rewound afterward.
+func (ext *MultiZipRarExtractor) readMetadata(fileReader FileContentStream) error {
+	start, err := fileReader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	var (
+		filenames []string
+		info      *comicinfo.ComicInfo
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), ext.timeout)
+	defer cancel()
+	err = ext.format.Extract(
+		ctx,
+		ext.fileReader,
+		func(_ context.Context, f archives.FileInfo) error {
+			if f.IsDir() {
+				return nil
+			}
+			filenames = append(filenames, f.NameInArchive)
+			if info != nil || !isComicInfoFile(f.NameInArchive) {
+				return nil
+			}
+
+			reader, openErr := f.Open()
+			if openErr != nil {
+				return openErr
+			}
+			defer reader.Close()
+			decoded, decodeErr := comicinfo.Decode(reader)
+			if decodeErr != nil {
+				return decodeErr
+			}
+			if info = &decoded; !hasPageInfo(decoded) {
+				return fs.SkipAll
+			}
+			return nil
+		},
+	)
+	if _, seekErr := fileReader.Seek(start, io.SeekStart); seekErr != nil {
+		return seekErr
+	}
+
+	if err == nil && info != nil {
+		ext.metadata = info.BookMetadata(sortedImageNames(filenames))
+	}
+	if err != nil {
+		slog.Warn(
+			"Failed to read "+comicinfo.FileName,
+			slog.String("error", err.Error()),
+		)
+	}
+	return nil
 }
 
 func (aei *archivesExtractInteract) handleFile(_ context.Context, f archives.FileInfo) error {
@@ -60,17 +130,20 @@ func (aei *archivesExtractInteract) handleFile(_ context.Context, f archives.Fil
 		return nil
 	}
 
+	filename := f.NameInArchive
+	if f.IsDir() {
+		return nil
+	}
+	if isComicInfoFile(filename) {
+		return nil // Already read by readMetadata
+	}
+
 	reader, err := f.Open()
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
 
-	filename := f.NameInArchive
-	if f.IsDir() {
-		return nil
-	}
-
 	result := FileResult{}
 	result.Data, result.Error = io.ReadAll(reader)
 	if !aei.yield(FileName(filename), result) {
@@ -81,7 +154,11 @@ func (aei *archivesExtractInteract) handleFile(_ context.Context, f archives.Fil
 	return nil
 }
 
-func (ext MultiZipRarExtractor) FileSeq() iter.Seq2[FileName, FileResult] {
+func (ext *MultiZipRarExtractor) Metadata() inktypes.BookMetadata {
+	return ext.metadata
+}
+
+func (ext *MultiZipRarExtractor) FileSeq() iter.Seq2[FileName, FileResult] {
 	return func(yield func(FileName, FileResult) bool) {
 		aei := archivesExtractInteract{yield: yield}
 		ctx, cancel := context.WithTimeout(context.Background(), ext.timeout)
@@ -0,0 +1,106 @@
+package cbxr
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestMultiZipRarExtractor_Metadata(t *testing.T) {
+	type zipEntry struct{ name, content string }
+	var (
+		comicInfo = zipEntry{
+			name: "ComicInfo.xml",
+			content: `<ComicInfo><LanguageISO>ja</LanguageISO><Pages>` +
+				`<Page Image="1" Type="Deleted"/></Pages></ComicInfo>`,
+		}
+		comicInfoNoPages = zipEntry{
+			name:    "ComicInfo.xml",
+			content: `<ComicInfo><LanguageISO>ja</LanguageISO></ComicInfo>`,
+		}
+		pages = []zipEntry{{name: "002.jpg", content: "image"}, {name: "001.jpg", content: "image"}}
+	)
+	tests := []struct {
+		name     string
+		entries  []zipEntry
+		expected inktypes.BookMetadata
+	}{
+		{
+			name:    "metadata after the pages",
+			entries: append(append([]zipEntry{}, pages...), comicInfo),
+			expected: inktypes.BookMetadata{
+				Language: "ja",
+				Pages:    map[string]inktypes.PageInfo{"002": {Type: inktypes.PageDeleted}},
+			},
+		},
+		{
+			name:    "metadata before the pages",
+			entries: append([]zipEntry{comicInfo}, pages...),
+			expected: inktypes.BookMetadata{
+				Language: "ja",
+				Pages:    map[string]inktypes.PageInfo{"002": {Type: inktypes.PageDeleted}},
+			},
+		},
+		{
+			name:     "metadata without page information",
+			entries:  append([]zipEntry{comicInfoNoPages}, pages...),
+			expected: inktypes.BookMetadata{Language: "ja"},
+		},
+		{
+			name:    "no metadata",
+			entries: pages,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "book.cbz")
+			file, err := os.Create(filename)
+			if err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			writer := zip.NewWriter(file)
+			for _, entry := range tt.entries {
+				fw, createErr := writer.Create(entry.name)
+				if createErr != nil {
+					t.Fatalf("Failed to create file %q in zip: %v", entry.name, createErr)
+				}
+				if _, err = fw.Write([]byte(entry.content)); err != nil {
+					t.Fatalf("Failed to write content for %q in zip: %v", entry.name, err)
+				}
+			}
+			if err = writer.Close(); err != nil {
+				t.Fatalf("Failed to close zip writer: %v", err)
+			}
+			_ = file.Close()
+
+			archiveFile, err := os.Open(filename)
+			if err != nil {
+				t.Fatalf("Failed to open zip file: %v", err)
+			}
+			defer archiveFile.Close()
+
+			extractor, err := NewMultiZipRarExtractor(filename, archiveFile)
+			if err != nil {
+				t.Fatalf("Failed to create extractor: %v", err)
+			}
+
+			// Metadata must be known before the extraction
+			if metadata := extractor.Metadata(); !reflect.DeepEqual(metadata, tt.expected) {
+				t.Errorf("Metadata() = %+v, want %+v", metadata, tt.expected)
+			}
+
+			suite := ExtractTestSuite{
+				WantFiles: []string{"002.jpg", "001.jpg"},
+				WantFileData: map[string][]byte{
+					"002.jpg": []byte("image"), "001.jpg": []byte("image"),
+				},
+			}
+			suite.Run(t, extractor)
+		})
+	}
+}
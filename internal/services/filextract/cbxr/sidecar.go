@@ -0,0 +1,173 @@
+package cbxr
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+const (
+	// SidecarJSONName is the JSON metadata file read next to the source files.
+	SidecarJSONName = "book.json"
+	// SidecarOPFName is the Calibre OPF metadata file read next to the source files.
+	SidecarOPFName = "metadata.opf"
+)
+
+type (
+	sidecarFile struct {
+		path  string
+		parse func(data []byte) (inktypes.BookMetadata, error)
+	}
+	sidecarJSON struct {
+		Title       string   `json:"title"`
+		Authors     []string `json:"authors"`
+		Series      string   `json:"series"`
+		SeriesIndex any      `json:"series_index"` // Either a number or a string
+		Language    string   `json:"language"`
+		Publisher   string   `json:"publisher"`
+		Description string   `json:"description"`
+		PublishDate string   `json:"publish_date"`
+		Cover       string   `json:"cover"`
+	}
+	sidecarOPF struct {
+		Metadata struct {
+			Title       string   `xml:"title"`
+			Creators    []string `xml:"creator"`
+			Language    string   `xml:"language"`
+			Publisher   string   `xml:"publisher"`
+			Description string   `xml:"description"`
+			Date        string   `xml:"date"`
+			Meta        []struct {
+				Name    string `xml:"name,attr"`
+				Content string `xml:"content,attr"`
+			} `xml:"meta"`
+		} `xml:"metadata"`
+		Guide []struct {
+			Type string `xml:"type,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"guide>reference"`
+	}
+)
+
+// SidecarMetadata reads the book metadata from the sidecar file placed next to the
+// source archive, or inside the source folder. An archive first looks for the sidecar
+// with its own name, like `volume.json` or `volume.opf`, so each archive of a folder has
+// its metadata, and only then for the files shared by the folder. The JSON file has
+// priority over the OPF one. Empty metadata is returned when there is no sidecar file.
+func SidecarMetadata(sourcePath string) (inktypes.BookMetadata, error) {
+	var (
+		directory = filepath.Dir(sourcePath)
+		sidecars  []sidecarFile
+	)
+	if stat, err := os.Stat(sourcePath); err == nil && stat.IsDir() {
+		directory = sourcePath
+	} else {
+		baseName := strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath))
+		sidecars = append(sidecars,
+			sidecarFile{path: baseName + ".json", parse: parseSidecarJSON},
+			sidecarFile{path: baseName + ".opf", parse: parseSidecarOPF},
+		)
+	}
+	sidecars = append(sidecars,
+		sidecarFile{path: filepath.Join(directory, SidecarJSONName), parse: parseSidecarJSON},
+		sidecarFile{path: filepath.Join(directory, SidecarOPFName), parse: parseSidecarOPF},
+	)
+
+	var metadata inktypes.BookMetadata
+	for _, sidecar := range sidecars {
+		data, err := os.ReadFile(sidecar.path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return metadata, err
+		}
+
+		if metadata, err = sidecar.parse(data); err != nil {
+			return metadata, err
+		}
+		break
+	}
+
+	if metadata.CoverImage != "" && !filepath.IsAbs(metadata.CoverImage) {
+		metadata.CoverImage = filepath.Join(directory, filepath.FromSlash(metadata.CoverImage))
+	}
+	return metadata, nil
+}
+
+func parseSidecarJSON(data []byte) (inktypes.BookMetadata, error) {
+	var sidecar sidecarJSON
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return inktypes.BookMetadata{}, err
+	}
+
+	metadata := inktypes.BookMetadata{
+		Title:       strings.TrimSpace(sidecar.Title),
+		Series:      strings.TrimSpace(sidecar.Series),
+		Language:    strings.TrimSpace(sidecar.Language),
+		Summary:     strings.TrimSpace(sidecar.Description),
+		Publisher:   strings.TrimSpace(sidecar.Publisher),
+		PublishDate: strings.TrimSpace(sidecar.PublishDate),
+		CoverImage:  strings.TrimSpace(sidecar.Cover),
+		Writers:     trimmedValues(sidecar.Authors),
+	}
+	switch index := sidecar.SeriesIndex.(type) {
+	case float64:
+		metadata.Number = strconv.FormatFloat(index, 'f', -1, 64)
+	case string:
+		metadata.Number = strings.TrimSpace(index)
+	}
+
+	return metadata, nil
+}
+
+func parseSidecarOPF(data []byte) (inktypes.BookMetadata, error) {
+	var sidecar sidecarOPF
+	if err := xml.Unmarshal(data, &sidecar); err != nil {
+		return inktypes.BookMetadata{}, err
+	}
+
+	opfMetadata := sidecar.Metadata
+	metadata := inktypes.BookMetadata{
+		Title:     strings.TrimSpace(opfMetadata.Title),
+		Language:  strings.TrimSpace(opfMetadata.Language),
+		Summary:   strings.TrimSpace(opfMetadata.Description),
+		Publisher: strings.TrimSpace(opfMetadata.Publisher),
+		Writers:   trimmedValues(opfMetadata.Creators),
+	}
+	// Calibre writes the full timestamp, only the date is kept
+	metadata.PublishDate, _, _ = strings.Cut(strings.TrimSpace(opfMetadata.Date), "T")
+	for _, meta := range opfMetadata.Meta {
+		switch meta.Name {
+		case "calibre:series":
+			metadata.Series = strings.TrimSpace(meta.Content)
+		case "calibre:series_index":
+			metadata.Number = strings.TrimSpace(meta.Content)
+		}
+	}
+	for _, reference := range sidecar.Guide {
+		if reference.Type == "cover" {
+			metadata.CoverImage = reference.Href
+			break
+		}
+	}
+
+	return metadata, nil
+}
+
+func trimmedValues(values []string) []string {
+	var trimmed []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,149 @@
+package cbxr
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestSidecarMetadata(t *testing.T) {
+	const opfContent = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>OPF Title</dc:title>
+    <dc:creator opf:role="aut">First Author</dc:creator>
+    <dc:creator opf:role="aut">Second Author</dc:creator>
+    <dc:publisher>Publisher</dc:publisher>
+    <dc:date>2019-07-01T00:00:00+00:00</dc:date>
+    <dc:language>jpn</dc:language>
+    <meta name="calibre:series" content="Series"/>
+    <meta name="calibre:series_index" content="3.0"/>
+  </metadata>
+  <guide>
+    <reference type="cover" title="Cover" href="cover.jpg"/>
+  </guide>
+</package>`
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected func(directory string) inktypes.BookMetadata
+	}{
+		{
+			name:     "no sidecar",
+			files:    map[string]string{},
+			expected: func(string) inktypes.BookMetadata { return inktypes.BookMetadata{} },
+		},
+		{
+			name: "json sidecar",
+			files: map[string]string{
+				SidecarJSONName: `{"title":"Title","authors":["Author", " "],"series":"Series",` +
+					`"series_index":2,"publish_date":"2020-01-02","cover":"/covers/book.png"}`,
+			},
+			expected: func(string) inktypes.BookMetadata {
+				return inktypes.BookMetadata{
+					Title: "Title", Writers: []string{"Author"}, Series: "Series", Number: "2",
+					PublishDate: "2020-01-02", CoverImage: "/covers/book.png",
+				}
+			},
+		},
+		{
+			name:  "opf sidecar",
+			files: map[string]string{SidecarOPFName: opfContent},
+			expected: func(directory string) inktypes.BookMetadata {
+				return inktypes.BookMetadata{
+					Title:       "OPF Title",
+					Writers:     []string{"First Author", "Second Author"},
+					Series:      "Series",
+					Number:      "3.0",
+					Language:    "jpn",
+					Publisher:   "Publisher",
+					PublishDate: "2019-07-01",
+					CoverImage:  filepath.Join(directory, "cover.jpg"),
+				}
+			},
+		},
+		{
+			name: "json has priority",
+			files: map[string]string{
+				SidecarJSONName: `{"title":"JSON Title"}`,
+				SidecarOPFName:  opfContent,
+			},
+			expected: func(string) inktypes.BookMetadata {
+				return inktypes.BookMetadata{Title: "JSON Title"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := t.TempDir()
+			for filename, content := range tt.files {
+				path := filepath.Join(directory, filename)
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			}
+
+			// Both an archive next to the sidecar and the folder itself find it
+			for _, sourcePath := range []string{filepath.Join(directory, "book.cbz"), directory} {
+				metadata, err := SidecarMetadata(sourcePath)
+				if err != nil {
+					t.Fatalf("SidecarMetadata(%q) unexpected error: %v", sourcePath, err)
+				}
+				if expected := tt.expected(directory); !reflect.DeepEqual(metadata, expected) {
+					t.Errorf("SidecarMetadata(%q) = %+v, want %+v", sourcePath, metadata, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSidecarMetadata_PerArchive(t *testing.T) {
+	directory := t.TempDir()
+	for filename, content := range map[string]string{
+		"volume 01.json": `{"title":"First Volume","series_index":1}`,
+		"volume 02.opf": `<package><metadata><title>Second Volume</title>` +
+			`<meta name="calibre:series_index" content="2"/></metadata></package>`,
+		SidecarJSONName: `{"title":"Shared Title","series":"Series"}`,
+	} {
+		path := filepath.Join(directory, filename)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		archive  string
+		expected inktypes.BookMetadata
+	}{
+		{
+			archive:  "volume 01.cbz",
+			expected: inktypes.BookMetadata{Title: "First Volume", Number: "1"},
+		},
+		{
+			archive:  "volume 02.cbr",
+			expected: inktypes.BookMetadata{Title: "Second Volume", Number: "2"},
+		},
+		{
+			archive:  "volume 03.cbz",
+			expected: inktypes.BookMetadata{Title: "Shared Title", Series: "Series"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.archive, func(t *testing.T) {
+			sourcePath := filepath.Join(directory, tt.archive)
+			metadata, err := SidecarMetadata(sourcePath)
+			if err != nil {
+				t.Fatalf("SidecarMetadata(%q) unexpected error: %v", sourcePath, err)
+			}
+			if !reflect.DeepEqual(metadata, tt.expected) {
+				t.Errorf("SidecarMetadata(%q) = %+v, want %+v", sourcePath, metadata, tt.expected)
+			}
+		})
+	}
+}
@@ -4,11 +4,16 @@ import (
 	"archive/zip"
 	"io"
 	"iter"
+	"log/slog"
 	"os"
+
+	"github.com/Jictyvoo/ink_stream/pkg/comicinfo"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 type CBZExtractor struct {
 	zipReader *zip.Reader
+	metadata  inktypes.BookMetadata
 }
 
 func NewCBZExtractor(filePointer *os.File) (*CBZExtractor, error) {
@@ -22,24 +27,67 @@ func NewCBZExtractor(filePointer *os.File) (*CBZExtractor, error) {
 		return nil, err
 	}
 
-	return &CBZExtractor{zipReader: zipFile}, nil
+	extractor := &CBZExtractor{zipReader: zipFile}
+	extractor.readMetadata()
+	return extractor, nil
+}
+
+// readMetadata loads the ComicInfo.xml entry, using the ComicBookInfo
+// from the zip comment to fill the fields it does not have.
+func (e *CBZExtractor) readMetadata() {
+	filenames := make([]string, 0, len(e.zipReader.File))
+	for _, innerFile := range e.zipReader.File {
+		if innerFile != nil {
+			filenames = append(filenames, innerFile.Name)
+		}
+	}
+
+	for _, innerFile := range e.zipReader.File {
+		if innerFile == nil || !isComicInfoFile(innerFile.Name) {
+			continue
+		}
+
+		data, err := readZipFile(innerFile)
+		if err == nil {
+			e.metadata, err = comicInfoMetadata(data, filenames)
+		}
+		if err != nil {
+			slog.Warn(
+				"Failed to read "+comicinfo.FileName,
+				slog.String("error", err.Error()),
+			)
+		}
+		break
+	}
+
+	if bookInfo, ok := comicinfo.DecodeComicBookInfo(e.zipReader.Comment); ok {
+		e.metadata.Merge(bookInfo.BookMetadata())
+	}
+}
+
+func (e *CBZExtractor) Metadata() inktypes.BookMetadata {
+	return e.metadata
+}
+
+func readZipFile(innerFile *zip.File) ([]byte, error) {
+	open, err := innerFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer open.Close()
+
+	return io.ReadAll(open)
 }
 
 func (e *CBZExtractor) FileSeq() iter.Seq2[FileName, FileResult] {
 	return func(yield func(FileName, FileResult) bool) {
 		for _, innerFile := range e.zipReader.File {
-			if innerFile == nil {
+			if innerFile == nil || isComicInfoFile(innerFile.Name) {
 				continue
 			}
 
 			yieldResult := FileResult{}
-			open, err := innerFile.Open()
-			if err != nil {
-				yieldResult.Error = err
-			} else {
-				yieldResult.Data, yieldResult.Error = io.ReadAll(open)
-			}
-
+			yieldResult.Data, yieldResult.Error = readZipFile(innerFile)
 			if !yield(FileName(innerFile.Name), yieldResult) {
 				return
 			}
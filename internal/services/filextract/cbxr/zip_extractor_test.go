@@ -5,8 +5,11 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 func TestCBZExtractor_FileSeq(t *testing.T) {
@@ -111,3 +114,79 @@ func TestCBZExtractor_FileSeq(t *testing.T) {
 		})
 	}
 }
+
+func TestCBZExtractor_Metadata(t *testing.T) {
+	const comicInfoContent = `<?xml version="1.0"?>
+<ComicInfo>
+  <Series>Series</Series>
+  <Number>2</Number>
+  <Writer>Writer Name</Writer>
+  <Manga>YesAndRightToLeft</Manga>
+  <Pages>
+    <Page Image="0" Type="FrontCover"/>
+    <Page Image="1" Type="Deleted"/>
+    <Page Image="2" Bookmark="Chapter 1"/>
+  </Pages>
+</ComicInfo>`
+	const comment = `{"ComicBookInfo/1.0":{"title":"Title","series":"Other","language":"ja"}}`
+
+	zipBuffer := new(bytes.Buffer)
+	writer := zip.NewWriter(zipBuffer)
+	// Entries out of order, as the pages are indexed by name
+	for _, name := range []string{"003.jpg", "ComicInfo.xml", "001.jpg", "002.jpg", "notes.txt"} {
+		content := "image"
+		if name == "ComicInfo.xml" {
+			content = comicInfoContent
+		}
+		fw, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create file %q in zip: %v", name, err)
+		}
+		if _, err = fw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write content for %q in zip: %v", name, err)
+		}
+	}
+	if err := writer.SetComment(comment); err != nil {
+		t.Fatalf("Failed to set zip comment: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "book.cbz")
+	if err := os.WriteFile(filename, zipBuffer.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	zipFile, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("Failed to open zip file: %v", err)
+	}
+	defer zipFile.Close()
+
+	extractor, err := NewCBZExtractor(zipFile)
+	if err != nil {
+		t.Fatalf("Failed to create extractor: %v", err)
+	}
+
+	expected := inktypes.BookMetadata{
+		Title:         "Title",
+		Series:        "Series",
+		Number:        "2",
+		Writers:       []string{"Writer Name"},
+		Language:      "ja",
+		ReadDirection: inktypes.ReadRightToLeft,
+		Pages: map[string]inktypes.PageInfo{
+			"001": {Type: inktypes.PageFrontCover},
+			"002": {Type: inktypes.PageDeleted},
+			"003": {Bookmark: "Chapter 1"},
+		},
+	}
+	if metadata := extractor.Metadata(); !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("Metadata() = %+v, want %+v", metadata, expected)
+	}
+
+	suite := ExtractTestSuite{
+		WantFiles: []string{"003.jpg", "001.jpg", "002.jpg", "notes.txt"},
+	}
+	suite.Run(t, extractor)
+}
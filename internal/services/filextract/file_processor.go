@@ -10,23 +10,27 @@ import (
 	"strings"
 
 	"github.com/Jictyvoo/ink_stream/internal/services/filextract/cbxr"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 type FileProcessorWorker struct {
 	OutputFolder   string
 	FilenameStream chan FileInfo
 	fileProcessFac FileOutputFactory
+	exclusionRules ExclusionRules
 }
 
 func NewFileProcessorWorker(
 	filenameStream chan FileInfo,
 	outputFolder string,
 	fileProcessFac FileOutputFactory,
+	exclusionRules ExclusionRules,
 ) *FileProcessorWorker {
 	return &FileProcessorWorker{
 		FilenameStream: filenameStream,
 		OutputFolder:   outputFolder,
 		fileProcessFac: fileProcessFac,
+		exclusionRules: exclusionRules,
 	}
 }
 
@@ -62,17 +66,32 @@ func (fp *FileProcessorWorker) processFile(file FileInfo) (resultErr error) {
 		extractor           cbxr.Extractor
 		fileOutputProcessor FileOutputWriter
 	)
-	if fileOutputProcessor, err = fp.fileProcessFac(extractDir); err != nil {
-		return fmt.Errorf("failed to create file output processor: %w", err)
-	}
-	defer fileOutputProcessor.Close()
-
 	if extractor, err = fp.newExtractor(file, filePointer); err != nil {
 		slog.Error("Failed to create extractor", slog.String("error", err.Error()))
 		return err
 	}
 
-	var totalSent uint64
+	// The sidecar file metadata has priority over the one found inside the source
+	metadata, sidecarErr := cbxr.SidecarMetadata(file.CompleteName)
+	if sidecarErr != nil {
+		slog.Warn(
+			"Failed to read sidecar metadata",
+			slog.String("filename", file.CompleteName),
+			slog.String("error", sidecarErr.Error()),
+		)
+	}
+	if metadataExtractor, ok := extractor.(cbxr.MetadataExtractor); ok {
+		metadata.Merge(metadataExtractor.Metadata())
+	}
+	if fileOutputProcessor, err = fp.fileProcessFac(extractDir, metadata); err != nil {
+		return fmt.Errorf("failed to create file output processor: %w", err)
+	}
+	defer fileOutputProcessor.Close()
+
+	var (
+		totalSent     uint64
+		bookExclusion = fp.exclusionRules.NewBookExclusion()
+	)
 	for fileName, fileResult := range extractor.FileSeq() {
 		if fileResult.Error != nil {
 			return fileResult.Error
@@ -81,9 +100,18 @@ func (fp *FileProcessorWorker) processFile(file FileInfo) (resultErr error) {
 			continue
 		}
 
-		fileBase := filepath.Base(string(fileName))
-		if strings.HasPrefix(strings.ToLower(fileBase), "cred") &&
-			len(fileBase) >= len("000.jpeg") {
+		if metadata.Page(string(fileName)).Type == inktypes.PageDeleted {
+			continue
+		}
+		if reason, excluded := bookExclusion.Excluded(
+			string(fileName), fileResult.Data,
+		); excluded {
+			slog.Info(
+				"Excluded page",
+				slog.String("inputFile", file.CompleteName),
+				slog.String("filename", string(fileName)),
+				slog.String("reason", reason),
+			)
 			continue
 		}
 
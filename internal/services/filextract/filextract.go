@@ -1,5 +1,7 @@
 package filextract
 
+import "github.com/Jictyvoo/ink_stream/pkg/inktypes"
+
 type FileInfo struct {
 	CompleteName string
 	BaseName     string
@@ -11,4 +13,7 @@ type FileOutputWriter interface {
 	Process(filename string, data []byte)
 }
 
-type FileOutputFactory func(outputDir string) (FileOutputWriter, error)
+// FileOutputFactory creates the writer of a book, given the metadata found by its extractor.
+type FileOutputFactory func(
+	outputDir string, metadata inktypes.BookMetadata,
+) (FileOutputWriter, error)
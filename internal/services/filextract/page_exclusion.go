@@ -0,0 +1,120 @@
+package filextract
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+// ExclusionRules tells which entries extracted from a book are dropped, like the
+// scanlator credit and recruitment pages.
+type ExclusionRules struct {
+	// Include globs, when not empty, only the entries matching one of them are kept
+	Include []string
+	// Exclude globs drop the matching entries
+	Exclude []string
+	// ReferenceHashes are the perceptual hashes of known credit pages
+	ReferenceHashes []imgutils.ImageHash
+	// MaxHashDistance is the largest hash distance of a page taken as a reference page copy
+	MaxHashDistance int
+	// DropDuplicates drops the pages looking like a previous page of the same book
+	DropDuplicates bool
+}
+
+// duplicateMaxDistance is the largest hash distance between two pages taken as the same
+// page, it's low as the pages of a chapter may look alike.
+const duplicateMaxDistance = 4
+
+type hashedPage struct {
+	fileName string
+	hash     imgutils.ImageHash
+}
+
+// BookExclusion applies the exclusion rules on the pages of a single book, remembering
+// the kept pages to find their duplicates. A page is only a duplicate of a page before
+// it in the book, which is ordered by name, whatever the order the archive stores them.
+// As the kept pages were already sent, a page only found after its later copy is kept,
+// along with the copy.
+type BookExclusion struct {
+	rules     ExclusionRules
+	keptPages []hashedPage
+}
+
+// NewBookExclusion starts applying the rules on a new book.
+func (rules ExclusionRules) NewBookExclusion() *BookExclusion {
+	return &BookExclusion{rules: rules}
+}
+
+// Validate checks that all the globs are well-formed.
+func (rules ExclusionRules) Validate() error {
+	for _, pattern := range slices.Concat(rules.Include, rules.Exclude) {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return fmt.Errorf("invalid glob `%s`: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Excluded tells if the entry must be dropped, returning the reason to be reported.
+// The globs are case-insensitive and match the entry base name, or its whole path
+// when the pattern has a slash.
+func (book *BookExclusion) Excluded(fileName string, data []byte) (reason string, excluded bool) {
+	rules := book.rules
+	if len(rules.Include) > 0 {
+		if _, found := matchGlob(rules.Include, fileName); !found {
+			return "not included", true
+		}
+	}
+	if pattern, found := matchGlob(rules.Exclude, fileName); found {
+		return fmt.Sprintf("matches glob `%s`", pattern), true
+	}
+
+	if len(rules.ReferenceHashes) == 0 && !rules.DropDuplicates {
+		return "", false
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil { // Leave it to the image processor, which reports the failure
+		return "", false
+	}
+	pageHash := imgutils.PerceptualHash(img)
+	for index, referenceHash := range rules.ReferenceHashes {
+		if distance := pageHash.Distance(referenceHash); distance <= rules.MaxHashDistance {
+			return fmt.Sprintf("looks like reference image #%d, distance %d", index, distance), true
+		}
+	}
+
+	if !rules.DropDuplicates {
+		return "", false
+	}
+	for _, page := range book.keptPages {
+		if page.fileName > fileName { // The page comes after it in the book
+			continue
+		}
+		if distance := pageHash.Distance(page.hash); distance <= duplicateMaxDistance {
+			return fmt.Sprintf("duplicate of `%s`, distance %d", page.fileName, distance), true
+		}
+	}
+	book.keptPages = append(book.keptPages, hashedPage{fileName: fileName, hash: pageHash})
+	return "", false
+}
+
+func matchGlob(patterns []string, fileName string) (pattern string, found bool) {
+	entryPath := strings.ToLower(filepath.ToSlash(fileName))
+	baseName := path.Base(entryPath)
+	for _, pattern = range patterns {
+		target := baseName
+		if strings.Contains(pattern, "/") {
+			target = entryPath
+		}
+		if matched, _ := path.Match(strings.ToLower(pattern), target); matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,202 @@
+package filextract
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestBookExclusion_Excluded(t *testing.T) {
+	encodePNG := func(img image.Image) []byte {
+		var buffer bytes.Buffer
+		if err := png.Encode(&buffer, img); err != nil {
+			t.Fatalf("png.Encode: %v", err)
+		}
+		return buffer.Bytes()
+	}
+	creditPage := testimgs.ImageBlackSquareWhiteMargin()
+	mangaPage := testimgs.ImageMultiColorSquare()
+
+	testCases := []struct {
+		name     string
+		rules    ExclusionRules
+		fileName string
+		data     []byte
+		expected bool
+	}{
+		{
+			name:     "No rules",
+			fileName: "chapter01/credits.png",
+			data:     encodePNG(creditPage),
+			expected: false,
+		},
+		{
+			name:     "Excluded base name",
+			rules:    ExclusionRules{Exclude: []string{"cred*"}},
+			fileName: "chapter01/Credits.png",
+			expected: true,
+		},
+		{
+			name:     "Page whose name is not excluded",
+			rules:    ExclusionRules{Exclude: []string{"cred*"}},
+			fileName: "chapter01/page_credits.png",
+			expected: false,
+		},
+		{
+			name:     "Excluded path",
+			rules:    ExclusionRules{Exclude: []string{"extras/*"}},
+			fileName: "extras/001.png",
+			expected: true,
+		},
+		{
+			name:     "Page not included",
+			rules:    ExclusionRules{Include: []string{"*.jpg", "*.png"}},
+			fileName: "chapter01/readme.txt",
+			expected: true,
+		},
+		{
+			name:     "Included page",
+			rules:    ExclusionRules{Include: []string{"*.jpg", "*.png"}},
+			fileName: "chapter01/001.PNG",
+			data:     encodePNG(mangaPage),
+			expected: false,
+		},
+		{
+			name: "Page looking like a reference image",
+			rules: ExclusionRules{
+				ReferenceHashes: []imgutils.ImageHash{imgutils.PerceptualHash(creditPage)},
+				MaxHashDistance: 8,
+			},
+			fileName: "chapter01/099.png",
+			data:     encodePNG(creditPage),
+			expected: true,
+		},
+		{
+			name: "Page different from the reference images",
+			rules: ExclusionRules{
+				ReferenceHashes: []imgutils.ImageHash{imgutils.PerceptualHash(creditPage)},
+				MaxHashDistance: 8,
+			},
+			fileName: "chapter01/001.png",
+			data:     encodePNG(mangaPage),
+			expected: false,
+		},
+		{
+			name: "Undecodable page is left to the image processor",
+			rules: ExclusionRules{
+				ReferenceHashes: []imgutils.ImageHash{imgutils.PerceptualHash(creditPage)},
+				MaxHashDistance: 8,
+			},
+			fileName: "chapter01/001.png",
+			data:     []byte("not an image"),
+			expected: false,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			bookExclusion := tCase.rules.NewBookExclusion()
+			reason, excluded := bookExclusion.Excluded(tCase.fileName, tCase.data)
+			if excluded != tCase.expected {
+				t.Errorf("expected: %v, actual: %v (%s)", tCase.expected, excluded, reason)
+			}
+		})
+	}
+}
+
+func TestBookExclusion_Duplicates(t *testing.T) {
+	encodeJPEG := func(img image.Image, quality int) []byte {
+		var buffer bytes.Buffer
+		if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: quality}); err != nil {
+			t.Fatalf("jpeg.Encode: %v", err)
+		}
+		return buffer.Bytes()
+	}
+	// Each half of the manga page is taken as another page
+	coverPage := testimgs.ImageGenericMangaPage()
+	halves := imgutils.HalfSplit(coverPage.Bounds(), inktypes.OrientationLandscape)
+	leftPage := imgutils.CropImage(coverPage, halves.Left)
+	rightPage := imgutils.CropImage(coverPage, halves.Right)
+
+	pages := []struct {
+		fileName string
+		data     []byte
+		expected bool
+	}{
+		{fileName: "000_cover.jpg", data: encodeJPEG(coverPage, 95), expected: false},
+		{fileName: "001.jpg", data: encodeJPEG(coverPage, 70), expected: true},
+		{fileName: "002.jpg", data: encodeJPEG(leftPage, 90), expected: false},
+		{fileName: "003.jpg", data: encodeJPEG(rightPage, 90), expected: false},
+		{fileName: "004.jpg", data: encodeJPEG(leftPage, 80), expected: true},
+	}
+
+	t.Run("Duplicates are dropped", func(t *testing.T) {
+		bookExclusion := ExclusionRules{DropDuplicates: true}.NewBookExclusion()
+		for _, page := range pages {
+			reason, excluded := bookExclusion.Excluded(page.fileName, page.data)
+			if excluded != page.expected {
+				t.Errorf(
+					"%s: expected: %v, actual: %v (%s)",
+					page.fileName, page.expected, excluded, reason,
+				)
+			}
+		}
+	})
+	t.Run("Duplicates follow the name order", func(t *testing.T) {
+		// The archive stores the copy first, the cover still comes first in the book
+		storedPages := []struct {
+			fileName string
+			data     []byte
+			expected bool
+		}{
+			{fileName: "002.jpg", data: encodeJPEG(coverPage, 70), expected: false},
+			{fileName: "000.jpg", data: encodeJPEG(coverPage, 95), expected: false},
+			{fileName: "003.jpg", data: encodeJPEG(coverPage, 80), expected: true},
+			{fileName: "001.jpg", data: encodeJPEG(leftPage, 90), expected: false},
+		}
+		bookExclusion := ExclusionRules{DropDuplicates: true}.NewBookExclusion()
+		for _, page := range storedPages {
+			reason, excluded := bookExclusion.Excluded(page.fileName, page.data)
+			if excluded != page.expected {
+				t.Errorf(
+					"%s: expected: %v, actual: %v (%s)",
+					page.fileName, page.expected, excluded, reason,
+				)
+			}
+		}
+	})
+	t.Run("Duplicates are kept when disabled", func(t *testing.T) {
+		bookExclusion := ExclusionRules{}.NewBookExclusion()
+		for _, page := range pages {
+			if reason, excluded := bookExclusion.Excluded(page.fileName, page.data); excluded {
+				t.Errorf("%s: unexpected exclusion (%s)", page.fileName, reason)
+			}
+		}
+	})
+	t.Run("Duplicates are found on each book", func(t *testing.T) {
+		rules := ExclusionRules{DropDuplicates: true}
+		for _, page := range pages[:2] {
+			bookExclusion := rules.NewBookExclusion()
+			if _, excluded := bookExclusion.Excluded(page.fileName, page.data); excluded {
+				t.Errorf("%s: unexpected exclusion", page.fileName)
+			}
+		}
+	})
+}
+
+func TestExclusionRules_Validate(t *testing.T) {
+	rules := ExclusionRules{Include: []string{"*.png"}, Exclude: []string{"cred[*"}}
+	if err := rules.Validate(); err == nil {
+		t.Error("expected an error for the malformed glob")
+	}
+	rules.Exclude = []string{"cred*"}
+	if err := rules.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
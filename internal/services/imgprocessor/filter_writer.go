@@ -1,6 +1,7 @@
 package imgprocessor
 
 import (
+	"image"
 	"io"
 
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
@@ -12,3 +13,14 @@ type FileWriter interface {
 	Handler(filename string, f WriterCallback) error
 	Flush() error
 }
+
+// MetadataWriter is implemented by the writers that use the book metadata on Flush.
+type MetadataWriter interface {
+	SetMetadata(metadata inktypes.BookMetadata)
+}
+
+// ImageWriter is implemented by the writers that also use the page image. It's given to
+// them as it was encoded, so they don't decode it back from the written file.
+type ImageWriter interface {
+	ImageHandler(filename string, img image.Image, f WriterCallback) error
+}
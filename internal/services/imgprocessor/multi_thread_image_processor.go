@@ -2,6 +2,7 @@ package imgprocessor
 
 import (
 	"bytes"
+	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
@@ -14,12 +15,17 @@ import (
 	"sync/atomic"
 
 	"github.com/Jictyvoo/ink_stream/internal/imageparser"
-	"github.com/Jictyvoo/ink_stream/internal/utils"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
 type (
-	fileEntry                 utils.Entry2[string, []byte]
+	fileEntry struct {
+		filename string
+		data     []byte
+		img      image.Image // Already decoded page, used instead of the data
+		page     imageparser.PageContext
+	}
 	MultiThreadImageProcessor struct {
 		fileWriter    FileWriter
 		imgPipeline   imageparser.ImagePipeline
@@ -28,6 +34,10 @@ type (
 		numGoroutines uint8
 		isFinished    atomic.Bool
 		encodingConf  inktypes.ImageEncodingOptions
+		sentPages     int // Pages sent to the workers, giving the index of the next page
+		stripSlicer   *stripSlicer
+		sharedCrop    bool
+		heldPages     []fileEntry // Pages held until the crop shared by the book is known
 	}
 )
 
@@ -52,22 +62,130 @@ func NewMultiThreadImageProcessor(
 	return mtip
 }
 
+// EnableStripSlicing makes the processor read the images as the vertical strips of a
+// webtoon, which are stitched and sliced again into pages of the device aspect ratio.
+func (mtip *MultiThreadImageProcessor) EnableStripSlicing(resolution inktypes.ImageDimensions) {
+	mtip.stripSlicer = newStripSlicer(resolution)
+}
+
+// EnableSharedCrop makes the processor crop all the pages of a chapter by the same box,
+// instead of cropping each page on its own. The pages are held until the book is closed,
+// when the crop of every page is analyzed and the median of each side is taken, separately
+// for the odd and even pages, as they have their margins on opposite sides. The steps
+// before the crop run on the analysis too, so the page is measured as it's cropped.
+func (mtip *MultiThreadImageProcessor) EnableSharedCrop() {
+	mtip.sharedCrop = true
+}
+
 func (mtip *MultiThreadImageProcessor) Process(filename string, data []byte) {
 	filename = strings.TrimSuffix(filename, filepath.Ext(filename))
-	mtip.inputChan <- fileEntry{
-		A: filename,
-		B: data,
+	if mtip.stripSlicer == nil {
+		mtip.send(fileEntry{filename: filename, data: data})
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		slog.Info(
+			"failed to decode strip image",
+			slog.String("filename", filename),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	mtip.sendStripPages(mtip.stripSlicer.Append(filepath.Dir(filename), img))
+}
+
+func (mtip *MultiThreadImageProcessor) send(entry fileEntry) {
+	entry.page.PageIndex = mtip.sentPages
+	mtip.sentPages++
+	if mtip.sharedCrop {
+		mtip.heldPages = append(mtip.heldPages, entry)
+		return
+	}
+	mtip.inputChan <- entry
+}
+
+// sendHeldPages analyzes the crop of the held pages, using all the workers, and sends them
+// with the crop shared by the pages of the same chapter, parity and orientation.
+// The landscape pages, usually spreads, are cropped on their own, as the margins measured
+// on the single pages would cut twice as much of them. The pages with no crop found, like
+// the blank and dropped ones, are left out of the shared crop.
+func (mtip *MultiThreadImageProcessor) sendHeldPages() {
+	type cropGroup struct {
+		chapter     string
+		isOdd       bool
+		orientation inktypes.ImageOrientation
+	}
+	var (
+		wg           sync.WaitGroup
+		mutex        sync.Mutex
+		workerSlots  = make(chan struct{}, mtip.numGoroutines)
+		pageGroups   = make([]*cropGroup, len(mtip.heldPages))
+		groupMargins = make(map[cropGroup][]imgutils.Margins[float64])
+	)
+	for index, entry := range mtip.heldPages {
+		workerSlots <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-workerSlots }()
+			img, err := entry.decode()
+			if err != nil {
+				return // The page is reported when it fails on the workers
+			}
+			analysis, found, err := mtip.imgPipeline.AnalyzeCrop(img, entry.page)
+			if err != nil || !found {
+				return
+			}
+
+			group := cropGroup{
+				chapter:     filepath.Dir(entry.filename),
+				isOdd:       entry.page.PageIndex%2 == 1,
+				orientation: analysis.Orientation,
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			pageGroups[index] = &group
+			groupMargins[group] = append(groupMargins[group], analysis.Margins)
+		})
+	}
+	wg.Wait()
+
+	sharedMargins := make(map[cropGroup]*imgutils.Margins[float64], len(groupMargins))
+	for group, margins := range groupMargins {
+		if group.orientation == inktypes.OrientationLandscape {
+			continue
+		}
+		median := imgutils.MedianCropMargins(margins)
+		sharedMargins[group] = &median
+	}
+	for index, entry := range mtip.heldPages {
+		if group := pageGroups[index]; group != nil {
+			entry.page.CropMargins = sharedMargins[*group]
+		}
+		mtip.inputChan <- entry
+	}
+	mtip.heldPages = nil
+}
+
+// sendStripPages sends the pages sliced from the strips, which are named by their
+// position inside their chapter directory, so they keep the strip order and chapter.
+func (mtip *MultiThreadImageProcessor) sendStripPages(pages []stripPage) {
+	for _, page := range pages {
+		mtip.send(fileEntry{
+			filename: filepath.Join(page.chapter, fmt.Sprintf("strip_%05d", mtip.sentPages)),
+			img:      page.img,
+		})
 	}
 }
 
 func (mtip *MultiThreadImageProcessor) workerHandl() {
 	defer mtip.wg.Done()
 	for entry := range mtip.inputChan {
-		err := mtip.run(entry.A, entry.B)
+		err := mtip.run(entry)
 		if err != nil {
 			slog.Info(
 				"failed while running image worker",
-				slog.String("filename", entry.A),
+				slog.String("filename", entry.filename),
 				slog.String("error", err.Error()),
 			)
 			return
@@ -75,44 +193,70 @@ func (mtip *MultiThreadImageProcessor) workerHandl() {
 	}
 }
 
-func (mtip *MultiThreadImageProcessor) run(fileName string, data []byte) (err error) {
+// decode returns the page image, decoding its data when it isn't decoded yet.
+func (entry fileEntry) decode() (img image.Image, err error) {
+	if entry.img != nil {
+		return entry.img, nil
+	}
+	img, _, err = image.Decode(bytes.NewReader(entry.data))
+	return img, err
+}
+
+func (mtip *MultiThreadImageProcessor) run(entry fileEntry) (err error) {
 	var decodedImg image.Image
-	if decodedImg, _, err = image.Decode(bytes.NewReader(data)); err != nil {
+	if decodedImg, err = entry.decode(); err != nil {
 		return err
 	}
 
 	var finalImgList []image.Image
-	if finalImgList, err = mtip.imgPipeline.Process(decodedImg); err != nil {
+	if finalImgList, err = mtip.imgPipeline.ProcessPage(decodedImg, entry.page); err != nil {
 		return err
 	}
 
+	imageWriter, hasImageHandler := mtip.fileWriter.(ImageWriter)
 	for index, img := range finalImgList {
-		err = mtip.fileWriter.Handler(
-			fileName+"__"+strconv.Itoa(index)+mtip.encodingConf.FileExtension(),
-			func(writer io.Writer) (metadata inktypes.ImageMetadata, err error) {
-				imgBounds := img.Bounds()
-				metadata = inktypes.ImageMetadata{
-					ImageDimensions: inktypes.ImageDimensions{
-						Width:  uint16(imgBounds.Dx()),
-						Height: uint16(imgBounds.Dy()),
-					},
-					ImageEncodingOptions: mtip.encodingConf,
-				}
-				if mtip.encodingConf.Format == inktypes.FormatPNG {
-					err = png.Encode(writer, img)
-				} else { // Default fallback to JPEG
-					err = jpeg.Encode(writer, img, &jpeg.Options{Quality: int(mtip.encodingConf.Quality)})
-				}
-				return metadata, err
-			},
-		)
+		filename := entry.filename + "__" + strconv.Itoa(index) + mtip.encodingConf.FileExtension()
+		callback := func(writer io.Writer) (metadata inktypes.ImageMetadata, err error) {
+			imgBounds := img.Bounds()
+			metadata = inktypes.ImageMetadata{
+				ImageDimensions: inktypes.ImageDimensions{
+					Width:  uint16(imgBounds.Dx()),
+					Height: uint16(imgBounds.Dy()),
+				},
+				ImageEncodingOptions: mtip.encodingConf,
+			}
+			if mtip.encodingConf.Format == inktypes.FormatPNG {
+				err = png.Encode(writer, img)
+			} else { // Default fallback to JPEG
+				err = jpeg.Encode(writer, img, &jpeg.Options{Quality: int(mtip.encodingConf.Quality)})
+			}
+			return metadata, err
+		}
+		if hasImageHandler {
+			err = imageWriter.ImageHandler(filename, img, callback)
+		} else {
+			err = mtip.fileWriter.Handler(filename, callback)
+		}
 	}
 
 	return err
 }
 
+// SetMetadata forwards the book metadata to the file writer, when it makes use of it.
+func (mtip *MultiThreadImageProcessor) SetMetadata(metadata inktypes.BookMetadata) {
+	if metadataWriter, ok := mtip.fileWriter.(MetadataWriter); ok {
+		metadataWriter.SetMetadata(metadata)
+	}
+}
+
 func (mtip *MultiThreadImageProcessor) Close() error {
 	if !mtip.isFinished.Swap(true) {
+		if mtip.stripSlicer != nil {
+			mtip.sendStripPages(mtip.stripSlicer.Flush())
+		}
+		if mtip.sharedCrop {
+			mtip.sendHeldPages()
+		}
 		close(mtip.inputChan)
 	}
 	return nil
@@ -0,0 +1,186 @@
+package imgprocessor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"maps"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/imageparser"
+	"github.com/Jictyvoo/ink_stream/internal/imageparser/imgpipesteps"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// sizeRecorderWriter records the dimensions of each written image by its name.
+type sizeRecorderWriter struct {
+	mutex sync.Mutex
+	sizes map[string]inktypes.ImageDimensions
+}
+
+func (writer *sizeRecorderWriter) Handler(filename string, f WriterCallback) error {
+	metadata, err := f(io.Discard)
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	writer.sizes[strings.TrimSuffix(filename, "__0.png")] = metadata.ImageDimensions
+	return err
+}
+
+func (writer *sizeRecorderWriter) Flush() error { return nil }
+
+// imageRecorderWriter records the images given to it, by their name.
+type imageRecorderWriter struct {
+	sizeRecorderWriter
+	images map[string]image.Image
+}
+
+func (writer *imageRecorderWriter) ImageHandler(
+	filename string, img image.Image, f WriterCallback,
+) error {
+	writer.mutex.Lock()
+	writer.images[filename] = img
+	writer.mutex.Unlock()
+	return writer.Handler(filename, f)
+}
+
+func TestMultiThreadImageProcessor_ImageWriter(t *testing.T) {
+	page := testimgs.NewSolidImage(image.Rect(0, 0, 40, 60), color.White)
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, page); err != nil {
+		t.Fatalf("failed to encode page: %v", err)
+	}
+
+	writer := &imageRecorderWriter{
+		sizeRecorderWriter: sizeRecorderWriter{sizes: make(map[string]inktypes.ImageDimensions)},
+		images:             make(map[string]image.Image),
+	}
+	mtip := NewMultiThreadImageProcessor(
+		imageparser.NewImagePipeline(nil),
+		writer, inktypes.ImageEncodingOptions{Format: inktypes.FormatPNG},
+	)
+	mtip.Process("page.png", buffer.Bytes())
+	if err := mtip.Shutdown(); err != nil {
+		t.Fatalf("failed to shutdown: %v", err)
+	}
+
+	img, found := writer.images["page__0.png"]
+	if !found {
+		t.Fatalf("expected the page image to be given to the writer, got %v", writer.images)
+	}
+	if size := writer.sizes["page"]; img.Bounds() != page.Bounds() ||
+		size != (inktypes.ImageDimensions{Width: 40, Height: 60}) {
+		t.Errorf(
+			"expected the written page to be the given image, got %v and %v",
+			img.Bounds(),
+			size,
+		)
+	}
+}
+
+func TestMultiThreadImageProcessor_SharedCrop(t *testing.T) {
+	borderedPage := func(size image.Rectangle, border int) image.Image {
+		return testimgs.NewBorderedImage(
+			size, border, border, border, border, color.White, color.Black,
+		)
+	}
+	// The full bleed page has art reaching its borders, so no crop is found on it
+	fullBleedPage := image.NewGray(image.Rect(0, 0, 100, 100))
+	draw.Draw(fullBleedPage, fullBleedPage.Bounds(), image.Black, image.Point{}, draw.Src)
+	draw.Draw(fullBleedPage, image.Rect(30, 30, 70, 70), image.White, image.Point{}, draw.Src)
+	blankPage := testimgs.NewSolidImage(image.Rect(0, 0, 100, 100), color.White)
+
+	palette := color.Palette{color.White, color.Black}
+	writer := &sizeRecorderWriter{sizes: make(map[string]inktypes.ImageDimensions)}
+	mtip := NewMultiThreadImageProcessor(
+		imageparser.NewImagePipeline(
+			palette,
+			imgpipesteps.NewStepAutoCrop(palette, imgpipesteps.AutoCropOptions{}),
+		),
+		writer,
+		inktypes.ImageEncodingOptions{Format: inktypes.FormatPNG},
+	)
+	mtip.EnableSharedCrop()
+
+	// Odd pages have thinner borders than the even ones. The blank pages would bring the
+	// even median to zero, and the spread must not receive the odd pages crop.
+	pages := []image.Image{
+		borderedPage(image.Rect(0, 0, 100, 100), 10), borderedPage(image.Rect(0, 0, 100, 100), 5),
+		borderedPage(image.Rect(0, 0, 100, 100), 10), borderedPage(image.Rect(0, 0, 100, 100), 5),
+		fullBleedPage, borderedPage(image.Rect(0, 0, 200, 100), 10),
+		blankPage, borderedPage(image.Rect(0, 0, 100, 100), 5),
+		blankPage,
+	}
+	for index, page := range pages {
+		var buffer bytes.Buffer
+		if err := png.Encode(&buffer, page); err != nil {
+			t.Fatalf("failed to encode page: %v", err)
+		}
+		mtip.Process("chapter01/page"+string(rune('a'+index))+".png", buffer.Bytes())
+	}
+	if err := mtip.Shutdown(); err != nil {
+		t.Fatalf("failed to shutdown: %v", err)
+	}
+
+	if len(writer.sizes) != len(pages) {
+		t.Fatalf("expected %d pages, got %d", len(pages), len(writer.sizes))
+	}
+	evenSize, oddSize := writer.sizes["chapter01/pagea"], writer.sizes["chapter01/pageb"]
+	if evenSize == oddSize || evenSize.Width >= 100 || oddSize.Width >= 100 {
+		t.Fatalf("expected distinct crops for odd and even pages, got %v and %v", evenSize, oddSize)
+	}
+	expectedSizes := map[string]inktypes.ImageDimensions{
+		"chapter01/pagec": evenSize,
+		"chapter01/pagee": evenSize,
+		"chapter01/paged": oddSize,
+		"chapter01/pageh": oddSize,
+		// The spread is cropped on its own
+		"chapter01/pagef": {Width: 182, Height: 82},
+		// The blank pages have nothing to crop
+		"chapter01/pageg": {Width: 100, Height: 100},
+	}
+	for name, expected := range expectedSizes {
+		if size := writer.sizes[name]; size != expected {
+			t.Errorf("expected page %s to have size %v, got %v", name, expected, size)
+		}
+	}
+}
+
+func TestMultiThreadImageProcessor_StripSlicing(t *testing.T) {
+	encodeStrip := func(height int) []byte {
+		img := testimgs.NewSolidImage(image.Rect(0, 0, 100, height), color.White)
+		var buffer bytes.Buffer
+		if err := png.Encode(&buffer, img); err != nil {
+			t.Fatalf("failed to encode strip: %v", err)
+		}
+		return buffer.Bytes()
+	}
+
+	writer := &sizeRecorderWriter{sizes: make(map[string]inktypes.ImageDimensions)}
+	mtip := NewMultiThreadImageProcessor(
+		imageparser.NewImagePipeline(nil),
+		writer, inktypes.ImageEncodingOptions{Format: inktypes.FormatPNG},
+	)
+	mtip.EnableStripSlicing(inktypes.ImageDimensions{Width: 600, Height: 900})
+	mtip.Process("chapter01/001.png", encodeStrip(300))
+	mtip.Process("chapter01/002.png", encodeStrip(300))
+	mtip.Process("chapter02/001.png", encodeStrip(150))
+	if err := mtip.Shutdown(); err != nil {
+		t.Fatalf("failed to shutdown: %v", err)
+	}
+
+	chapterPages := make(map[string]int)
+	for name := range writer.sizes {
+		chapterPages[filepath.Dir(name)]++
+	}
+	expected := map[string]int{"chapter01": 4, "chapter02": 1}
+	if !maps.Equal(chapterPages, expected) {
+		t.Errorf("expected pages by chapter: %v, actual: %v", expected, chapterPages)
+	}
+}
@@ -0,0 +1,114 @@
+package imgprocessor
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// stripPage is a page sliced from the strips of a chapter.
+type stripPage struct {
+	chapter string
+	img     image.Image
+}
+
+// stripSlicer stitches the tall strips of a webtoon chapter, and slices them again into
+// pages with the device aspect ratio, cutting on the whitespace between the panels.
+// When the page aspect is unknown, as the resolution is zero, the strips are kept whole.
+type stripSlicer struct {
+	pageAspect float64 // Height of the pages over their width, zero keeps the strips whole
+	chapter    string
+	strip      *image.RGBA // Stitched strips that are not sliced yet
+}
+
+func newStripSlicer(resolution inktypes.ImageDimensions) *stripSlicer {
+	if resolution.Width == 0 || resolution.Height == 0 {
+		return &stripSlicer{}
+	}
+	return &stripSlicer{
+		pageAspect: float64(resolution.Height) / float64(resolution.Width),
+	}
+}
+
+// Append stitches the image at the end of the chapter strip, returning the pages that
+// are complete. The strip of the previous chapter is flushed when a new one starts.
+func (slicer *stripSlicer) Append(chapter string, img image.Image) (pages []stripPage) {
+	if chapter != slicer.chapter {
+		pages = slicer.Flush()
+		slicer.chapter = chapter
+	}
+	if slicer.pageAspect == 0 {
+		return append(pages, stripPage{chapter: chapter, img: img})
+	}
+
+	slicer.stitch(img)
+	return append(pages, slicer.slice(false)...)
+}
+
+// Flush slices all the remaining strip, the last page may be shorter than the others.
+func (slicer *stripSlicer) Flush() (pages []stripPage) {
+	pages = slicer.slice(true)
+	slicer.strip = nil
+	return pages
+}
+
+// stitch appends the image at the end of the strip, rescaling it to the strip width.
+func (slicer *stripSlicer) stitch(img image.Image) {
+	imgBounds := img.Bounds()
+	if imgBounds.Empty() {
+		return
+	}
+
+	width, stripHeight := imgBounds.Dx(), 0
+	if slicer.strip != nil {
+		width, stripHeight = slicer.strip.Bounds().Dx(), slicer.strip.Bounds().Dy()
+	}
+	imgHeight := imgBounds.Dy() * width / imgBounds.Dx()
+
+	stitched := image.NewRGBA(image.Rect(0, 0, width, stripHeight+imgHeight))
+	if slicer.strip != nil {
+		draw.Draw(
+			stitched, image.Rect(0, 0, width, stripHeight),
+			slicer.strip, slicer.strip.Bounds().Min, draw.Src,
+		)
+	}
+	imgTarget := image.Rect(0, stripHeight, width, stripHeight+imgHeight)
+	if imgBounds.Dx() == width {
+		draw.Draw(stitched, imgTarget, img, imgBounds.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(stitched, imgTarget, img, imgBounds, draw.Src, nil)
+	}
+	slicer.strip = stitched
+}
+
+// slice cuts the pages from the top of the strip. Unless it's the final slice, enough
+// strip is kept after the pages to search the next cut, as the next image may continue
+// the last panel.
+func (slicer *stripSlicer) slice(final bool) (pages []stripPage) {
+	if slicer.strip == nil {
+		return nil
+	}
+
+	pageHeight := max(1, int(float64(slicer.strip.Bounds().Dx())*slicer.pageAspect))
+	keptHeight := int(float64(pageHeight)*imgutils.StripMaxPageFraction) + 1
+	if final {
+		keptHeight = 0
+	}
+	for bounds := slicer.strip.Bounds(); bounds.Dy() > keptHeight; bounds = slicer.strip.Bounds() {
+		cut := imgutils.FindStripCut(slicer.strip, pageHeight)
+		pages = append(pages, stripPage{
+			chapter: slicer.chapter,
+			img: slicer.strip.SubImage(
+				image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, cut),
+			),
+		})
+		slicer.strip = slicer.strip.SubImage(
+			image.Rect(bounds.Min.X, cut, bounds.Max.X, bounds.Max.Y),
+		).(*image.RGBA)
+	}
+
+	return pages
+}
@@ -0,0 +1,82 @@
+package imgprocessor
+
+import (
+	"image"
+	"image/draw"
+	"slices"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestStripSlicer(t *testing.T) {
+	// newStrip draws a white strip with a black panel on each of the given row ranges
+	newStrip := func(width, height int, panelRows ...[2]int) image.Image {
+		img := image.NewGray(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		for _, rows := range panelRows {
+			panel := image.Rect(width/10, rows[0], width-width/10, rows[1])
+			draw.Draw(img, panel, image.Black, image.Point{}, draw.Src)
+		}
+		return img
+	}
+	pageHeights := func(pages []stripPage) []int {
+		heights := make([]int, 0, len(pages))
+		for _, page := range pages {
+			heights = append(heights, page.img.Bounds().Dy())
+		}
+		return heights
+	}
+
+	// Pages of 100x150, the panels are cut by the strip borders
+	slicer := newStripSlicer(inktypes.ImageDimensions{Width: 600, Height: 900})
+	var pages []stripPage
+	pages = append(pages, slicer.Append("chapter01", newStrip(100, 200, [2]int{10, 160}))...)
+	pages = append(pages, slicer.Append("chapter01", newStrip(100, 300,
+		[2]int{0, 100}, [2]int{120, 270},
+	))...)
+	// The strip of another width is rescaled to the chapter strip width
+	pages = append(pages, slicer.Append("chapter01", newStrip(200, 200, [2]int{20, 180}))...)
+	pages = append(pages, slicer.Append("chapter02", newStrip(100, 100, [2]int{10, 90}))...)
+	pages = append(pages, slicer.Flush()...)
+
+	expectedHeights := []int{160, 150, 160, 130, 100}
+	heights := pageHeights(pages)
+	if len(heights) != len(expectedHeights) {
+		t.Fatalf("expected page heights: %v, actual: %v", expectedHeights, heights)
+	}
+	for index, height := range heights {
+		if height != expectedHeights[index] {
+			t.Errorf("expected page heights: %v, actual: %v", expectedHeights, heights)
+			break
+		}
+		if width := pages[index].img.Bounds().Dx(); width != 100 {
+			t.Errorf("expected page width: 100, actual: %d", width)
+		}
+	}
+
+	expectedChapters := []string{"chapter01", "chapter01", "chapter01", "chapter01", "chapter02"}
+	for index, page := range pages {
+		if page.chapter != expectedChapters[index] {
+			t.Errorf(
+				"expected page %d on %s, actual: %s",
+				index,
+				expectedChapters[index],
+				page.chapter,
+			)
+		}
+	}
+
+	t.Run("Zero resolution keeps the strips whole", func(t *testing.T) {
+		slicer := newStripSlicer(inktypes.ImageDimensions{})
+		var pages []stripPage
+		pages = append(pages, slicer.Append("chapter01", newStrip(100, 200, [2]int{10, 160}))...)
+		pages = append(pages, slicer.Append("chapter01", newStrip(100, 300, [2]int{0, 100}))...)
+		pages = append(pages, slicer.Flush()...)
+
+		expectedHeights := []int{200, 300}
+		if heights := pageHeights(pages); !slices.Equal(heights, expectedHeights) {
+			t.Errorf("expected page heights: %v, actual: %v", expectedHeights, heights)
+		}
+	})
+}
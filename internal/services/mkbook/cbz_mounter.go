@@ -0,0 +1,207 @@
+package mkbook
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Jictyvoo/ink_stream/internal/services/imgprocessor"
+	"github.com/Jictyvoo/ink_stream/pkg/comicinfo"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+var _ imgprocessor.FileWriter = (*CBZMounter)(nil)
+
+type cbzPageData struct {
+	fileName   string
+	sourceName string
+	isCover    bool
+	size       int64
+	metadata   inktypes.ImageMetadata
+}
+
+// CBZMounter streams the processed pages into a zip archive,
+// finishing it with a generated ComicInfo.xml on Flush.
+type CBZMounter struct {
+	title         string
+	readDirection inktypes.ReadDirection
+	file          *os.File
+	zipWriter     *zip.Writer
+	pages         []cbzPageData
+	bookMetadata  inktypes.BookMetadata
+	sync.Mutex
+}
+
+func NewCBZMounter(
+	outputDirectory string, readDirection inktypes.ReadDirection,
+) (*CBZMounter, error) {
+	file, err := os.Create(outputDirectory + ".cbz")
+	if err != nil {
+		return nil, err
+	}
+
+	return &CBZMounter{
+		title:         bookTitle(outputDirectory),
+		readDirection: readDirection,
+		file:          file,
+		zipWriter:     zip.NewWriter(file),
+	}, nil
+}
+
+// SetMetadata keeps the book metadata, written on the ComicInfo.xml on Flush.
+func (cm *CBZMounter) SetMetadata(metadata inktypes.BookMetadata) {
+	cm.Lock()
+	defer cm.Unlock()
+	cm.bookMetadata = metadata
+}
+
+func (cm *CBZMounter) Handler(filename string, callback imgprocessor.WriterCallback) error {
+	sourceName := filename
+	// Encode outside the lock, so pages keep being processed in parallel
+	var buffer bytes.Buffer
+	imgMetadata, err := callback(&buffer)
+	if err != nil {
+		return fmt.Errorf("error while processing file %s: %w", filename, err)
+	}
+
+	filename = strings.TrimLeft(normalizeFileName(filename), "./")
+	cm.Lock()
+	defer cm.Unlock()
+	return cm.writePage(cbzPageData{
+		fileName:   filename,
+		sourceName: sourceName,
+		size:       int64(buffer.Len()),
+		metadata:   imgMetadata,
+	}, &buffer)
+}
+
+// writePage writes the page entry on the archive, it must be called holding the lock.
+func (cm *CBZMounter) writePage(page cbzPageData, content io.Reader) error {
+	header := &zip.FileHeader{Name: page.fileName, Method: zip.Deflate}
+	// JPEG data is already compressed, deflating it again only costs time
+	switch strings.ToLower(filepath.Ext(page.fileName)) {
+	case ".jpg", ".jpeg":
+		header.Method = zip.Store
+	}
+
+	entryWriter, err := cm.zipWriter.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("error while creating cbz entry `%s`: %w", page.fileName, err)
+	}
+	if _, err = io.Copy(entryWriter, content); err != nil {
+		return fmt.Errorf("error while writing file `%s` to cbz: %w", page.fileName, err)
+	}
+
+	cm.pages = append(cm.pages, page)
+	return nil
+}
+
+// writeCover adds the explicit cover image as a page, flagged as the front cover.
+func (cm *CBZMounter) writeCover(coverPath string) error {
+	coverMetadata, err := coverImageMetadata(coverPath)
+	if err != nil {
+		return err
+	}
+	coverFile, err := os.Open(coverPath)
+	if err != nil {
+		return err
+	}
+	defer coverFile.Close()
+	stat, err := coverFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	return cm.writePage(cbzPageData{
+		// Readers order the pages by name, so the cover name must come first
+		fileName: "000000_cover" + strings.ToLower(filepath.Ext(coverPath)),
+		isCover:  true,
+		size:     stat.Size(),
+		metadata: coverMetadata,
+	}, coverFile)
+}
+
+func (cm *CBZMounter) comicInfo() comicinfo.ComicInfo {
+	// Readers order the pages by name, so the first one by name is the cover
+	slices.SortFunc(cm.pages, func(a, b cbzPageData) int {
+		return strings.Compare(a.fileName, b.fileName)
+	})
+
+	info := comicinfo.ComicInfo{
+		Title:       cm.title,
+		Series:      cm.bookMetadata.Series,
+		Number:      cm.bookMetadata.Number,
+		Summary:     cm.bookMetadata.Summary,
+		Publisher:   cm.bookMetadata.Publisher,
+		Writer:      strings.Join(cm.bookMetadata.Writers, ", "),
+		LanguageISO: cm.bookMetadata.Language,
+		PageCount:   len(cm.pages),
+		Manga:       comicinfo.NewMangaType(cm.readDirection),
+		Pages:       make([]comicinfo.Page, 0, len(cm.pages)),
+	}
+	if title := cm.bookMetadata.FullTitle(); title != "" {
+		info.Title = title
+	}
+	info.SetPublishDate(cm.bookMetadata.PublishDate)
+
+	// Pages were already written, so the deleted ones are only flagged for the readers
+	isCover := func(page cbzPageData) bool {
+		return page.isCover ||
+			pageInfo(cm.bookMetadata, page.sourceName).Type == inktypes.PageFrontCover
+	}
+	hasCover := slices.ContainsFunc(cm.pages, isCover)
+	for index, page := range cm.pages {
+		pageMetadata := pageInfo(cm.bookMetadata, page.sourceName)
+		pageType := pageMetadata.Type
+		switch {
+		case page.isCover, !hasCover && index == 0:
+			pageType = comicinfo.PageFrontCover
+		case pageType == "":
+			pageType = comicinfo.PageStory
+		}
+		info.Pages = append(info.Pages, comicinfo.Page{
+			Image:       index,
+			Type:        pageType,
+			Bookmark:    pageMetadata.Bookmark,
+			ImageSize:   page.size,
+			ImageWidth:  int(page.metadata.Width),
+			ImageHeight: int(page.metadata.Height),
+		})
+	}
+
+	return info
+}
+
+func (cm *CBZMounter) Flush() (err error) {
+	cm.Lock()
+	defer cm.Unlock()
+	defer cm.file.Close()
+	// The archive is closed even on failure, so the pages already written stay readable
+	defer func() {
+		if closeErr := cm.zipWriter.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("error while writing cbz: %w", closeErr))
+		}
+	}()
+
+	if coverPath := cm.bookMetadata.CoverImage; coverPath != "" {
+		if err = cm.writeCover(coverPath); err != nil {
+			return err
+		}
+	}
+
+	infoWriter, err := cm.zipWriter.Create(comicinfo.FileName)
+	if err != nil {
+		return err
+	}
+	if err = cm.comicInfo().Encode(infoWriter); err != nil {
+		return fmt.Errorf("error while writing %s: %w", comicinfo.FileName, err)
+	}
+	return err
+}
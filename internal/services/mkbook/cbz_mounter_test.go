@@ -0,0 +1,140 @@
+package mkbook
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/comicinfo"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestCBZMounter_Flush(t *testing.T) {
+	pages := []string{"chapter02/001__0.jpeg", "chapter01/001__0.jpeg", "chapter01/002__0.jpeg"}
+	// Entries keep the write order, with the names normalized
+	entryNames := []string{"chapter02/001_0.jpeg", "chapter01/001_0.jpeg", "chapter01/002_0.jpeg"}
+	tests := []struct {
+		name          string
+		metadata      inktypes.BookMetadata
+		expectedTitle string
+		expectedNames []string
+		expectedPages []comicinfo.Page
+	}{
+		{
+			name:          "first page is the cover",
+			expectedTitle: "book",
+			expectedNames: append(slices.Clone(entryNames), comicinfo.FileName),
+			expectedPages: []comicinfo.Page{
+				{Image: 0, Type: comicinfo.PageFrontCover},
+				{Image: 1, Type: comicinfo.PageStory},
+				{Image: 2, Type: comicinfo.PageStory},
+			},
+		},
+		{
+			name: "metadata cover and bookmark",
+			metadata: inktypes.BookMetadata{
+				Series: "Series", Number: "2", Writers: []string{"Writer", "Artist"},
+				Pages: map[string]inktypes.PageInfo{
+					"chapter01/002": {Type: inktypes.PageFrontCover},
+					"chapter02/001": {Bookmark: "Chapter 2"},
+				},
+			},
+			expectedTitle: "Series #2",
+			expectedNames: append(slices.Clone(entryNames), comicinfo.FileName),
+			expectedPages: []comicinfo.Page{
+				{Image: 0, Type: comicinfo.PageStory},
+				{Image: 1, Type: comicinfo.PageFrontCover},
+				{Image: 2, Type: comicinfo.PageStory, Bookmark: "Chapter 2"},
+			},
+		},
+		{
+			name: "explicit cover",
+			metadata: inktypes.BookMetadata{
+				CoverImage: writeImageFile(t, "cover.JPG", 30, 40),
+			},
+			expectedTitle: "book",
+			expectedNames: append(slices.Clone(entryNames), "000000_cover.jpg", comicinfo.FileName),
+			expectedPages: []comicinfo.Page{
+				{Image: 0, Type: comicinfo.PageFrontCover, ImageWidth: 30, ImageHeight: 40},
+				{Image: 1, Type: comicinfo.PageStory},
+				{Image: 2, Type: comicinfo.PageStory},
+				{Image: 3, Type: comicinfo.PageStory},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "book")
+			mounter, err := NewCBZMounter(outputPath, inktypes.ReadRightToLeft)
+			if err != nil {
+				t.Fatalf("failed to create mounter: %v", err)
+			}
+			mounter.SetMetadata(tt.metadata)
+			writePages(t, mounter, pages...)
+			if err = mounter.Flush(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			names, entries := readZipEntries(t, outputPath+".cbz")
+			if !slices.Equal(names, tt.expectedNames) {
+				t.Errorf("expected entries %v, got %v", tt.expectedNames, names)
+			}
+			for _, name := range names[:len(names)-1] {
+				if method := entries[name].Method; method != zip.Store {
+					t.Errorf("JPEG entry `%s` must be stored, got method %d", name, method)
+				}
+			}
+
+			info, err := comicinfo.Decode(
+				bytes.NewReader(readZipEntry(t, entries[comicinfo.FileName])),
+			)
+			if err != nil {
+				t.Fatalf("failed to decode %s: %v", comicinfo.FileName, err)
+			}
+			if info.Title != tt.expectedTitle {
+				t.Errorf("expected title %q, got %q", tt.expectedTitle, info.Title)
+			}
+			if info.Manga != comicinfo.NewMangaType(inktypes.ReadRightToLeft) {
+				t.Errorf("expected the right to left manga type, got %q", info.Manga)
+			}
+			if info.PageCount != len(tt.expectedPages) {
+				t.Errorf("expected %d pages, got %d", len(tt.expectedPages), info.PageCount)
+			}
+			for index, page := range info.Pages {
+				expected := tt.expectedPages[index]
+				// The book pages are all 60x80, only the cover size is checked from the table
+				if expected.ImageWidth == 0 {
+					expected.ImageWidth, expected.ImageHeight = 60, 80
+				}
+				page.ImageSize = 0
+				if page != expected {
+					t.Errorf("page %d: expected %+v, got %+v", index, expected, page)
+				}
+			}
+		})
+	}
+}
+
+func TestCBZMounter_FlushCoverError(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "book")
+	mounter, err := NewCBZMounter(outputPath, inktypes.ReadLeftToRight)
+	if err != nil {
+		t.Fatalf("failed to create mounter: %v", err)
+	}
+	mounter.SetMetadata(inktypes.BookMetadata{
+		CoverImage: filepath.Join(t.TempDir(), "missing.jpg"),
+	})
+	writePages(t, mounter, "001__0.jpeg", "002__0.jpeg")
+	if err = mounter.Flush(); err == nil {
+		t.Fatal("expected an error for the missing cover")
+	}
+
+	// The archive must still be closed, keeping the pages written before the failure
+	names, _ := readZipEntries(t, outputPath+".cbz")
+	if expected := []string{"001_0.jpeg", "002_0.jpeg"}; !slices.Equal(names, expected) {
+		t.Errorf("expected entries %v, got %v", expected, names)
+	}
+}
@@ -0,0 +1,110 @@
+package mkbook
+
+import (
+	"fmt"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+const (
+	pageSpreadLeft  = "page-spread-left"
+	pageSpreadRight = "page-spread-right"
+)
+
+// fixedLayout describes the EPUB3 fixed-layout rendition of the book pages.
+type fixedLayout struct {
+	// spread is the rendition:spread value, telling when two pages are shown side by side
+	spread        string
+	resolution    inktypes.ImageDimensions
+	readDirection inktypes.ReadDirection
+	// kindleComic adds the metadata used by Kindle to enable the comic features
+	kindleComic bool
+}
+
+func newFixedLayout(
+	resolution inktypes.ImageDimensions, readDirection inktypes.ReadDirection,
+) fixedLayout {
+	return fixedLayout{spread: "landscape", resolution: resolution, readDirection: readDirection}
+}
+
+func (layout fixedLayout) hasResolution() bool {
+	return layout.resolution.Width > 0 && layout.resolution.Height > 0
+}
+
+func (layout fixedLayout) orientation() string {
+	if !layout.hasResolution() {
+		return "auto"
+	}
+	if layout.resolution.Orientation() == inktypes.OrientationLandscape {
+		return "landscape"
+	}
+	return "portrait"
+}
+
+func (layout fixedLayout) metadata() []string {
+	metadata := []string{
+		`<meta property="rendition:layout">pre-paginated</meta>`,
+		`<meta property="rendition:spread">` + layout.spread + `</meta>`,
+		`<meta property="rendition:orientation">` + layout.orientation() + `</meta>`,
+	}
+	if layout.hasResolution() {
+		metadata = append(metadata, fmt.Sprintf(
+			`<meta name="original-resolution" content="%dx%d"/>`,
+			layout.resolution.Width, layout.resolution.Height,
+		))
+	}
+	if layout.kindleComic {
+		metadata = append(metadata, layout.kindleMetadata()...)
+	}
+	return metadata
+}
+
+// kindleMetadata returns the metadata required by Kindle to keep the panel view
+// and the page turn direction when converting the book.
+func (layout fixedLayout) kindleMetadata() []string {
+	orientationLock := layout.orientation()
+	if orientationLock == "auto" {
+		orientationLock = "none"
+	}
+	writingMode := "horizontal-lr"
+	if layout.readDirection == inktypes.ReadRightToLeft {
+		writingMode = "horizontal-rl"
+	}
+
+	return []string{
+		`<meta name="book-type" content="comic"/>`,
+		`<meta name="fixed-layout" content="true"/>`,
+		`<meta name="RegionMagnification" content="true"/>`,
+		`<meta name="orientation-lock" content="` + orientationLock + `"/>`,
+		`<meta name="primary-writing-mode" content="` + writingMode + `"/>`,
+	}
+}
+
+// headContent returns the viewport required by the reading systems to not reflow the pages.
+func (layout fixedLayout) headContent() []string {
+	if !layout.hasResolution() {
+		return nil
+	}
+	return []string{fmt.Sprintf(
+		`<meta name="viewport" content="width=%d, height=%d"/>`,
+		layout.resolution.Width, layout.resolution.Height,
+	)}
+}
+
+// pageSpreads returns the spread properties alternated along the spine. The first
+// page stands alone on the side where the book opens, so the pairs that follow
+// are joined in the read direction.
+func (layout fixedLayout) pageSpreads() []string {
+	if layout.readDirection == inktypes.ReadRightToLeft {
+		return []string{pageSpreadLeft, pageSpreadRight}
+	}
+	return []string{pageSpreadRight, pageSpreadLeft}
+}
+
+func (layout fixedLayout) patch() epubPatch {
+	return epubPatch{
+		metadata:     layout.metadata(),
+		headContent:  layout.headContent(),
+		spineSpreads: layout.pageSpreads(),
+	}
+}
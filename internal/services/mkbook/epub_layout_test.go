@@ -0,0 +1,167 @@
+package mkbook
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestEpubMounter_fixedLayout(t *testing.T) {
+	tests := []struct {
+		name                string
+		readDirection       inktypes.ReadDirection
+		resolution          inktypes.ImageDimensions
+		expectedOrientation string
+		expectedResolution  string
+		expectedViewport    string
+		expectedSpreads     []string
+	}{
+		{
+			name:                "portrait left to right",
+			readDirection:       inktypes.ReadLeftToRight,
+			resolution:          inktypes.ImageDimensions{Width: 600, Height: 800},
+			expectedOrientation: "portrait",
+			expectedResolution:  "600x800",
+			expectedViewport:    `<meta name="viewport" content="width=600, height=800"/>`,
+			expectedSpreads:     []string{pageSpreadRight, pageSpreadLeft, pageSpreadRight},
+		},
+		{
+			name:                "landscape right to left",
+			readDirection:       inktypes.ReadRightToLeft,
+			resolution:          inktypes.ImageDimensions{Width: 800, Height: 600},
+			expectedOrientation: "landscape",
+			expectedResolution:  "800x600",
+			expectedViewport:    `<meta name="viewport" content="width=800, height=600"/>`,
+			expectedSpreads:     []string{pageSpreadLeft, pageSpreadRight, pageSpreadLeft},
+		},
+		{
+			name:                "no resolution",
+			readDirection:       inktypes.ReadLeftToRight,
+			expectedOrientation: "auto",
+			expectedSpreads:     []string{pageSpreadRight, pageSpreadLeft, pageSpreadRight},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "book")
+			mounter, err := NewEpubMounter(outputPath, tt.readDirection, tt.resolution)
+			if err != nil {
+				t.Fatalf("failed to create mounter: %v", err)
+			}
+			content := writeEpub(t, mounter, "001__0.jpeg", "002__0.jpeg")
+
+			expectedMeta := map[string]string{
+				"rendition:layout":      "pre-paginated",
+				"rendition:spread":      "landscape",
+				"rendition:orientation": tt.expectedOrientation,
+				"original-resolution":   tt.expectedResolution,
+			}
+			for key, expected := range expectedMeta {
+				value, found := content.opf.meta(key)
+				if found != (expected != "") || value != expected {
+					t.Errorf(
+						"meta `%s`: expected %q, got %q (found %t)",
+						key,
+						expected,
+						value,
+						found,
+					)
+				}
+			}
+
+			// Every document, including the cover and the navigation, must keep its size
+			for name, document := range content.documents {
+				hasViewport := strings.Contains(document, `<meta name="viewport"`)
+				if hasViewport != (tt.expectedViewport != "") ||
+					!strings.Contains(document, tt.expectedViewport) {
+					t.Errorf("document `%s`: expected viewport %q", name, tt.expectedViewport)
+				}
+			}
+
+			spreads := make([]string, 0, len(content.opf.ItemRefs))
+			for _, itemRef := range content.opf.ItemRefs {
+				spreads = append(spreads, itemRef.Properties)
+			}
+			if !slices.Equal(spreads, tt.expectedSpreads) {
+				t.Errorf("expected spine spreads %v, got %v", tt.expectedSpreads, spreads)
+			}
+		})
+	}
+}
+
+func TestNewKindleEpubMounter(t *testing.T) {
+	tests := []struct {
+		name          string
+		newMounter    epubConstructor
+		readDirection inktypes.ReadDirection
+		resolution    inktypes.ImageDimensions
+		expectedMeta  map[string]string
+	}{
+		{
+			name:          "manga with device resolution",
+			newMounter:    NewKindleEpubMounter,
+			readDirection: inktypes.ReadRightToLeft,
+			resolution:    inktypes.ImageDimensions{Width: 1072, Height: 1448},
+			expectedMeta: map[string]string{
+				"book-type":            "comic",
+				"fixed-layout":         "true",
+				"RegionMagnification":  "true",
+				"orientation-lock":     "portrait",
+				"primary-writing-mode": "horizontal-rl",
+			},
+		},
+		{
+			name:          "comic without resolution",
+			newMounter:    NewKindleEpubMounter,
+			readDirection: inktypes.ReadLeftToRight,
+			expectedMeta: map[string]string{
+				"book-type":            "comic",
+				"fixed-layout":         "true",
+				"RegionMagnification":  "true",
+				"orientation-lock":     "none",
+				"primary-writing-mode": "horizontal-lr",
+			},
+		},
+		{
+			name:          "plain epub",
+			newMounter:    NewEpubMounter,
+			readDirection: inktypes.ReadRightToLeft,
+			resolution:    inktypes.ImageDimensions{Width: 1072, Height: 1448},
+			expectedMeta: map[string]string{
+				"book-type":            "",
+				"fixed-layout":         "",
+				"RegionMagnification":  "",
+				"orientation-lock":     "",
+				"primary-writing-mode": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "book")
+			mounter, err := tt.newMounter(outputPath, tt.readDirection, tt.resolution)
+			if err != nil {
+				t.Fatalf("failed to create mounter: %v", err)
+			}
+			content := writeEpub(t, mounter, "001__0.jpeg", "002__0.jpeg")
+
+			for key, expected := range tt.expectedMeta {
+				value, found := content.opf.meta(key)
+				if found != (expected != "") || value != expected {
+					t.Errorf(
+						"meta `%s`: expected %q, got %q (found %t)",
+						key,
+						expected,
+						value,
+						found,
+					)
+				}
+			}
+		})
+	}
+}
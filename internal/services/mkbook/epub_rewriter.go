@@ -0,0 +1,139 @@
+package mkbook
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"path"
+	"strings"
+)
+
+// epubPatch holds the extra markup injected into the EPUB generated by go-epub,
+// as the library has no API to register custom metadata.
+type epubPatch struct {
+	// metadata elements appended to the package document metadata
+	metadata []string
+	// headContent elements appended to the head of every xhtml document
+	headContent []string
+	// spineSpreads properties set, in turns, on the spine items
+	spineSpreads []string
+}
+
+func (patch epubPatch) apply(name string, content []byte) []byte {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".opf":
+		content = insertBefore(content, "</metadata>", patch.metadata)
+		return setSpineProperties(content, patch.spineSpreads)
+	case ".xhtml":
+		return insertBefore(content, "</head>", patch.headContent)
+	}
+	return content
+}
+
+func insertBefore(content []byte, closingTag string, elements []string) []byte {
+	position := bytes.LastIndex(content, []byte(closingTag))
+	if position < 0 || len(elements) == 0 {
+		return content
+	}
+
+	// Keep the elements aligned with the indentation of the closing tag
+	lineStart := bytes.LastIndexByte(content[:position], '\n') + 1
+	indentation := content[lineStart:position]
+	if len(bytes.TrimSpace(indentation)) > 0 {
+		lineStart, indentation = position, nil
+	}
+
+	var builder bytes.Buffer
+	builder.Grow(len(content))
+	builder.Write(content[:lineStart])
+	for _, element := range elements {
+		builder.Write(indentation)
+		builder.WriteString("  " + element + "\n")
+	}
+	builder.Write(content[lineStart:])
+	return builder.Bytes()
+}
+
+// setSpineProperties sets the properties on each itemref, cycling through the given values.
+func setSpineProperties(content []byte, properties []string) []byte {
+	if len(properties) == 0 {
+		return content
+	}
+
+	const itemRefTag = "<itemref "
+	var (
+		builder bytes.Buffer
+		index   int
+	)
+	builder.Grow(len(content))
+	for {
+		position := bytes.Index(content, []byte(itemRefTag))
+		if position < 0 {
+			break
+		}
+		position += len(itemRefTag)
+		builder.Write(content[:position])
+		builder.WriteString(`properties="` + properties[index%len(properties)] + `" `)
+		content = content[position:]
+		index++
+	}
+	builder.Write(content)
+	return builder.Bytes()
+}
+
+// rewriteEpub copies the EPUB from srcPath into the destination, applying the patch.
+// Untouched entries are copied raw, so images are not compressed again.
+func rewriteEpub(srcPath string, destination io.Writer, patch epubPatch) error {
+	reader, err := zip.OpenReader(srcPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	zipWriter := zip.NewWriter(destination)
+	for _, file := range reader.File {
+		if err = copyEpubEntry(zipWriter, file, patch); err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+func copyEpubEntry(zipWriter *zip.Writer, file *zip.File, patch epubPatch) error {
+	ext := strings.ToLower(path.Ext(file.Name))
+	if ext != ".opf" && ext != ".xhtml" {
+		rawReader, err := file.OpenRaw()
+		if err != nil {
+			return err
+		}
+		rawWriter, err := zipWriter.CreateRaw(&file.FileHeader)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(rawWriter, rawReader)
+		return err
+	}
+
+	entryReader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	content, err := io.ReadAll(entryReader)
+	_ = entryReader.Close()
+	if err != nil {
+		return err
+	}
+
+	content = patch.apply(file.Name, content)
+	entryWriter, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     file.Name,
+		Method:   file.Method,
+		Modified: file.Modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = entryWriter.Write(content)
+	return err
+}
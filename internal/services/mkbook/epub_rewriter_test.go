@@ -0,0 +1,54 @@
+package mkbook
+
+import "testing"
+
+func TestEpubPatch_apply(t *testing.T) {
+	patch := epubPatch{
+		metadata:     []string{`<meta property="rendition:layout">pre-paginated</meta>`},
+		headContent:  []string{`<meta name="viewport" content="width=600, height=800"/>`},
+		spineSpreads: []string{pageSpreadRight, pageSpreadLeft},
+	}
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		expected string
+	}{
+		{
+			name:     "package metadata and spine",
+			filename: "EPUB/package.opf",
+			content: "<package>\n  <metadata>\n    <dc:title>Book</dc:title>\n  </metadata>\n" +
+				"  <spine>\n    <itemref idref=\"a\"/>\n    <itemref idref=\"b\"/>\n" +
+				"    <itemref idref=\"c\"/>\n  </spine>\n</package>",
+			expected: "<package>\n  <metadata>\n    <dc:title>Book</dc:title>\n" +
+				"    <meta property=\"rendition:layout\">pre-paginated</meta>\n  </metadata>\n" +
+				"  <spine>\n    <itemref properties=\"page-spread-right\" idref=\"a\"/>\n" +
+				"    <itemref properties=\"page-spread-left\" idref=\"b\"/>\n" +
+				"    <itemref properties=\"page-spread-right\" idref=\"c\"/>\n" +
+				"  </spine>\n</package>",
+		},
+		{
+			name:     "document head on the same line",
+			filename: "EPUB/xhtml/page.XHTML",
+			content:  "<html><head><title>Page</title></head><body></body></html>",
+			expected: "<html><head><title>Page</title>" +
+				"  <meta name=\"viewport\" content=\"width=600, height=800\"/>\n" +
+				"</head><body></body></html>",
+		},
+		{
+			name:     "other entries are untouched",
+			filename: "EPUB/css/style.css",
+			content:  "</head></metadata><itemref ",
+			expected: "</head></metadata><itemref ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(patch.apply(tt.filename, []byte(tt.content)))
+			if got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,9 +1,23 @@
 package mkbook
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/Jictyvoo/ink_stream/internal/utils"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+const (
+	defaultAuthor      = "ink_stream"
+	defaultDescription = "Generated by ink_stream"
 )
 
 func writeBinaryFile(
@@ -22,3 +36,93 @@ func normalizeFileName(input string) string {
 	fileBase := utils.NormalizeName(input, '_', utils.DefaultInsideIgnore(), '.', '-', '/')
 	return fileBase
 }
+
+// bookTitle derives the book title from the output directory name.
+func bookTitle(outputDirectory string) string {
+	title := filepath.Base(outputDirectory)
+	if title == "." {
+		asSha := sha256.Sum256([]byte(outputDirectory))
+		title = string(asSha[:16])
+	}
+	return title
+}
+
+// chapterIDFromFilename derives the chapter from the original filename directory.
+func chapterIDFromFilename(filename string) string {
+	return utils.SanitizeName(
+		filepath.Dir(filename), ' ', nil,
+		utils.DefaultInsideIgnore(), '.', '-',
+	)
+}
+
+// isChapter reports whether the chapter ID comes from a folder, instead of the book root.
+func isChapter(chapterID string) bool {
+	return chapterID != "." && chapterID != ""
+}
+
+// sourcePageName splits the processed filename into the source page name and the index
+// of the image produced from it, removing the extension and the `__N` suffix.
+func sourcePageName(filename string) (name string, part int) {
+	name = strings.TrimSuffix(filename, filepath.Ext(filename))
+	separatorIndex := strings.LastIndex(name, "__")
+	if separatorIndex < 0 {
+		return name, 0
+	}
+
+	part, err := strconv.Atoi(name[separatorIndex+2:])
+	if err != nil {
+		return name, 0
+	}
+	return name[:separatorIndex], part
+}
+
+// pageInfo returns the metadata of the page that produced the given filename.
+// The cover and the bookmark only apply to the first image of the page.
+func pageInfo(metadata inktypes.BookMetadata, filename string) inktypes.PageInfo {
+	name, part := sourcePageName(filename)
+	info := metadata.Pages[name]
+	if part > 0 {
+		info.Bookmark = ""
+		if info.Type == inktypes.PageFrontCover {
+			info.Type = inktypes.PageStory
+		}
+	}
+	return info
+}
+
+// coverFirst moves the first page matching isCover to the book start.
+func coverFirst[T any](pages []T, isCover func(T) bool) {
+	index := slices.IndexFunc(pages, isCover)
+	if index <= 0 {
+		return
+	}
+
+	cover := pages[index]
+	copy(pages[1:index+1], pages[:index])
+	pages[0] = cover
+}
+
+// coverImageMetadata reads the dimensions and format of the explicit cover image.
+func coverImageMetadata(coverPath string) (inktypes.ImageMetadata, error) {
+	coverFile, err := os.Open(coverPath)
+	if err != nil {
+		return inktypes.ImageMetadata{}, err
+	}
+	defer coverFile.Close()
+
+	config, format, err := image.DecodeConfig(coverFile)
+	if err != nil {
+		return inktypes.ImageMetadata{}, fmt.Errorf("invalid cover image `%s`: %w", coverPath, err)
+	}
+	metadata := inktypes.NewImageMetadata(config.Width, config.Height)
+	metadata.Format = inktypes.ImageFormat(format)
+	return metadata, nil
+}
+
+// bookAuthor returns the writers from the metadata, falling back to the default author.
+func bookAuthor(metadata inktypes.BookMetadata) string {
+	if len(metadata.Writers) == 0 {
+		return defaultAuthor
+	}
+	return strings.Join(metadata.Writers, ", ")
+}
@@ -0,0 +1,86 @@
+package mkbook
+
+import (
+	"archive/zip"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/services/imgprocessor"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// jpegPage returns the writer callback of a gray JPEG page with the given size.
+func jpegPage(width, height int) imgprocessor.WriterCallback {
+	return func(writer io.Writer) (inktypes.ImageMetadata, error) {
+		metadata := inktypes.NewImageMetadata(width, height)
+		metadata.Format = inktypes.FormatJPEG
+		img := image.NewGray(image.Rect(0, 0, width, height))
+		return metadata, jpeg.Encode(writer, img, nil)
+	}
+}
+
+// writeImageFile writes a JPEG image with the given size on the temp directory.
+func writeImageFile(t *testing.T, name string, width, height int) string {
+	t.Helper()
+	imgPath := filepath.Join(t.TempDir(), name)
+	imgFile, err := os.Create(imgPath)
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	defer imgFile.Close()
+
+	if _, err = jpegPage(width, height)(imgFile); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+	return imgPath
+}
+
+// writePages sends the pages to the writer, in the given order.
+func writePages(t *testing.T, writer imgprocessor.FileWriter, filenames ...string) {
+	t.Helper()
+	for _, filename := range filenames {
+		if err := writer.Handler(filename, jpegPage(60, 80)); err != nil {
+			t.Fatalf("failed to write page `%s`: %v", filename, err)
+		}
+	}
+}
+
+// readZipEntries opens the archive and returns its entries by name.
+func readZipEntries(
+	t *testing.T,
+	archivePath string,
+) (names []string, entries map[string]*zip.File) {
+	t.Helper()
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	t.Cleanup(func() { _ = reader.Close() })
+
+	entries = make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+		entries[file.Name] = file
+	}
+	return names, entries
+}
+
+// readZipEntry returns the content of the archive entry.
+func readZipEntry(t *testing.T, file *zip.File) []byte {
+	t.Helper()
+	entryReader, err := file.Open()
+	if err != nil {
+		t.Fatalf("failed to open entry `%s`: %v", file.Name, err)
+	}
+	defer entryReader.Close()
+
+	content, err := io.ReadAll(entryReader)
+	if err != nil {
+		t.Fatalf("failed to read entry `%s`: %v", file.Name, err)
+	}
+	return content
+}
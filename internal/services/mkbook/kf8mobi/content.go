@@ -0,0 +1,85 @@
+package kf8mobi
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+type chunkEntry struct {
+	selector string
+	entry    indexEntry
+}
+
+// kf8Text builds the KF8 text flow. Every page is stored as its skeleton
+// followed by a single chunk holding the page content, which the reader
+// inserts back at the chunk insert position.
+func (b *Book) kf8Text() (text []byte, skeletons []indexEntry, chunks []chunkEntry) {
+	title := html.EscapeString(b.metadata.Title)
+	skeletons = make([]indexEntry, 0, len(b.pages))
+	chunks = make([]chunkEntry, 0, len(b.pages))
+
+	for index, page := range b.pages {
+		bodyAID := toBase32(2*index, 1)
+		skeletonHead := fmt.Sprintf(
+			`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<!DOCTYPE html>`+"\n"+
+				`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">`+
+				`<head><title>%s</title><meta name="viewport" content="width=%d, height=%d"/></head>`+
+				`<body aid="%s" style="margin:0;padding:0;">`,
+			title, page.Width, page.Height, bodyAID,
+		)
+		skeletonTail := `</body></html>`
+		chunk := fmt.Sprintf(
+			`<div aid="%s" style="text-align:center;">`+
+				`<img src="%s" width="%d" height="%d" alt=""/></div>`,
+			toBase32(2*index+1, 1), resourceURI(index+1, page.mimeType()),
+			page.Width, page.Height,
+		)
+
+		skeletonStart := uint32(len(text))
+		skeletonLength := uint32(len(skeletonHead) + len(skeletonTail))
+		text = append(text, skeletonHead...)
+		text = append(text, skeletonTail...)
+		text = append(text, chunk...)
+
+		skeletons = append(skeletons, indexEntry{
+			label: fmt.Sprintf("SKEL%010d", index),
+			values: [][]uint32{
+				{1, 1}, // Chunk count, repeated as kindlegen does
+				{skeletonStart, skeletonLength, skeletonStart, skeletonLength},
+			},
+		})
+		chunks = append(chunks, chunkEntry{
+			selector: fmt.Sprintf("P-//*[@aid='%s']", bodyAID),
+			entry: indexEntry{
+				label: fmt.Sprintf("%010d", skeletonStart+uint32(len(skeletonHead))),
+				values: [][]uint32{
+					nil, // CNCX offset, filled once every selector is known
+					{uint32(index)},
+					{uint32(index)},
+					{0, uint32(len(chunk))},
+				},
+			},
+		})
+	}
+
+	return text, skeletons, chunks
+}
+
+// mobi7Text builds the legacy MOBI7 markup, one image per page separated by page breaks.
+func (b *Book) mobi7Text() []byte {
+	var builder strings.Builder
+	builder.WriteString(`<html><head><guide></guide></head><body>`)
+	for index, page := range b.pages {
+		if index > 0 {
+			builder.WriteString(`<mbp:pagebreak/>`)
+		}
+		_, _ = fmt.Fprintf(
+			&builder, `<div align="center"><img recindex="%05d" width="%d" height="%d"/></div>`,
+			index+1, page.Width, page.Height,
+		)
+	}
+	builder.WriteString(`</body></html>`)
+
+	return []byte(builder.String())
+}
@@ -0,0 +1,70 @@
+package kf8mobi
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+const (
+	// nullIndex is the value used by the MOBI headers for absent records and indexes.
+	nullIndex = 0xffffffff
+	// textRecordSize is the maximum amount of text stored in a single text record.
+	textRecordSize = 4096
+	// utf8Encoding is the Windows code page identifier for UTF-8.
+	utf8Encoding = 65001
+)
+
+// encodeVarint encodes a value using the forward variable-width integer
+// scheme from the Mobipocket indexes: 7 bits per byte, big-endian, with
+// the high bit flagging the last byte.
+func encodeVarint(value uint32) []byte {
+	var buf [5]byte
+	pos := len(buf)
+	for {
+		pos--
+		buf[pos] = byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			break
+		}
+	}
+	buf[len(buf)-1] |= 0x80
+	return buf[pos:]
+}
+
+// alignBlock pads the given block with zeroes until its length is a multiple of four.
+func alignBlock(block []byte) []byte {
+	if extra := len(block) % 4; extra > 0 {
+		block = append(block, make([]byte, 4-extra)...)
+	}
+	return block
+}
+
+// toBase32 formats the value using the uppercase base-32 digits used by KF8 links.
+func toBase32(value int, minDigits int) string {
+	formatted := strings.ToUpper(strconv.FormatInt(int64(value), 32))
+	if len(formatted) < minDigits {
+		formatted = strings.Repeat("0", minDigits-len(formatted)) + formatted
+	}
+	return formatted
+}
+
+// resourceURI builds the `kindle:embed` link for the 1-based resource index.
+func resourceURI(index int, mimeType string) string {
+	return "kindle:embed:" + toBase32(index, 4) + "?mime=" + mimeType
+}
+
+func appendUint16(data []byte, values ...uint16) []byte {
+	for _, value := range values {
+		data = binary.BigEndian.AppendUint16(data, value)
+	}
+	return data
+}
+
+func appendUint32(data []byte, values ...uint32) []byte {
+	for _, value := range values {
+		data = binary.BigEndian.AppendUint32(data, value)
+	}
+	return data
+}
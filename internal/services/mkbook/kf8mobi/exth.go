@@ -0,0 +1,45 @@
+package kf8mobi
+
+// EXTH record identifiers used by the writer.
+const (
+	exthAuthor             uint32 = 100
+	exthPublisher          uint32 = 101
+	exthDescription        uint32 = 103
+	exthPublishingDate     uint32 = 106
+	exthFixedLayout        uint32 = 122
+	exthKF8Boundary        uint32 = 121
+	exthResourceCount      uint32 = 125
+	exthOriginalRes        uint32 = 126
+	exthKF8UnknownCount    uint32 = 131
+	exthCoverOffset        uint32 = 201
+	exthHasFakeCover       uint32 = 203
+	exthDocumentType       uint32 = 501
+	exthUpdatedTitle       uint32 = 503
+	exthLanguage           uint32 = 524
+	exthPageProgressionDir uint32 = 527
+)
+
+type exthRecord struct {
+	id   uint32
+	data []byte
+}
+
+func exthString(id uint32, value string) exthRecord {
+	return exthRecord{id: id, data: []byte(value)}
+}
+
+func exthUint32(id uint32, value uint32) exthRecord {
+	return exthRecord{id: id, data: appendUint32(nil, value)}
+}
+
+// buildEXTH serializes the records into an EXTH block padded to a four bytes boundary.
+func buildEXTH(records []exthRecord) []byte {
+	var body []byte
+	for _, rec := range records {
+		body = appendUint32(body, rec.id, uint32(8+len(rec.data)))
+		body = append(body, rec.data...)
+	}
+
+	exth := append([]byte("EXTH"), appendUint32(nil, uint32(12+len(body)), uint32(len(records)))...)
+	return alignBlock(append(exth, body...))
+}
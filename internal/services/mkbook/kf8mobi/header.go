@@ -0,0 +1,85 @@
+package kf8mobi
+
+const (
+	mobiHeaderLength = 264
+	// recordZeroPrefix is the size of the PalmDOC header plus the MOBI header.
+	recordZeroPrefix = 16 + mobiHeaderLength
+
+	fileVersionMobi7 = 6
+	fileVersionKF8   = 8
+)
+
+// headerFields keeps every dynamic value from the record zero of a MOBI section.
+// Record indexes are relative to the record zero of the section they belong to.
+type headerFields struct {
+	fileVersion    uint32
+	uid            uint32
+	textLength     uint32
+	lastTextRecord uint16
+	firstNonText   uint32
+	firstResource  uint32
+	exthFlags      uint32
+	fdstRecord     uint32
+	fdstCount      uint32
+	flisRecord     uint32
+	fcisRecord     uint32
+	extraDataFlags uint32
+	ncxIndex       uint32
+	chunkIndex     uint32
+	skeletonIndex  uint32
+	guideIndex     uint32
+	exth           []byte
+	fullTitle      []byte
+}
+
+// bytes serializes the PalmDOC header, the MOBI header, the EXTH block and the full title.
+func (fields headerFields) bytes() []byte {
+	data := make([]byte, 0, recordZeroPrefix+len(fields.exth)+len(fields.fullTitle)+4)
+
+	// PalmDOC header: no compression, no encryption
+	data = appendUint16(data, 1, 0)
+	data = appendUint32(data, fields.textLength)
+	data = appendUint16(data, fields.lastTextRecord, textRecordSize, 0, 0)
+
+	data = append(data, "MOBI"...)
+	data = appendUint32(
+		data, mobiHeaderLength,
+		2, // Book type
+		utf8Encoding, fields.uid, fields.fileVersion,
+		nullIndex, nullIndex, // Orthographic and inflection indexes
+	)
+	for range 8 { // Extra indexes
+		data = appendUint32(data, nullIndex)
+	}
+	data = appendUint32(
+		data, fields.firstNonText,
+		uint32(recordZeroPrefix+len(fields.exth)), uint32(len(fields.fullTitle)),
+		0, 0, 0, // Locale, input and output languages
+		fields.fileVersion, fields.firstResource,
+		0, 0, // Huffman records
+		0, 0,
+		fields.exthFlags,
+	)
+	data = append(data, make([]byte, 32)...)
+	data = appendUint32(data, nullIndex)
+	data = appendUint32(data, nullIndex, 0, 0, 0) // DRM
+	data = appendUint32(data, 0, 0)
+	data = appendUint32(
+		data, fields.fdstRecord, fields.fdstCount,
+		fields.fcisRecord, 1, fields.flisRecord, 1,
+		0, 0,
+		nullIndex, 0, // SRCS
+		nullIndex, nullIndex,
+		fields.extraDataFlags,
+		fields.ncxIndex, fields.chunkIndex, fields.skeletonIndex,
+		nullIndex, // DATP
+		fields.guideIndex,
+		nullIndex, 0, nullIndex, 0,
+	)
+
+	data = append(data, fields.exth...)
+	data = append(data, fields.fullTitle...)
+	// The title is followed by at least two zeroes and the record is kept aligned
+	data = append(data, 0, 0)
+	return alignBlock(data)
+}
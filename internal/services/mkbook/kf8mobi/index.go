@@ -0,0 +1,167 @@
+package kf8mobi
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+const (
+	indexHeaderLength = 192
+	// indexRecordLimit keeps the same safety margin used by kindlegen for index records.
+	indexRecordLimit = 0x10000 - indexHeaderLength - 1048
+	cncxRecordLimit  = 0x10000 - 1024
+)
+
+type (
+	tagDefinition struct {
+		number, valuesPerEntry, bitmask uint8
+	}
+	indexEntry struct {
+		label string
+		// values holds the tag values in the same order as the tag definitions
+		values [][]uint32
+	}
+	indexTable struct {
+		tags    []tagDefinition
+		entries []indexEntry
+		cncx    [][]byte
+	}
+)
+
+var (
+	skeletonTags = []tagDefinition{
+		{number: 1, valuesPerEntry: 1, bitmask: 0b0011}, // Chunk count
+		{number: 6, valuesPerEntry: 2, bitmask: 0b1100}, // Geometry
+	}
+	chunkTags = []tagDefinition{
+		{number: 2, valuesPerEntry: 1, bitmask: 0b0001}, // CNCX offset of the selector
+		{number: 3, valuesPerEntry: 1, bitmask: 0b0010}, // File number
+		{number: 4, valuesPerEntry: 1, bitmask: 0b0100}, // Sequence number
+		{number: 6, valuesPerEntry: 2, bitmask: 0b1000}, // Geometry
+	}
+)
+
+// newCNCX stores the strings in CNCX records, returning the offset of each one.
+func newCNCX(values []string) (records [][]byte, offsets map[string]uint32) {
+	offsets = make(map[string]uint32, len(values))
+	var (
+		buffer []byte
+		offset uint32
+	)
+	for _, value := range values {
+		if _, exists := offsets[value]; exists {
+			continue
+		}
+		raw := append(encodeVarint(uint32(len(value))), value...)
+		if len(buffer)+len(raw) > cncxRecordLimit {
+			records = append(records, alignBlock(buffer))
+			buffer = nil
+			offset = uint32(len(records)) << 16
+		}
+		buffer = append(buffer, raw...)
+		offsets[value] = offset
+		offset += uint32(len(raw))
+	}
+
+	if len(buffer) > 0 {
+		records = append(records, alignBlock(buffer))
+	}
+	return records, offsets
+}
+
+func (table indexTable) controlByte(entry indexEntry) byte {
+	var control byte
+	for index, tag := range table.tags {
+		entriesCount := uint8(len(entry.values[index])) / tag.valuesPerEntry
+		control |= tag.bitmask & (entriesCount << bits.TrailingZeros8(tag.bitmask))
+	}
+	return control
+}
+
+func (table indexTable) tagx() []byte {
+	tagx := []byte("TAGX")
+	tagx = appendUint32(tagx, uint32(12+4*(len(table.tags)+1)), 1)
+	for _, tag := range table.tags {
+		tagx = append(tagx, tag.number, tag.valuesPerEntry, tag.bitmask, 0)
+	}
+	return append(tagx, 0, 0, 0, 1) // End of the tag table
+}
+
+// records builds the index header record followed by the index and CNCX records.
+func (table indexTable) records() ([][]byte, error) {
+	type indexBlock struct {
+		entries, idxt []byte
+		count         uint16
+		lastLabel     string
+	}
+	blocks := []indexBlock{{}}
+	for _, entry := range table.entries {
+		if len(entry.label) > 0xff {
+			return nil, fmt.Errorf("index label `%s` is too long", entry.label)
+		}
+		raw := append([]byte{byte(len(entry.label))}, entry.label...)
+		raw = append(raw, table.controlByte(entry))
+		for _, values := range entry.values {
+			for _, value := range values {
+				raw = append(raw, encodeVarint(value)...)
+			}
+		}
+
+		current := &blocks[len(blocks)-1]
+		if len(current.entries)+len(current.idxt)+len(raw)+2 > indexRecordLimit {
+			blocks = append(blocks, indexBlock{})
+			current = &blocks[len(blocks)-1]
+		}
+		current.idxt = appendUint16(current.idxt, uint16(indexHeaderLength+len(current.entries)))
+		current.entries = append(current.entries, raw...)
+		current.count++
+		current.lastLabel = entry.label
+	}
+
+	indexRecords := make([][]byte, 0, len(blocks))
+	for _, block := range blocks {
+		entriesBlock := alignBlock(block.entries)
+		indxRecord := []byte("INDX")
+		indxRecord = appendUint32(
+			indxRecord, indexHeaderLength, 0,
+			1, // Index records have type 1
+			0, uint32(indexHeaderLength+len(entriesBlock)), uint32(block.count),
+			nullIndex, nullIndex,
+		)
+		indxRecord = append(indxRecord, make([]byte, 156)...)
+		indxRecord = append(indxRecord, entriesBlock...)
+		indxRecord = append(indxRecord, alignBlock(append([]byte("IDXT"), block.idxt...))...)
+		indexRecords = append(indexRecords, indxRecord)
+	}
+
+	// The geometry of each index record is written as entries pointed by the header IDXT
+	tagx := table.tagx()
+	var geometry, idxt []byte
+	idxt = []byte("IDXT")
+	for _, block := range blocks {
+		idxt = appendUint16(idxt, uint16(indexHeaderLength+len(tagx)+len(geometry)))
+		geometry = append(geometry, byte(len(block.lastLabel)))
+		geometry = append(geometry, block.lastLabel...)
+		geometry = appendUint16(geometry, block.count)
+	}
+	geometry = alignBlock(geometry)
+
+	header := []byte("INDX")
+	header = appendUint32(
+		header, indexHeaderLength, 0, 0,
+		2, // Index type
+		uint32(indexHeaderLength+len(tagx)+len(geometry)),
+		uint32(len(indexRecords)), utf8Encoding, nullIndex,
+		uint32(len(table.entries)),
+		0, 0, 0, // ORDT and LIGT
+		uint32(len(table.cncx)),
+	)
+	header = append(header, make([]byte, 124)...)
+	header = appendUint32(header, indexHeaderLength, 0, 0)
+	header = append(header, tagx...)
+	header = append(header, geometry...)
+	header = append(header, alignBlock(idxt)...)
+
+	result := append([][]byte{header}, indexRecords...)
+	return append(result, table.cncx...), nil
+}
@@ -0,0 +1,290 @@
+package kf8mobi
+
+import (
+	"errors"
+	"hash/fnv"
+	"io"
+	"strconv"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+var ErrNoPages = errors.New("the book has no pages to write")
+
+// Layout selects which sections are written to the output file.
+type Layout uint8
+
+const (
+	// LayoutKF8 writes only the KF8 section (AZW3).
+	LayoutKF8 Layout = iota
+	// LayoutJoint writes the MOBI7 fallback section followed by the KF8 section.
+	LayoutJoint
+)
+
+func (layout Layout) FileExtension() string {
+	if layout == LayoutJoint {
+		return ".mobi"
+	}
+	return ".azw3"
+}
+
+type (
+	Metadata struct {
+		Title         string
+		Author        string
+		Description   string
+		Publisher     string
+		PublishDate   string
+		Language      string
+		ReadDirection inktypes.ReadDirection
+	}
+	ImagePage struct {
+		FilePath      string
+		Format        inktypes.ImageFormat
+		Width, Height int
+	}
+	Book struct {
+		metadata Metadata
+		layout   Layout
+		pages    []ImagePage
+	}
+)
+
+func NewBook(metadata Metadata, layout Layout) *Book {
+	return &Book{metadata: metadata, layout: layout}
+}
+
+// AddImagePage appends a full page image, its content is only read when the book is written.
+func (b *Book) AddImagePage(page ImagePage) {
+	b.pages = append(b.pages, page)
+}
+
+func (page ImagePage) mimeType() string {
+	if page.Format == "" {
+		return "image/" + string(inktypes.FormatJPEG)
+	}
+	return "image/" + string(page.Format)
+}
+
+func (b *Book) WriteTo(writer io.Writer) (int64, error) {
+	if len(b.pages) == 0 {
+		return 0, ErrNoPages
+	}
+
+	resources := make([]record, 0, len(b.pages))
+	for _, page := range b.pages {
+		rec, err := newFileRecord(page.FilePath)
+		if err != nil {
+			return 0, err
+		}
+		resources = append(resources, rec)
+	}
+
+	var (
+		records []record
+		err     error
+	)
+	switch b.layout {
+	case LayoutJoint:
+		records, err = b.jointRecords(resources)
+	default:
+		records, err = b.kf8Records(resources)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return writePalmDB(writer, b.metadata.Title, records)
+}
+
+func (b *Book) exthRecords(resourceCount int) []exthRecord {
+	var originalRes struct{ width, height int }
+	for _, page := range b.pages {
+		originalRes.width = max(originalRes.width, page.Width)
+		originalRes.height = max(originalRes.height, page.Height)
+	}
+
+	records := []exthRecord{
+		exthString(exthAuthor, b.metadata.Author),
+		exthString(exthUpdatedTitle, b.metadata.Title),
+		exthString(exthDocumentType, "EBOK"),
+		exthUint32(exthResourceCount, uint32(resourceCount)),
+		exthUint32(exthKF8UnknownCount, 0),
+		exthUint32(exthCoverOffset, 0), // The first page is always the cover
+		exthUint32(exthHasFakeCover, 0),
+		exthString(exthFixedLayout, "true"),
+		exthString(
+			exthOriginalRes,
+			strconv.Itoa(originalRes.width)+"x"+strconv.Itoa(originalRes.height),
+		),
+		exthString(exthPageProgressionDir, b.metadata.ReadDirection.PageProgression().String()),
+	}
+	for _, optional := range []struct {
+		id    uint32
+		value string
+	}{
+		{id: exthDescription, value: b.metadata.Description},
+		{id: exthPublisher, value: b.metadata.Publisher},
+		{id: exthPublishingDate, value: b.metadata.PublishDate},
+		{id: exthLanguage, value: b.metadata.Language},
+	} {
+		if optional.value != "" {
+			records = append(records, exthString(optional.id, optional.value))
+		}
+	}
+
+	return records
+}
+
+// uid derives the book unique ID from the title, so rewriting a book keeps its identity.
+func (b *Book) uid() uint32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(b.metadata.Title))
+	return hash.Sum32()
+}
+
+// kf8Records builds the KF8 section. Resources are nil when they are already
+// stored by the MOBI7 section of a joint file.
+func (b *Book) kf8Records(resources []record) ([]record, error) {
+	text, skeletons, chunks := b.kf8Text()
+
+	selectors := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		selectors = append(selectors, chunk.selector)
+	}
+	cncxRecords, cncxOffsets := newCNCX(selectors)
+
+	chunkEntries := make([]indexEntry, 0, len(chunks))
+	for _, chunk := range chunks {
+		chunk.entry.values[0] = []uint32{cncxOffsets[chunk.selector]}
+		chunkEntries = append(chunkEntries, chunk.entry)
+	}
+
+	chunkIndex, err := indexTable{
+		tags:    chunkTags,
+		entries: chunkEntries,
+		cncx:    cncxRecords,
+	}.records()
+	if err != nil {
+		return nil, err
+	}
+	skeletonIndex, err := indexTable{tags: skeletonTags, entries: skeletons}.records()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := headerFields{
+		fileVersion:    fileVersionKF8,
+		uid:            b.uid(),
+		textLength:     uint32(len(text)),
+		exthFlags:      0b1010000,
+		extraDataFlags: 0b1, // Multibyte trailing entries
+		ncxIndex:       nullIndex,
+		guideIndex:     nullIndex,
+		fdstCount:      1,
+		fullTitle:      []byte(b.metadata.Title),
+	}
+
+	records := []record{{}} // Placeholder for the record zero
+	for _, textRecord := range splitTextRecords(text) {
+		records = append(records, newDataRecord(textRecord))
+	}
+	fields.lastTextRecord = uint16(len(records) - 1)
+	fields.firstNonText = uint32(len(records))
+
+	fields.chunkIndex = uint32(len(records))
+	for _, data := range chunkIndex {
+		records = append(records, newDataRecord(data))
+	}
+	fields.skeletonIndex = uint32(len(records))
+	for _, data := range skeletonIndex {
+		records = append(records, newDataRecord(data))
+	}
+
+	fields.firstResource = uint32(len(records))
+	records = append(records, resources...)
+
+	fields.fdstRecord = uint32(len(records))
+	records = append(
+		records,
+		newDataRecord(appendUint32([]byte("FDST"), 12, 1, 0, uint32(len(text)))),
+	)
+
+	fields.flisRecord = uint32(len(records))
+	records = append(records, newDataRecord(flisRecord()))
+	fields.fcisRecord = uint32(len(records))
+	records = append(records, newDataRecord(fcisRecord(len(text))))
+	records = append(records, newDataRecord(eofRecord()))
+
+	fields.exth = buildEXTH(b.exthRecords(len(b.pages)))
+	records[0] = newDataRecord(fields.bytes())
+	return records, nil
+}
+
+// jointRecords builds the MOBI7 section holding every resource, followed by the KF8 section.
+func (b *Book) jointRecords(resources []record) ([]record, error) {
+	text := b.mobi7Text()
+	fields := headerFields{
+		fileVersion:    fileVersionMobi7,
+		uid:            b.uid(),
+		textLength:     uint32(len(text)),
+		exthFlags:      0b100001010000,
+		extraDataFlags: 0b1,
+		ncxIndex:       nullIndex,
+		chunkIndex:     nullIndex,
+		skeletonIndex:  nullIndex,
+		guideIndex:     nullIndex,
+		fdstCount:      1,
+		fullTitle:      []byte(b.metadata.Title),
+	}
+
+	records := []record{{}} // Placeholder for the record zero
+	for _, textRecord := range splitTextRecords(text) {
+		records = append(records, newDataRecord(textRecord))
+	}
+	fields.lastTextRecord = uint16(len(records) - 1)
+	fields.firstNonText = uint32(len(records))
+
+	fields.firstResource = uint32(len(records))
+	records = append(records, resources...)
+	// MOBI7 stores the first and last content records in place of the FDST
+	fields.fdstRecord = 1<<16 | uint32(len(records)-1)
+
+	fields.flisRecord = uint32(len(records))
+	records = append(records, newDataRecord(flisRecord()))
+	fields.fcisRecord = uint32(len(records))
+	records = append(records, newDataRecord(fcisRecord(len(text))))
+
+	records = append(records, newDataRecord([]byte("BOUNDARY")))
+	kf8Boundary := len(records)
+	kf8Section, err := b.kf8Records(nil)
+	if err != nil {
+		return nil, err
+	}
+	records = append(records, kf8Section...)
+
+	fields.exth = buildEXTH(
+		append(b.exthRecords(len(resources)), exthUint32(exthKF8Boundary, uint32(kf8Boundary))),
+	)
+	records[0] = newDataRecord(fields.bytes())
+	return records, nil
+}
+
+func flisRecord() []byte {
+	flis := []byte("FLIS")
+	flis = appendUint32(flis, 8)
+	flis = appendUint16(flis, 65, 0)
+	flis = appendUint32(flis, 0, nullIndex)
+	flis = appendUint16(flis, 1, 3)
+	return appendUint32(flis, 3, 1, nullIndex)
+}
+
+func fcisRecord(textLength int) []byte {
+	fcis := []byte("FCIS")
+	fcis = appendUint32(fcis, 20, 16, 1, 0, uint32(textLength), 0, 32, 8)
+	return appendUint16(fcis, 1, 1, 0, 0)
+}
+
+func eofRecord() []byte {
+	return []byte{0xe9, 0x8e, '\r', '\n'}
+}
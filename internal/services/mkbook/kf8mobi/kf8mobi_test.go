@@ -0,0 +1,291 @@
+package kf8mobi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestEncodeVarint(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    uint32
+		expected []byte
+	}{
+		{name: "zero", value: 0, expected: []byte{0x80}},
+		{name: "single byte", value: 0x7f, expected: []byte{0xff}},
+		{name: "two bytes", value: 0x80, expected: []byte{0x01, 0x80}},
+		{name: "three bytes", value: 0x4000, expected: []byte{0x01, 0x00, 0x80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeVarint(tt.value); !bytes.Equal(got, tt.expected) {
+				t.Errorf("encodeVarint(%d) = %x, want %x", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitTextRecords(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            []byte
+		expectedRecords int
+		expectedOverlap []int
+	}{
+		{
+			name:            "single record",
+			text:            []byte("<html></html>"),
+			expectedRecords: 1,
+			expectedOverlap: []int{0},
+		},
+		{
+			name:            "ascii exactly two records",
+			text:            bytes.Repeat([]byte("a"), 2*textRecordSize),
+			expectedRecords: 2,
+			expectedOverlap: []int{0, 0},
+		},
+		{
+			name: "multibyte character cut on the boundary",
+			text: []byte(
+				strings.Repeat("a", textRecordSize-1) + "ó" + strings.Repeat("b", 10),
+			),
+			expectedRecords: 2,
+			expectedOverlap: []int{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := splitTextRecords(tt.text)
+			if len(records) != tt.expectedRecords {
+				t.Fatalf("expected %d records, got %d", tt.expectedRecords, len(records))
+			}
+
+			var rebuilt []byte
+			for index, textRecord := range records {
+				overlap := int(textRecord[len(textRecord)-1])
+				if overlap != tt.expectedOverlap[index] {
+					t.Errorf(
+						"record %d: expected overlap %d, got %d",
+						index,
+						tt.expectedOverlap[index],
+						overlap,
+					)
+				}
+				rebuilt = append(rebuilt, textRecord[:len(textRecord)-1-overlap]...)
+			}
+			if !bytes.Equal(rebuilt, tt.text) {
+				t.Errorf("rebuilt text does not match the original one")
+			}
+		})
+	}
+}
+
+// palmRecords splits the PalmDB file into its records, following the record list.
+func palmRecords(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+	if string(data[60:68]) != "BOOKMOBI" {
+		t.Fatalf("unexpected PalmDB type %q", data[60:68])
+	}
+
+	totalRecords := int(binary.BigEndian.Uint16(data[76:78]))
+	records := make([][]byte, 0, totalRecords)
+	for index := range totalRecords {
+		start, end := binary.BigEndian.Uint32(data[78+8*index:]), uint32(len(data))
+		if index+1 < totalRecords {
+			end = binary.BigEndian.Uint32(data[78+8*(index+1):])
+		}
+		if start > end || end > uint32(len(data)) {
+			t.Fatalf("record %d has invalid bounds [%d, %d)", index, start, end)
+		}
+		records = append(records, data[start:end])
+	}
+	return records
+}
+
+// exthValues reads the EXTH block that follows the MOBI header on the record zero.
+func exthValues(t *testing.T, recordZero []byte) map[uint32]string {
+	t.Helper()
+	exth := recordZero[recordZeroPrefix:]
+	if string(exth[:4]) != "EXTH" {
+		t.Fatalf("record zero does not hold an EXTH block")
+	}
+
+	values := make(map[uint32]string)
+	position := 12
+	for range binary.BigEndian.Uint32(exth[8:12]) {
+		id := binary.BigEndian.Uint32(exth[position:])
+		length := int(binary.BigEndian.Uint32(exth[position+4:]))
+		values[id] = string(exth[position+8 : position+length])
+		position += length
+	}
+	return values
+}
+
+func TestBook_WriteTo(t *testing.T) {
+	tempDir := t.TempDir()
+	var pages []ImagePage
+	for _, size := range []image.Rectangle{image.Rect(0, 0, 8, 12), image.Rect(0, 0, 10, 6)} {
+		imgPath := filepath.Join(tempDir, fmt.Sprintf("page_%d.jpeg", len(pages)))
+		imgFile, err := os.Create(imgPath)
+		if err != nil {
+			t.Fatalf("Failed to create image: %v", err)
+		}
+		if err = jpeg.Encode(imgFile, image.NewGray(size), nil); err != nil {
+			t.Fatalf("Failed to encode image: %v", err)
+		}
+		_ = imgFile.Close()
+		pages = append(pages, ImagePage{
+			FilePath: imgPath, Format: inktypes.FormatJPEG, Width: size.Dx(), Height: size.Dy(),
+		})
+	}
+
+	tests := []struct {
+		name            string
+		layout          Layout
+		expectedVersion uint32
+		expectedRecords int
+	}{
+		{name: "kf8 only", layout: LayoutKF8, expectedVersion: fileVersionKF8, expectedRecords: 13},
+		{
+			name:            "joint mobi7 and kf8",
+			layout:          LayoutJoint,
+			expectedVersion: fileVersionMobi7,
+			expectedRecords: 18,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := NewBook(
+				Metadata{
+					Title: "Test Book", Author: "Author",
+					ReadDirection: inktypes.ReadRightToLeft,
+				},
+				tt.layout,
+			)
+			if _, err := book.WriteTo(&bytes.Buffer{}); err != ErrNoPages {
+				t.Fatalf("expected ErrNoPages, got %v", err)
+			}
+
+			for _, page := range pages {
+				book.AddImagePage(page)
+			}
+			var output bytes.Buffer
+			written, writeErr := book.WriteTo(&output)
+			if writeErr != nil {
+				t.Fatalf("unexpected error: %v", writeErr)
+			}
+			data := output.Bytes()
+			if written != int64(len(data)) {
+				t.Errorf("WriteTo reported %d bytes, buffer has %d", written, len(data))
+			}
+
+			records := palmRecords(t, data)
+			if len(records) != tt.expectedRecords {
+				t.Errorf("expected %d records, got %d", tt.expectedRecords, len(records))
+			}
+			recordZero := records[0]
+			if string(recordZero[16:20]) != "MOBI" {
+				t.Fatalf("record zero does not hold a MOBI header")
+			}
+			version := binary.BigEndian.Uint32(recordZero[36:40])
+			if version != tt.expectedVersion {
+				t.Errorf("expected file version %d, got %d", tt.expectedVersion, version)
+			}
+
+			// The image records are written as they are, from the first resource on
+			firstResource := int(binary.BigEndian.Uint32(recordZero[108:112]))
+			for index, page := range pages {
+				content, err := os.ReadFile(page.FilePath)
+				if err != nil {
+					t.Fatalf("Failed to read image: %v", err)
+				}
+				if !bytes.Equal(records[firstResource+index], content) {
+					t.Errorf("record %d does not hold the page %d", firstResource+index, index)
+				}
+			}
+			fcisRecord := binary.BigEndian.Uint32(recordZero[200:204])
+			flisRecord := binary.BigEndian.Uint32(recordZero[208:212])
+			if string(records[fcisRecord][:4]) != "FCIS" ||
+				string(records[flisRecord][:4]) != "FLIS" {
+				t.Errorf("the FCIS and FLIS records are not where the header points")
+			}
+			if !bytes.Equal(records[len(records)-1], eofRecord()) {
+				t.Errorf("the last record must be the EOF record")
+			}
+
+			expectedValues := map[uint32]string{
+				exthAuthor:             "Author",
+				exthUpdatedTitle:       "Test Book",
+				exthResourceCount:      string(appendUint32(nil, uint32(len(pages)))),
+				exthCoverOffset:        string(appendUint32(nil, 0)),
+				exthFixedLayout:        "true",
+				exthOriginalRes:        "10x12",
+				exthPageProgressionDir: "rtl",
+			}
+			values := exthValues(t, recordZero)
+			for id, expected := range expectedValues {
+				if values[id] != expected {
+					t.Errorf("EXTH record %d = %q, want %q", id, values[id], expected)
+				}
+			}
+
+			boundaryValue, isJoint := values[exthKF8Boundary]
+			if isJoint != (tt.layout == LayoutJoint) {
+				t.Fatalf("the KF8 boundary must only be set on joint files")
+			}
+			if !isJoint {
+				return
+			}
+			boundary := int(binary.BigEndian.Uint32([]byte(boundaryValue)))
+			if string(records[boundary-1]) != "BOUNDARY" {
+				t.Errorf("the KF8 section must follow the BOUNDARY record")
+			}
+			kf8Version := binary.BigEndian.Uint32(records[boundary][36:40])
+			if kf8Version != fileVersionKF8 {
+				t.Errorf("expected KF8 section version %d, got %d", fileVersionKF8, kf8Version)
+			}
+		})
+	}
+}
+
+func TestBook_exthRecords(t *testing.T) {
+	book := NewBook(Metadata{
+		Title:       "Test Book",
+		Author:      "Author",
+		Publisher:   "Publisher",
+		PublishDate: "2020-05",
+	}, LayoutKF8)
+
+	values := make(map[uint32]string)
+	for _, record := range book.exthRecords(1) {
+		values[record.id] = string(record.data)
+	}
+
+	expected := map[uint32]string{
+		exthAuthor:         "Author",
+		exthPublisher:      "Publisher",
+		exthPublishingDate: "2020-05",
+	}
+	for id, value := range expected {
+		if values[id] != value {
+			t.Errorf("EXTH record %d = %q, want %q", id, values[id], value)
+		}
+	}
+	for _, id := range []uint32{exthDescription, exthLanguage} {
+		if _, found := values[id]; found {
+			t.Errorf("EXTH record %d must be omitted when empty", id)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package kf8mobi
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode"
+)
+
+const (
+	palmDBHeaderLength = 78
+	palmDBNameLength   = 32
+)
+
+// record is a single PalmDB record. Records backed by a file on disk are
+// only read while the database is being written, so big images are never
+// kept in memory.
+type record struct {
+	data     []byte
+	filePath string
+	size     int64
+}
+
+func newDataRecord(data []byte) record {
+	return record{data: data, size: int64(len(data))}
+}
+
+func newFileRecord(filePath string) (record, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return record{}, err
+	}
+	if stat.Size() >= 1<<32 {
+		return record{}, fmt.Errorf("file `%s` is too big to fit in a record", filePath)
+	}
+	return record{filePath: filePath, size: stat.Size()}, nil
+}
+
+func (rec record) writeTo(writer io.Writer) (int64, error) {
+	if rec.filePath == "" {
+		written, err := writer.Write(rec.data)
+		return int64(written), err
+	}
+
+	file, err := os.Open(rec.filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return io.Copy(writer, file)
+}
+
+// palmDBName sanitizes the title into the 31 ASCII characters allowed in the database name.
+func palmDBName(title string) []byte {
+	name := make([]byte, 0, palmDBNameLength)
+	for _, char := range title {
+		if len(name) >= palmDBNameLength-1 {
+			break
+		}
+		isAllowed := unicode.IsLetter(char) || unicode.IsDigit(char) || char == '-'
+		switch {
+		case char < unicode.MaxASCII && isAllowed:
+			name = append(name, byte(char))
+		case len(name) > 0 && name[len(name)-1] != '_':
+			name = append(name, '_')
+		}
+	}
+
+	return append(name, make([]byte, palmDBNameLength-len(name))...)
+}
+
+// writePalmDB writes the PalmDB container header, the record list and every record.
+func writePalmDB(writer io.Writer, title string, records []record) (total int64, err error) {
+	now := uint32(time.Now().Unix())
+	totalRecords := uint32(len(records))
+
+	header := make([]byte, 0, palmDBHeaderLength+8*len(records)+2)
+	header = append(header, palmDBName(title)...)
+	header = appendUint16(header, 0, 0)                // Attributes and version
+	header = appendUint32(header, now, now, 0)         // Creation, modification and backup dates
+	header = appendUint32(header, 0, 0, 0)             // Modification number, app and sort info
+	header = append(header, "BOOKMOBI"...)             // Type and creator
+	header = appendUint32(header, 2*totalRecords-1, 0) // Unique ID seed and next record list
+	header = appendUint16(header, uint16(totalRecords))
+
+	offset := int64(palmDBHeaderLength + 8*len(records) + 2)
+	for index, rec := range records {
+		if offset >= 1<<32 {
+			return 0, fmt.Errorf("record %d starts beyond the PalmDB addressable limit", index)
+		}
+		header = appendUint32(header, uint32(offset), uint32(2*index)&0x00ffffff)
+		offset += rec.size
+	}
+	header = append(header, 0, 0) // Gap to data
+
+	written, err := writer.Write(header)
+	total += int64(written)
+	if err != nil {
+		return total, err
+	}
+
+	for index, rec := range records {
+		var recWritten int64
+		recWritten, err = rec.writeTo(writer)
+		total += recWritten
+		if err != nil {
+			return total, fmt.Errorf("failed to write record %d: %w", index, err)
+		}
+		if recWritten != rec.size {
+			return total, fmt.Errorf(
+				"record %d size changed while writing: expected %d, wrote %d",
+				index, rec.size, recWritten,
+			)
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,31 @@
+package kf8mobi
+
+import "unicode/utf8"
+
+// splitTextRecords splits the uncompressed text into records of textRecordSize bytes.
+// Each record ends with the multibyte trailing entry: the bytes needed to complete
+// a character cut at the record boundary, followed by the count of those bytes.
+func splitTextRecords(text []byte) [][]byte {
+	records := make([][]byte, 0, len(text)/textRecordSize+1)
+	for start := 0; start < len(text); start += textRecordSize {
+		end := min(start+textRecordSize, len(text))
+
+		var overlap []byte
+		if end < len(text) {
+			runeStart := end - 1
+			for runeStart > start && !utf8.RuneStart(text[runeStart]) {
+				runeStart--
+			}
+			if _, size := utf8.DecodeRune(text[runeStart:]); runeStart+size > end {
+				overlap = text[end : runeStart+size]
+			}
+		}
+
+		textRecord := make([]byte, 0, end-start+len(overlap)+1)
+		textRecord = append(textRecord, text[start:end]...)
+		textRecord = append(textRecord, overlap...)
+		records = append(records, append(textRecord, byte(len(overlap))))
+	}
+
+	return records
+}
@@ -2,8 +2,9 @@ package mkbook
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"fmt"
+	"html"
+	"image"
 	"os"
 	"path/filepath"
 	"slices"
@@ -16,43 +17,53 @@ import (
 	"github.com/Jictyvoo/ink_stream/internal/services/mkbook/tmplepub"
 	"github.com/Jictyvoo/ink_stream/internal/services/outdirwriter"
 	"github.com/Jictyvoo/ink_stream/internal/utils"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
+var _ imgprocessor.ImageWriter = (*EpubMounter)(nil)
+
 type imageSectionData struct {
 	pageData               tmplepub.ImageData
 	sectionTitle, fileName string
 	chapterID              string
+	// sourceName is the filename given to the Handler, used to find the page metadata
+	sourceName string
 }
 
 type EpubMounter struct {
 	epub           *epub.Epub
 	outDir, tmpDir string
+	fileExtension  string
+	isKepub        bool
+	layout         fixedLayout
 	styleLocation  string
 	coverInfo      struct{ location, name string }
 	imageSections  []imageSectionData
+	bookMetadata   inktypes.BookMetadata
 	outWriter      outdirwriter.WriterHandle
 	sync.Mutex
 }
 
+// NewEpubMounter creates an EpubMounter that writes a fixed-layout EPUB3,
+// whose pages are sized to the given device resolution.
 func NewEpubMounter(
 	outputDirectory string, readDirection inktypes.ReadDirection,
+	resolution inktypes.ImageDimensions,
 ) (*EpubMounter, error) {
-	title := filepath.Base(outputDirectory)
-	if title == "." {
-		asSha := sha256.Sum256([]byte(outputDirectory))
-		title = string(asSha[:16])
-	}
-	e, err := epub.NewEpub(title)
+	e, err := epub.NewEpub(bookTitle(outputDirectory))
 	if err != nil {
 		return nil, err
 	}
 
 	// Set the PPD to the read direction
-	e.SetPpd(readDirection.String())
-	e.SetAuthor("ink_stream")
-	e.SetDescription("Generated by ink_stream")
-	epubMounter := &EpubMounter{epub: e, outDir: outputDirectory}
+	e.SetPpd(readDirection.PageProgression().String())
+	e.SetAuthor(defaultAuthor)
+	e.SetDescription(defaultDescription)
+	epubMounter := &EpubMounter{
+		epub: e, outDir: outputDirectory, fileExtension: ".epub",
+		layout: newFixedLayout(resolution, readDirection),
+	}
 
 	// Prepare temporary output directory for images
 	if epubMounter.tmpDir, err = os.MkdirTemp("", "inkstream-epub-*"); err != nil {
@@ -67,6 +78,39 @@ func NewEpubMounter(
 	return epubMounter, err
 }
 
+// NewKepubMounter creates an EpubMounter that writes a Kobo KEPUB file, with the
+// Kobo span markup on the pages.
+func NewKepubMounter(
+	outputDirectory string, readDirection inktypes.ReadDirection,
+	resolution inktypes.ImageDimensions,
+) (*EpubMounter, error) {
+	epubMounter, err := NewEpubMounter(outputDirectory, readDirection, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	epubMounter.fileExtension = ".kepub.epub"
+	epubMounter.isKepub = true
+	// Nickel only opens the pages full screen when they are not joined in spreads
+	epubMounter.layout.spread = "none"
+	return epubMounter, nil
+}
+
+// NewKindleEpubMounter creates an EpubMounter with the metadata that
+// makes Kindle handle the book as a comic.
+func NewKindleEpubMounter(
+	outputDirectory string, readDirection inktypes.ReadDirection,
+	resolution inktypes.ImageDimensions,
+) (*EpubMounter, error) {
+	epubMounter, err := NewEpubMounter(outputDirectory, readDirection, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	epubMounter.layout.kindleComic = true
+	return epubMounter, nil
+}
+
 func (em *EpubMounter) registerMainCSS() (err error) {
 	// `data:text/plain;charset=utf-8;base64,aGV5YQ==`
 	var buffer bytes.Buffer
@@ -78,12 +122,25 @@ func (em *EpubMounter) registerMainCSS() (err error) {
 	return err
 }
 
+// SetMetadata keeps the book metadata, applied to the EPUB on Flush.
+func (em *EpubMounter) SetMetadata(metadata inktypes.BookMetadata) {
+	em.Lock()
+	defer em.Unlock()
+	em.bookMetadata = metadata
+}
+
 func (em *EpubMounter) Handler(filename string, callback imgprocessor.WriterCallback) error {
+	return em.ImageHandler(filename, nil, callback)
+}
+
+// ImageHandler writes the page as Handler does. On the Kindle comics, the panels given to
+// Panel View are detected on the page image, when it's given.
+func (em *EpubMounter) ImageHandler(
+	filename string, img image.Image, callback imgprocessor.WriterCallback,
+) error {
 	// Capture chapter based on original filename directory before normalization
-	chapterID := utils.SanitizeName(
-		filepath.Dir(filename), ' ', nil,
-		utils.DefaultInsideIgnore(), '.', '-',
-	)
+	chapterID := chapterIDFromFilename(filename)
+	sourceName := filename
 
 	// Write image to the temp output directory using the outdirwriter to avoid memory usage
 	imgMetadata, absPath, err := em.outWriter.ExecuteFileWrite(filename, callback)
@@ -108,22 +165,37 @@ func (em *EpubMounter) Handler(filename string, callback imgprocessor.WriterCall
 		ImageWidth:  int(imgMetadata.Width),
 		ImageHeight: int(imgMetadata.Height),
 	}
-	return em.AddImagePage(pageData, filename, filename, chapterID)
+	if em.layout.kindleComic && img != nil {
+		if panels := imgutils.DetectPanels(img, em.layout.readDirection); len(panels) > 0 {
+			pageData.PanelImages = newPanelImages(panels, img.Bounds())
+		}
+	}
+	return em.addImagePage(pageData, imageSectionData{
+		sectionTitle: filename,
+		fileName:     filename,
+		chapterID:    chapterID,
+		sourceName:   sourceName,
+	})
 }
 
 func (em *EpubMounter) AddImagePage(
 	pageData tmplepub.ImageData,
 	sectionTitle, fileName, chapterID string,
 ) error {
+	return em.addImagePage(pageData, imageSectionData{
+		sectionTitle: sectionTitle,
+		fileName:     fileName,
+		chapterID:    chapterID,
+		sourceName:   fileName,
+	})
+}
+
+func (em *EpubMounter) addImagePage(pageData tmplepub.ImageData, section imageSectionData) error {
 	// Provide sensible defaults so the page renders even if caller omitted details
 	if len(pageData.PanelImages) == 0 {
-		pageData.PanelImages = []tmplepub.PanelImage{
-			{Class: "panel-top-left", Ordinal: 2},
-			{Class: "panel-top-right", Ordinal: 1},
-			{Class: "panel-bottom-left", Ordinal: 4},
-			{Class: "panel-bottom-right", Ordinal: 3},
-		}
+		pageData.PanelImages = newPanelImages(quadrantPanels(em.layout.readDirection))
 	}
+	pageData.KoboSpans = em.isKepub
 	// Ensure BaseID is set from ImageSrc
 	if pageData.BaseID == "" {
 		pageData.BaseID = utils.BuildBaseID(pageData.ImageSrc)
@@ -139,30 +211,106 @@ func (em *EpubMounter) AddImagePage(
 	em.Lock()
 	defer em.Unlock()
 
-	if em.coverInfo.name == "" || fileName < em.coverInfo.name {
-		em.coverInfo.name = fileName
+	if em.coverInfo.name == "" || section.fileName < em.coverInfo.name {
+		em.coverInfo.name = section.fileName
 		em.coverInfo.location = pageData.ImageSrc
 	}
-	em.imageSections = append(em.imageSections, imageSectionData{
-		pageData:     pageData,
-		sectionTitle: sectionTitle,
-		fileName:     fileName,
-		chapterID:    chapterID,
+	section.pageData = pageData
+	em.imageSections = append(em.imageSections, section)
+	return nil
+}
+
+// applyMetadata sets the book information and orders the pages following the metadata,
+// also dropping any deleted page that reached the writer.
+func (em *EpubMounter) applyMetadata() error {
+	if title := em.bookMetadata.FullTitle(); title != "" {
+		em.epub.SetTitle(title)
+	}
+	em.epub.SetAuthor(bookAuthor(em.bookMetadata))
+	if em.bookMetadata.Language != "" {
+		em.epub.SetLang(em.bookMetadata.Language)
+	}
+	if em.bookMetadata.Summary != "" {
+		em.epub.SetDescription(em.bookMetadata.Summary)
+	}
+
+	em.imageSections = slices.DeleteFunc(em.imageSections, func(section imageSectionData) bool {
+		return pageInfo(em.bookMetadata, section.sourceName).Type == inktypes.PageDeleted
 	})
+	isCover := func(section imageSectionData) bool {
+		return pageInfo(em.bookMetadata, section.sourceName).Type == inktypes.PageFrontCover
+	}
+	if slices.ContainsFunc(em.imageSections, isCover) {
+		coverFirst(em.imageSections, isCover)
+		em.coverInfo.location = em.imageSections[0].pageData.ImageSrc
+	}
+
+	// The explicit cover is only shown on the cover page, before the book pages
+	if coverPath := em.bookMetadata.CoverImage; coverPath != "" {
+		location, err := em.epub.AddImage(
+			coverPath,
+			"cover"+strings.ToLower(filepath.Ext(coverPath)),
+		)
+		if err != nil {
+			return fmt.Errorf("error while writing cover `%s` to epub: %w", coverPath, err)
+		}
+		em.coverInfo.location = location
+	}
 	return nil
 }
 
+// packageMetadata returns the book metadata elements that go-epub has no API to set.
+// Series are written both as an EPUB3 collection and as the Calibre metadata.
+func (em *EpubMounter) packageMetadata() []string {
+	var (
+		metadata = em.bookMetadata
+		elements []string
+	)
+	if metadata.Publisher != "" {
+		elements = append(
+			elements,
+			"<dc:publisher>"+html.EscapeString(metadata.Publisher)+"</dc:publisher>",
+		)
+	}
+	if metadata.PublishDate != "" {
+		elements = append(
+			elements,
+			"<dc:date>"+html.EscapeString(metadata.PublishDate)+"</dc:date>",
+		)
+	}
+	if metadata.Series == "" {
+		return elements
+	}
+
+	series := html.EscapeString(metadata.Series)
+	elements = append(elements,
+		`<meta property="belongs-to-collection" id="series">`+series+`</meta>`,
+		`<meta refines="#series" property="collection-type">series</meta>`,
+		`<meta name="calibre:series" content="`+series+`"/>`,
+	)
+	if metadata.Number != "" {
+		number := html.EscapeString(metadata.Number)
+		elements = append(elements,
+			`<meta refines="#series" property="group-position">`+number+`</meta>`,
+			`<meta name="calibre:series_index" content="`+number+`"/>`,
+		)
+	}
+	return elements
+}
+
 func (em *EpubMounter) Flush() error {
 	// finalize any folder analysis on the temp directory
 	//_ = em.outWriter.Flush()
 
-	if err := em.epub.SetCover(em.coverInfo.location, ""); err != nil {
-		return err
-	}
-
 	slices.SortFunc(em.imageSections, func(a, b imageSectionData) int {
 		return strings.Compare(a.fileName, b.fileName)
 	})
+	if err := em.applyMetadata(); err != nil {
+		return err
+	}
+	if err := em.epub.SetCover(em.coverInfo.location, ""); err != nil {
+		return err
+	}
 
 	// Strategy: the first page seen for a chapter becomes the top-level section;
 	// remaining pages in the same chapter become subsections under it.
@@ -174,7 +322,9 @@ func (em *EpubMounter) Flush() error {
 			return err
 		}
 
-		if parent, ok := parentByChapter[imgSection.chapterID]; ok {
+		// A bookmark from the metadata starts a new section, even inside the same chapter
+		bookmark := pageInfo(em.bookMetadata, imgSection.sourceName).Bookmark
+		if parent, ok := parentByChapter[imgSection.chapterID]; ok && bookmark == "" {
 			// Subsequent pages: add as subsection under the first page's section
 			if _, err := em.epub.AddSubSection(
 				parent, buf.String(),
@@ -188,7 +338,10 @@ func (em *EpubMounter) Flush() error {
 		}
 
 		sectionTitle := "root"
-		if imgSection.chapterID != "." && imgSection.chapterID != "" {
+		switch {
+		case bookmark != "":
+			sectionTitle = bookmark
+		case isChapter(imgSection.chapterID):
 			sectionTitle = imgSection.chapterID
 		}
 		// First page of this chapter: add as the parent section
@@ -204,7 +357,7 @@ func (em *EpubMounter) Flush() error {
 		parentByChapter[imgSection.chapterID] = parentFN
 	}
 
-	file, err := os.Create(em.outDir + ".epub")
+	file, err := os.Create(em.outDir + em.fileExtension)
 	if err != nil {
 		return err
 	}
@@ -212,8 +365,15 @@ func (em *EpubMounter) Flush() error {
 	// Cleanup temp directory regardless of write outcome
 	defer os.RemoveAll(em.tmpDir)
 
-	if _, err = em.epub.WriteTo(file); err != nil {
+	// The generated epub is written on the temp directory, to be patched into the output
+	generatedPath := filepath.Join(em.tmpDir, "generated.epub")
+	if err = em.epub.Write(generatedPath); err != nil {
 		return fmt.Errorf("error while writing epub: %w", err)
 	}
+	patch := em.layout.patch()
+	patch.metadata = append(patch.metadata, em.packageMetadata()...)
+	if err = rewriteEpub(generatedPath, file, patch); err != nil {
+		return fmt.Errorf("error while patching epub metadata: %w", err)
+	}
 	return err
 }
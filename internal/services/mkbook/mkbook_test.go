@@ -0,0 +1,138 @@
+package mkbook
+
+import (
+	"encoding/xml"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+type (
+	opfMeta struct {
+		Name     string `xml:"name,attr"`
+		Content  string `xml:"content,attr"`
+		Property string `xml:"property,attr"`
+		Value    string `xml:",chardata"`
+	}
+	opfItemRef struct {
+		IDRef      string `xml:"idref,attr"`
+		Properties string `xml:"properties,attr"`
+	}
+	// opfPackage holds the parts of the package document checked by the tests.
+	opfPackage struct {
+		Meta     []opfMeta    `xml:"metadata>meta"`
+		ItemRefs []opfItemRef `xml:"spine>itemref"`
+	}
+	epubConstructor func(
+		outputDirectory string, readDirection inktypes.ReadDirection,
+		resolution inktypes.ImageDimensions,
+	) (*EpubMounter, error)
+	// epubContent is the written EPUB, with the xhtml documents keyed by their base name.
+	epubContent struct {
+		opf       opfPackage
+		documents map[string]string
+	}
+)
+
+// meta returns the value of the meta element with the given property or name.
+func (pkg opfPackage) meta(key string) (string, bool) {
+	for _, meta := range pkg.Meta {
+		switch key {
+		case meta.Property:
+			return meta.Value, true
+		case meta.Name:
+			return meta.Content, true
+		}
+	}
+	return "", false
+}
+
+// writeEpub writes the pages with the mounter and reads back the generated file.
+func writeEpub(t *testing.T, mounter *EpubMounter, pages ...string) epubContent {
+	t.Helper()
+	writePages(t, mounter, pages...)
+	if err := mounter.Flush(); err != nil {
+		t.Fatalf("failed to flush epub: %v", err)
+	}
+	if _, err := os.Stat(mounter.tmpDir); !os.IsNotExist(err) {
+		t.Errorf("temporary directory must be removed after flush")
+	}
+
+	names, entries := readZipEntries(t, mounter.outDir+mounter.fileExtension)
+	content := epubContent{documents: make(map[string]string)}
+	for _, name := range names {
+		switch path.Ext(name) {
+		case ".opf":
+			if err := xml.Unmarshal(readZipEntry(t, entries[name]), &content.opf); err != nil {
+				t.Fatalf("failed to decode package document: %v", err)
+			}
+		case ".xhtml":
+			content.documents[path.Base(name)] = string(readZipEntry(t, entries[name]))
+		}
+	}
+	return content
+}
+
+func TestNewKepubMounter(t *testing.T) {
+	resolution := inktypes.ImageDimensions{Width: 600, Height: 800}
+	pageDocuments := []string{"chapter01__001_0.jpeg.xhtml", "chapter01__002_0.jpeg.xhtml"}
+	tests := []struct {
+		name              string
+		newMounter        epubConstructor
+		expectedExtension string
+		expectedSpread    string
+		expectedSpans     bool
+	}{
+		{
+			name:              "epub",
+			newMounter:        NewEpubMounter,
+			expectedExtension: ".epub",
+			expectedSpread:    "landscape",
+		},
+		{
+			name:              "kepub",
+			newMounter:        NewKepubMounter,
+			expectedExtension: ".kepub.epub",
+			expectedSpread:    "none",
+			expectedSpans:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "book")
+			mounter, err := tt.newMounter(outputPath, inktypes.ReadLeftToRight, resolution)
+			if err != nil {
+				t.Fatalf("failed to create mounter: %v", err)
+			}
+			content := writeEpub(t, mounter, "chapter01/001__0.jpeg", "chapter01/002__0.jpeg")
+			if _, err = os.Stat(outputPath + tt.expectedExtension); err != nil {
+				t.Fatalf("expected the book at `%s`: %v", outputPath+tt.expectedExtension, err)
+			}
+
+			if spread, _ := content.opf.meta("rendition:spread"); spread != tt.expectedSpread {
+				t.Errorf("expected spread %q, got %q", tt.expectedSpread, spread)
+			}
+			for _, name := range pageDocuments {
+				document, found := content.documents[name]
+				if !found {
+					t.Fatalf("page document `%s` not found", name)
+				}
+				hasSpans := strings.Contains(document, `<span class="koboSpan" id="kobo.1.1">`) &&
+					strings.Contains(document, `<div id="book-columns"><div id="book-inner">`)
+				if hasSpans != tt.expectedSpans {
+					t.Errorf(
+						"page `%s`: expected kobo spans %t, got %t",
+						name,
+						tt.expectedSpans,
+						hasSpans,
+					)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package mkbook
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Jictyvoo/ink_stream/internal/services/imgprocessor"
+	"github.com/Jictyvoo/ink_stream/internal/services/mkbook/kf8mobi"
+	"github.com/Jictyvoo/ink_stream/internal/services/outdirwriter"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+var _ imgprocessor.FileWriter = (*MobiMounter)(nil)
+
+type mobiPageData struct {
+	fileName string
+	absPath  string
+	metadata inktypes.ImageMetadata
+}
+
+// MobiMounter writes the processed pages into a MOBI/AZW3 file, using the
+// same temporary directory strategy as EpubMounter to avoid memory usage.
+type MobiMounter struct {
+	outDir, tmpDir string
+	layout         kf8mobi.Layout
+	metadata       kf8mobi.Metadata
+	bookMetadata   inktypes.BookMetadata
+	pages          []mobiPageData
+	outWriter      outdirwriter.WriterHandle
+	sync.Mutex
+}
+
+func NewMobiMounter(
+	outputDirectory string, readDirection inktypes.ReadDirection, layout kf8mobi.Layout,
+) (*MobiMounter, error) {
+	mobiMounter := &MobiMounter{
+		outDir: outputDirectory,
+		layout: layout,
+		metadata: kf8mobi.Metadata{
+			Title:         bookTitle(outputDirectory),
+			Author:        defaultAuthor,
+			Description:   defaultDescription,
+			ReadDirection: readDirection,
+		},
+	}
+
+	// Prepare temporary output directory for images
+	var err error
+	if mobiMounter.tmpDir, err = os.MkdirTemp("", "inkstream-mobi-*"); err != nil {
+		return nil, err
+	}
+	if mobiMounter.outWriter, err = outdirwriter.NewWriterHandle(mobiMounter.tmpDir); err != nil {
+		return nil, err
+	}
+
+	return mobiMounter, nil
+}
+
+// SetMetadata keeps the book metadata, applied to the MOBI on Flush.
+func (mm *MobiMounter) SetMetadata(metadata inktypes.BookMetadata) {
+	mm.Lock()
+	defer mm.Unlock()
+	mm.bookMetadata = metadata
+}
+
+func (mm *MobiMounter) applyMetadata() {
+	if title := mm.bookMetadata.FullTitle(); title != "" {
+		mm.metadata.Title = title
+	}
+	mm.metadata.Author = bookAuthor(mm.bookMetadata)
+	if mm.bookMetadata.Summary != "" {
+		mm.metadata.Description = mm.bookMetadata.Summary
+	}
+	mm.metadata.Publisher = mm.bookMetadata.Publisher
+	mm.metadata.PublishDate = mm.bookMetadata.PublishDate
+	mm.metadata.Language = mm.bookMetadata.Language
+
+	mm.pages = slices.DeleteFunc(mm.pages, func(page mobiPageData) bool {
+		return pageInfo(mm.bookMetadata, page.fileName).Type == inktypes.PageDeleted
+	})
+	coverFirst(mm.pages, func(page mobiPageData) bool {
+		return pageInfo(mm.bookMetadata, page.fileName).Type == inktypes.PageFrontCover
+	})
+}
+
+func (mm *MobiMounter) Handler(filename string, callback imgprocessor.WriterCallback) error {
+	imgMetadata, absPath, err := mm.outWriter.ExecuteFileWrite(filename, callback)
+	if err != nil {
+		return fmt.Errorf("error while processing file %s: %w", filename, err)
+	}
+
+	mm.Lock()
+	defer mm.Unlock()
+	mm.pages = append(mm.pages, mobiPageData{
+		fileName: filename,
+		absPath:  absPath,
+		metadata: imgMetadata,
+	})
+	return nil
+}
+
+func (mm *MobiMounter) Flush() error {
+	// Cleanup temp directory regardless of write outcome
+	defer os.RemoveAll(mm.tmpDir)
+
+	// The first page by name becomes the cover, the same as in EpubMounter
+	slices.SortFunc(mm.pages, func(a, b mobiPageData) int {
+		return strings.Compare(a.fileName, b.fileName)
+	})
+	mm.applyMetadata()
+
+	book := kf8mobi.NewBook(mm.metadata, mm.layout)
+	// The explicit cover takes the first page, which is the MOBI cover
+	if coverPath := mm.bookMetadata.CoverImage; coverPath != "" {
+		coverMetadata, err := coverImageMetadata(coverPath)
+		if err != nil {
+			return err
+		}
+		book.AddImagePage(kf8mobi.ImagePage{
+			FilePath: coverPath,
+			Format:   coverMetadata.Format,
+			Width:    int(coverMetadata.Width),
+			Height:   int(coverMetadata.Height),
+		})
+	}
+	for _, page := range mm.pages {
+		book.AddImagePage(kf8mobi.ImagePage{
+			FilePath: page.absPath,
+			Format:   page.metadata.Format,
+			Width:    int(page.metadata.Width),
+			Height:   int(page.metadata.Height),
+		})
+	}
+
+	file, err := os.Create(mm.outDir + mm.layout.FileExtension())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err = book.WriteTo(file); err != nil {
+		return fmt.Errorf("error while writing mobi: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package mkbook
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/services/mkbook/kf8mobi"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// mobiImageWidths reads the widths of the image records from a KF8 file,
+// as each page of the test has its own width.
+func mobiImageWidths(t *testing.T, data []byte) (title string, widths []int) {
+	t.Helper()
+	totalRecords := int(binary.BigEndian.Uint16(data[76:78]))
+	recordStart := func(index int) uint32 {
+		if index >= totalRecords {
+			return uint32(len(data))
+		}
+		return binary.BigEndian.Uint32(data[78+8*index:])
+	}
+
+	recordZero := data[recordStart(0):recordStart(1)]
+	titleOffset := binary.BigEndian.Uint32(recordZero[84:88])
+	titleLength := binary.BigEndian.Uint32(recordZero[88:92])
+	title = string(recordZero[titleOffset : titleOffset+titleLength])
+
+	firstResource := int(binary.BigEndian.Uint32(recordZero[108:112]))
+	for index := firstResource; index < totalRecords; index++ {
+		config, _, err := image.DecodeConfig(
+			bytes.NewReader(data[recordStart(index):recordStart(index+1)]),
+		)
+		if err != nil {
+			break // The resources end on the first record that is not an image
+		}
+		widths = append(widths, config.Width)
+	}
+	return title, widths
+}
+
+func TestMobiMounter_Flush(t *testing.T) {
+	pageWidths := map[string]int{"001__0.jpeg": 10, "002__0.jpeg": 20, "003__0.jpeg": 30}
+	tests := []struct {
+		name           string
+		metadata       inktypes.BookMetadata
+		expectedTitle  string
+		expectedWidths []int
+	}{
+		{
+			name:           "pages by name",
+			expectedTitle:  "book",
+			expectedWidths: []int{10, 20, 30},
+		},
+		{
+			name: "metadata cover and deleted page",
+			metadata: inktypes.BookMetadata{
+				Series: "Series", Number: "1",
+				Pages: map[string]inktypes.PageInfo{
+					"002": {Type: inktypes.PageFrontCover},
+					"003": {Type: inktypes.PageDeleted},
+				},
+			},
+			expectedTitle:  "Series #1",
+			expectedWidths: []int{20, 10},
+		},
+		{
+			name: "explicit cover",
+			metadata: inktypes.BookMetadata{
+				Title:      "Book",
+				CoverImage: writeImageFile(t, "cover.jpg", 40, 40),
+			},
+			expectedTitle:  "Book",
+			expectedWidths: []int{40, 10, 20, 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "book")
+			mounter, err := NewMobiMounter(outputPath, inktypes.ReadRightToLeft, kf8mobi.LayoutKF8)
+			if err != nil {
+				t.Fatalf("failed to create mounter: %v", err)
+			}
+			mounter.SetMetadata(tt.metadata)
+			for _, filename := range []string{"003__0.jpeg", "001__0.jpeg", "002__0.jpeg"} {
+				if err = mounter.Handler(filename, jpegPage(pageWidths[filename], 80)); err != nil {
+					t.Fatalf("failed to write page `%s`: %v", filename, err)
+				}
+			}
+			if err = mounter.Flush(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err = os.Stat(mounter.tmpDir); !os.IsNotExist(err) {
+				t.Errorf("temporary directory must be removed after flush")
+			}
+
+			data, err := os.ReadFile(outputPath + ".azw3")
+			if err != nil {
+				t.Fatalf("failed to read the book: %v", err)
+			}
+			title, widths := mobiImageWidths(t, data)
+			if title != tt.expectedTitle {
+				t.Errorf("expected title %q, got %q", tt.expectedTitle, title)
+			}
+			if !slices.Equal(widths, tt.expectedWidths) {
+				t.Errorf("expected image widths %v, got %v", tt.expectedWidths, widths)
+			}
+		})
+	}
+}
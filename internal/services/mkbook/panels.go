@@ -0,0 +1,56 @@
+package mkbook
+
+import (
+	"image"
+	"math"
+
+	"github.com/Jictyvoo/ink_stream/internal/services/mkbook/tmplepub"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// quadrantPanels splits a 2x2 page in its quadrants, in reading order.
+func quadrantPanels(readDirection inktypes.ReadDirection) ([]image.Rectangle, image.Rectangle) {
+	var (
+		topLeft     = image.Rect(0, 0, 1, 1)
+		topRight    = image.Rect(1, 0, 2, 1)
+		bottomLeft  = image.Rect(0, 1, 1, 2)
+		bottomRight = image.Rect(1, 1, 2, 2)
+	)
+	if readDirection == inktypes.ReadRightToLeft {
+		return []image.Rectangle{topRight, topLeft, bottomRight, bottomLeft}, image.Rect(0, 0, 2, 2)
+	}
+	return []image.Rectangle{topLeft, topRight, bottomLeft, bottomRight}, image.Rect(0, 0, 2, 2)
+}
+
+func roundPercentage(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// newPanelImages converts the panels into the template data, with the page image
+// scaled and moved to fit the panel on the screen when magnified.
+func newPanelImages(panels []image.Rectangle, page image.Rectangle) []tmplepub.PanelImage {
+	pageWidth, pageHeight := float64(page.Dx()), float64(page.Dy())
+	panelImages := make([]tmplepub.PanelImage, 0, len(panels))
+	for index, panel := range panels {
+		var (
+			left   = 100 * float64(panel.Min.X-page.Min.X) / pageWidth
+			top    = 100 * float64(panel.Min.Y-page.Min.Y) / pageHeight
+			width  = 100 * float64(panel.Dx()) / pageWidth
+			height = 100 * float64(panel.Dy()) / pageHeight
+			scale  = min(100/width, 100/height)
+		)
+		panelImages = append(panelImages, tmplepub.PanelImage{
+			Ordinal:    index + 1,
+			Left:       roundPercentage(left),
+			Top:        roundPercentage(top),
+			Width:      roundPercentage(width),
+			Height:     roundPercentage(height),
+			ZoomLeft:   roundPercentage((100-width*scale)/2 - left*scale),
+			ZoomTop:    roundPercentage((100-height*scale)/2 - top*scale),
+			ZoomWidth:  roundPercentage(100 * scale),
+			ZoomHeight: roundPercentage(100 * scale),
+		})
+	}
+
+	return panelImages
+}
@@ -0,0 +1,135 @@
+package mkbook
+
+import (
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/internal/services/mkbook/tmplepub"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestNewPanelImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		panels   []image.Rectangle
+		page     image.Rectangle
+		expected []tmplepub.PanelImage
+	}{
+		{
+			name: "quadrants right to left",
+			panels: func() []image.Rectangle {
+				panels, _ := quadrantPanels(inktypes.ReadRightToLeft)
+				return panels[:2]
+			}(),
+			page: image.Rect(0, 0, 2, 2),
+			expected: []tmplepub.PanelImage{
+				{
+					Ordinal: 1, Left: 50, Top: 0, Width: 50, Height: 50,
+					ZoomLeft: -100, ZoomTop: 0, ZoomWidth: 200, ZoomHeight: 200,
+				},
+				{
+					Ordinal: 2, Left: 0, Top: 0, Width: 50, Height: 50,
+					ZoomLeft: 0, ZoomTop: 0, ZoomWidth: 200, ZoomHeight: 200,
+				},
+			},
+		},
+		{
+			name:   "wide panel is centered vertically",
+			panels: []image.Rectangle{image.Rect(0, 50, 100, 100)},
+			page:   image.Rect(0, 0, 100, 200),
+			expected: []tmplepub.PanelImage{{
+				Ordinal: 1, Left: 0, Top: 25, Width: 100, Height: 25,
+				ZoomLeft: 0, ZoomTop: 12.5, ZoomWidth: 100, ZoomHeight: 100,
+			}},
+		},
+		{
+			name:   "page bounds with offset",
+			panels: []image.Rectangle{image.Rect(60, 60, 110, 110)},
+			page:   image.Rect(10, 10, 110, 110),
+			expected: []tmplepub.PanelImage{{
+				Ordinal: 1, Left: 50, Top: 50, Width: 50, Height: 50,
+				ZoomLeft: -100, ZoomTop: -100, ZoomWidth: 200, ZoomHeight: 200,
+			}},
+		},
+		{
+			name:   "values are rounded",
+			panels: []image.Rectangle{image.Rect(0, 0, 100, 300)},
+			page:   image.Rect(0, 0, 300, 300),
+			expected: []tmplepub.PanelImage{{
+				Ordinal: 1, Left: 0, Top: 0, Width: 33.33, Height: 100,
+				ZoomLeft: 33.33, ZoomTop: 0, ZoomWidth: 100, ZoomHeight: 100,
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newPanelImages(tt.panels, tt.page)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEpubMounter_ImageHandler(t *testing.T) {
+	// The page has a panel on top and two below it, framed over the white page
+	page := image.NewGray(image.Rect(0, 0, 200, 300))
+	draw.Draw(page, page.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, panel := range []image.Rectangle{
+		image.Rect(10, 10, 190, 140), image.Rect(10, 150, 95, 290), image.Rect(105, 150, 190, 290),
+	} {
+		draw.Draw(page, panel, image.Black, image.Point{}, draw.Src)
+		draw.Draw(page, panel.Inset(2), image.White, image.Point{}, draw.Src)
+	}
+	quadrants := newPanelImages(quadrantPanels(inktypes.ReadLeftToRight))
+
+	tests := []struct {
+		name           string
+		newMounter     epubConstructor
+		img            image.Image
+		expectedPanels int
+	}{
+		{name: "kindle comic page", newMounter: NewKindleEpubMounter, img: page, expectedPanels: 3},
+		{name: "kindle comic without image", newMounter: NewKindleEpubMounter},
+		{name: "plain epub page", newMounter: NewEpubMounter, img: page},
+		{name: "kepub page", newMounter: NewKepubMounter, img: page},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounter, err := tt.newMounter(
+				filepath.Join(t.TempDir(), "book"), inktypes.ReadLeftToRight,
+				inktypes.ImageDimensions{Width: 200, Height: 300},
+			)
+			if err != nil {
+				t.Fatalf("failed to create mounter: %v", err)
+			}
+			t.Cleanup(func() { _ = os.RemoveAll(mounter.tmpDir) })
+			if err = mounter.ImageHandler("001__0.jpeg", tt.img, jpegPage(200, 300)); err != nil {
+				t.Fatalf("failed to write page: %v", err)
+			}
+
+			panels := mounter.imageSections[0].pageData.PanelImages
+			if tt.expectedPanels == 0 {
+				if !slices.Equal(panels, quadrants) {
+					t.Errorf("expected the quadrant panels, got %+v", panels)
+				}
+				return
+			}
+			if len(panels) != tt.expectedPanels {
+				t.Fatalf("expected %d panels, got %+v", tt.expectedPanels, panels)
+			}
+			if expected := (tmplepub.PanelImage{
+				Ordinal: 1, Left: 5, Top: 3.33, Width: 90, Height: 43.33,
+			}); panels[0].Left != expected.Left || panels[0].Top != expected.Top ||
+				panels[0].Width != expected.Width || panels[0].Height != expected.Height {
+				t.Errorf("expected the top panel %+v, got %+v", expected, panels[0])
+			}
+		})
+	}
+}
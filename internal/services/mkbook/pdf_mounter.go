@@ -0,0 +1,261 @@
+package mkbook
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	pdfApi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/Jictyvoo/ink_stream/internal/services/imgprocessor"
+	"github.com/Jictyvoo/ink_stream/internal/services/outdirwriter"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+var _ imgprocessor.FileWriter = (*PDFMounter)(nil)
+
+type pdfPageData struct {
+	fileName, chapterID string
+	sourceName          string
+	absPath             string
+}
+
+// PDFMounter writes the processed pages into a PDF, one image per page.
+// Pages are kept on a temporary directory until Flush, as the EpubMounter does.
+type PDFMounter struct {
+	outDir, tmpDir string
+	readDirection  inktypes.ReadDirection
+	resolution     inktypes.ImageDimensions
+	pages          []pdfPageData
+	bookMetadata   inktypes.BookMetadata
+	outWriter      outdirwriter.WriterHandle
+	sync.Mutex
+}
+
+// NewPDFMounter creates a PDFMounter whose pages MediaBox matches the given resolution.
+// When the resolution is empty, each page takes the size of its image.
+func NewPDFMounter(
+	outputDirectory string, readDirection inktypes.ReadDirection,
+	resolution inktypes.ImageDimensions,
+) (*PDFMounter, error) {
+	pdfMounter := &PDFMounter{
+		outDir:        outputDirectory,
+		readDirection: readDirection,
+		resolution:    resolution,
+	}
+
+	// Prepare temporary output directory for images
+	var err error
+	if pdfMounter.tmpDir, err = os.MkdirTemp("", "inkstream-pdf-*"); err != nil {
+		return nil, err
+	}
+	if pdfMounter.outWriter, err = outdirwriter.NewWriterHandle(pdfMounter.tmpDir); err != nil {
+		return nil, err
+	}
+
+	return pdfMounter, nil
+}
+
+// SetMetadata keeps the book metadata, used for the outline and the page order on Flush.
+func (pm *PDFMounter) SetMetadata(metadata inktypes.BookMetadata) {
+	pm.Lock()
+	defer pm.Unlock()
+	pm.bookMetadata = metadata
+}
+
+func (pm *PDFMounter) Handler(filename string, callback imgprocessor.WriterCallback) error {
+	chapterID := chapterIDFromFilename(filename)
+	_, absPath, err := pm.outWriter.ExecuteFileWrite(filename, callback)
+	if err != nil {
+		return fmt.Errorf("error while processing file %s: %w", filename, err)
+	}
+
+	pm.Lock()
+	defer pm.Unlock()
+	pm.pages = append(pm.pages, pdfPageData{
+		fileName:   normalizeFileName(filename),
+		chapterID:  chapterID,
+		sourceName: filename,
+		absPath:    absPath,
+	})
+	return nil
+}
+
+func (pm *PDFMounter) importConfig() *pdfcpu.Import {
+	imp := pdfcpu.DefaultImportConfig()
+	if pm.resolution.Width == 0 || pm.resolution.Height == 0 {
+		return imp // Full position uses the image size as MediaBox
+	}
+
+	// One pixel of the device is mapped to one point, keeping the image aspect ratio
+	imp.PageDim = &types.Dim{
+		Width:  float64(pm.resolution.Width),
+		Height: float64(pm.resolution.Height),
+	}
+	imp.PageSize, imp.UserDim = "", true
+	imp.Pos, imp.Scale = types.Center, 1
+	return imp
+}
+
+// outline creates a bookmark at the first page of each chapter,
+// and at each page bookmarked on the metadata.
+func (pm *PDFMounter) outline() []pdfcpu.Bookmark {
+	var (
+		bookmarks   []pdfcpu.Bookmark
+		lastChapter string
+	)
+	for index, page := range pm.pages {
+		pageNumber := index + 1 + pm.coverPages()
+		if bookmark := pageInfo(pm.bookMetadata, page.sourceName).Bookmark; bookmark != "" {
+			lastChapter = page.chapterID
+			bookmarks = append(bookmarks, pdfcpu.Bookmark{Title: bookmark, PageFrom: pageNumber})
+			continue
+		}
+		if page.chapterID == lastChapter || !isChapter(page.chapterID) {
+			continue
+		}
+		lastChapter = page.chapterID
+		bookmarks = append(bookmarks, pdfcpu.Bookmark{Title: page.chapterID, PageFrom: pageNumber})
+	}
+
+	return bookmarks
+}
+
+// coverPages returns the number of pages placed before the book pages.
+func (pm *PDFMounter) coverPages() int {
+	if pm.bookMetadata.CoverImage != "" {
+		return 1
+	}
+	return 0
+}
+
+// setDocumentInfo writes the book metadata on the PDF document information dictionary.
+func (pm *PDFMounter) setDocumentInfo(ctx *model.Context) error {
+	infoDict := types.NewDict()
+	for _, entry := range []struct{ key, value string }{
+		{key: "Title", value: pm.bookMetadata.FullTitle()},
+		{key: "Author", value: strings.Join(pm.bookMetadata.Writers, ", ")},
+		{key: "Subject", value: pm.bookMetadata.Summary},
+		{key: "Creator", value: defaultAuthor},
+	} {
+		if entry.value == "" {
+			continue
+		}
+		encoded, err := types.EscapedUTF16String(entry.value)
+		if err != nil {
+			return err
+		}
+		infoDict.InsertString(entry.key, *encoded)
+	}
+
+	infoIndRef, err := ctx.IndRefForNewObject(infoDict)
+	if err != nil {
+		return err
+	}
+	ctx.Info = infoIndRef
+	return nil
+}
+
+func (pm *PDFMounter) addPage(
+	ctx *model.Context, pagesDict types.Dict, pagesIndRef *types.IndirectRef,
+	imp *pdfcpu.Import, page pdfPageData,
+) error {
+	imgFile, err := os.Open(page.absPath)
+	if err != nil {
+		return err
+	}
+	defer imgFile.Close()
+
+	indRefs, err := pdfcpu.NewPagesForImage(
+		ctx.XRefTable, bufio.NewReader(imgFile), pagesIndRef, imp,
+	)
+	if err != nil {
+		return fmt.Errorf("error while writing file `%s` to pdf: %w", page.fileName, err)
+	}
+	for _, indRef := range indRefs {
+		if err = ctx.SetValid(*indRef); err != nil {
+			return err
+		}
+		if err = model.AppendPageTree(indRef, 1, pagesDict); err != nil {
+			return err
+		}
+		ctx.PageCount++
+	}
+
+	return nil
+}
+
+func (pm *PDFMounter) Flush() error {
+	// Cleanup temp directory regardless of write outcome
+	defer os.RemoveAll(pm.tmpDir)
+
+	slices.SortFunc(pm.pages, func(a, b pdfPageData) int {
+		return strings.Compare(a.fileName, b.fileName)
+	})
+	pm.pages = slices.DeleteFunc(pm.pages, func(page pdfPageData) bool {
+		return pageInfo(pm.bookMetadata, page.sourceName).Type == inktypes.PageDeleted
+	})
+	coverFirst(pm.pages, func(page pdfPageData) bool {
+		return pageInfo(pm.bookMetadata, page.sourceName).Type == inktypes.PageFrontCover
+	})
+
+	imp := pm.importConfig()
+	// The import images command is not set, as it would drop the outline on write
+	ctx, err := pdfcpu.CreateContextWithXRefTable(model.NewDefaultConfiguration(), imp.PageDim)
+	if err != nil {
+		return err
+	}
+
+	pagesIndRef, err := ctx.Pages()
+	if err != nil {
+		return err
+	}
+	pagesDict, err := ctx.DereferenceDict(*pagesIndRef)
+	if err != nil {
+		return err
+	}
+	if coverPath := pm.bookMetadata.CoverImage; coverPath != "" {
+		cover := pdfPageData{fileName: coverPath, absPath: coverPath}
+		if err = pm.addPage(ctx, pagesDict, pagesIndRef, imp, cover); err != nil {
+			return err
+		}
+	}
+	for _, page := range pm.pages {
+		if err = pm.addPage(ctx, pagesDict, pagesIndRef, imp, page); err != nil {
+			return err
+		}
+	}
+	if err = pm.setDocumentInfo(ctx); err != nil {
+		return err
+	}
+
+	if bookmarks := pm.outline(); len(bookmarks) > 0 {
+		if err = pdfcpu.AddBookmarks(ctx, bookmarks, true); err != nil {
+			return fmt.Errorf("error while adding pdf outline: %w", err)
+		}
+	}
+	if pm.readDirection == inktypes.ReadRightToLeft {
+		var rootDict types.Dict
+		if rootDict, err = ctx.Catalog(); err != nil {
+			return err
+		}
+		rootDict["ViewerPreferences"] = types.Dict{"Direction": types.Name("R2L")}
+	}
+
+	file, err := os.Create(pm.outDir + ".pdf")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err = pdfApi.WriteContext(ctx, file); err != nil {
+		return fmt.Errorf("error while writing pdf: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package mkbook
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	pdfApi "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestPDFMounter_Flush(t *testing.T) {
+	pages := []string{"chapter02/001__0.jpeg", "chapter01/002__0.jpeg", "chapter01/001__0.jpeg"}
+	tests := []struct {
+		name              string
+		readDirection     inktypes.ReadDirection
+		resolution        inktypes.ImageDimensions
+		metadata          inktypes.BookMetadata
+		expectedDims      []types.Dim
+		expectedBookmarks []pdfcpu.Bookmark
+		expectedTitle     string
+		expectedDirection string
+	}{
+		{
+			name:          "device resolution",
+			readDirection: inktypes.ReadRightToLeft,
+			resolution:    inktypes.ImageDimensions{Width: 600, Height: 800},
+			metadata: inktypes.BookMetadata{
+				Title: "Book",
+				Pages: map[string]inktypes.PageInfo{"chapter02/001": {Bookmark: "Finale"}},
+			},
+			expectedDims: []types.Dim{
+				{Width: 600, Height: 800}, {Width: 600, Height: 800}, {Width: 600, Height: 800},
+			},
+			expectedBookmarks: []pdfcpu.Bookmark{
+				{Title: "chapter01", PageFrom: 1}, {Title: "Finale", PageFrom: 3},
+			},
+			expectedTitle:     "Book",
+			expectedDirection: "R2L",
+		},
+		{
+			name:          "image size with cover and deleted page",
+			readDirection: inktypes.ReadLeftToRight,
+			metadata: inktypes.BookMetadata{
+				CoverImage: writeImageFile(t, "cover.jpg", 30, 40),
+				Pages: map[string]inktypes.PageInfo{
+					"chapter01/002": {Type: inktypes.PageDeleted},
+				},
+			},
+			expectedDims: []types.Dim{
+				{Width: 30, Height: 40}, {Width: 60, Height: 80}, {Width: 60, Height: 80},
+			},
+			expectedBookmarks: []pdfcpu.Bookmark{
+				{Title: "chapter01", PageFrom: 2}, {Title: "chapter02", PageFrom: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "book")
+			mounter, err := NewPDFMounter(outputPath, tt.readDirection, tt.resolution)
+			if err != nil {
+				t.Fatalf("failed to create mounter: %v", err)
+			}
+			mounter.SetMetadata(tt.metadata)
+			writePages(t, mounter, pages...)
+			if err = mounter.Flush(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			pdfFile, err := os.Open(outputPath + ".pdf")
+			if err != nil {
+				t.Fatalf("failed to open pdf: %v", err)
+			}
+			defer pdfFile.Close()
+			ctx, err := pdfApi.ReadValidateAndOptimize(pdfFile, model.NewDefaultConfiguration())
+			if err != nil {
+				t.Fatalf("failed to read pdf: %v", err)
+			}
+
+			dims, err := ctx.PageDims()
+			if err != nil {
+				t.Fatalf("failed to read the page sizes: %v", err)
+			}
+			if !slices.Equal(dims, tt.expectedDims) {
+				t.Errorf("expected page sizes %v, got %v", tt.expectedDims, dims)
+			}
+			bookmarks, err := pdfcpu.Bookmarks(ctx)
+			if err != nil {
+				t.Fatalf("failed to read the outline: %v", err)
+			}
+			if len(bookmarks) != len(tt.expectedBookmarks) {
+				t.Fatalf("expected %d bookmarks, got %+v", len(tt.expectedBookmarks), bookmarks)
+			}
+			for index, bookmark := range bookmarks {
+				expected := tt.expectedBookmarks[index]
+				if bookmark.Title != expected.Title || bookmark.PageFrom != expected.PageFrom {
+					t.Errorf("bookmark %d: expected %+v, got %+v", index, expected, bookmark)
+				}
+			}
+			if ctx.Title != tt.expectedTitle {
+				t.Errorf("expected title %q, got %q", tt.expectedTitle, ctx.Title)
+			}
+
+			rootDict, err := ctx.Catalog()
+			if err != nil {
+				t.Fatalf("failed to read the catalog: %v", err)
+			}
+			var direction string
+			if preferences := rootDict.DictEntry("ViewerPreferences"); preferences != nil {
+				if name := preferences.NameEntry("Direction"); name != nil {
+					direction = *name
+				}
+			}
+			if direction != tt.expectedDirection {
+				t.Errorf("expected direction %q, got %q", tt.expectedDirection, direction)
+			}
+		})
+	}
+}
@@ -15,11 +15,21 @@ type ImageData struct {
 	// IDs for tap targets and their corresponding panel elements.
 	BaseID string
 
+	// KoboSpans wraps the page content with the Kobo span markup used by KEPUB files.
+	KoboSpans bool
+
 	// Panel definitions
 	PanelImages []PanelImage
 }
 
+// PanelImage describes a panel magnified by the Kindle Panel View.
+// All the values are percentages of the page size.
 type PanelImage struct {
-	Class   string
 	Ordinal int
+
+	// Region of the page that triggers the panel magnification
+	Left, Top, Width, Height float64
+
+	// Placement of the page image while the panel is magnified
+	ZoomLeft, ZoomTop, ZoomWidth, ZoomHeight float64
 }
@@ -17,12 +17,121 @@ type OutputFormat string
 
 const (
 	FormatEpub   OutputFormat = "epub"
+	FormatKepub  OutputFormat = "kepub"
 	FormatMobi   OutputFormat = "mobi"
+	FormatAZW3   OutputFormat = "azw3"
+	FormatCBZ    OutputFormat = "cbz"
+	FormatPDF    OutputFormat = "pdf"
 	FormatFolder OutputFormat = "folder"
 )
 
 type ReadDirection string
 
+type DitherStyle string
+
+const (
+	DitherNone           DitherStyle = "none"
+	DitherFloydSteinberg DitherStyle = "floyd-steinberg"
+	DitherAtkinson       DitherStyle = "atkinson"
+	DitherBayer          DitherStyle = "bayer"
+)
+
+type SpreadPolicy string
+
+const (
+	SpreadSplit  SpreadPolicy = "split"
+	SpreadRotate SpreadPolicy = "rotate"
+	// SpreadRotateSplit shows the full rotated spread, followed by both halves
+	SpreadRotateSplit SpreadPolicy = "rotate-split"
+	SpreadKeep        SpreadPolicy = "keep"
+)
+
+type ThresholdStyle string
+
+const (
+	ThresholdNone    ThresholdStyle = "none"
+	ThresholdSauvola ThresholdStyle = "sauvola"
+	ThresholdNiblack ThresholdStyle = "niblack"
+)
+
+type FitMode string
+
+const (
+	// FitLetterbox fits the page inside the screen, keeping its aspect ratio
+	FitLetterbox FitMode = "fit"
+	// FitFill covers the screen, cropping the overflowing page
+	FitFill FitMode = "fill"
+	// FitStretch scales the page to the screen, distorting it unless AddMargins is set.
+	// The spreads kept whole always receive margins, so they keep their aspect ratio.
+	FitStretch FitMode = "stretch"
+	// FitWidth fits the page width, splitting the overflowing height into scroll pages
+	FitWidth FitMode = "fit-width"
+	// FitNoUpscale fits the page inside the screen, without enlarging the small pages
+	FitNoUpscale FitMode = "no-upscale"
+)
+
+type ResampleStyle string
+
+const (
+	ResampleNearest    ResampleStyle = "nearest"
+	ResampleBilinear   ResampleStyle = "bilinear"
+	ResampleCatmullRom ResampleStyle = "catmull-rom"
+	ResampleLanczos3   ResampleStyle = "lanczos3"
+)
+
+// SharpenOptions are the parameters of the unsharp mask applied after the rescale.
+type SharpenOptions struct {
+	Radius    int
+	Amount    float64
+	Threshold uint8
+}
+
+func (opts SharpenOptions) Enabled() bool {
+	return opts.Radius > 0 && opts.Amount > 0
+}
+
+// BlankPageOptions tells which blank and near-blank pages are dropped from the book.
+type BlankPageOptions struct {
+	// MaxInkRatio is the largest fraction of ink pixels on a page still taken as blank
+	MaxInkRatio float64
+	// KeepFirst pages are never dropped, so the covers survive
+	KeepFirst int
+}
+
+func (opts BlankPageOptions) Enabled() bool {
+	return opts.MaxInkRatio > 0
+}
+
+// AutoCropOptions tunes how the page borders are cropped, the zero fields use the defaults.
+type AutoCropOptions struct {
+	// BlurRadius of the blur hiding the scan noise before the borders are searched
+	BlurRadius int
+	// MinRetainedSize is the smallest fraction (0-1) of each side kept, bigger crops are skipped
+	MinRetainedSize float64
+	// MarginRatio of the cropped box added back around it, nil uses the default and
+	// zero crops to the art edge
+	MarginRatio *float64
+	// TrimTransparent crops the transparent borders, instead of the paper colored ones
+	TrimTransparent bool
+	// IgnorePageNumbers ignores the small blobs isolated near the page edges, like the
+	// page numbers and the scan specks, so the crop reaches the art edge
+	IgnorePageNumbers bool
+}
+
+// BinarizeOptions are the parameters of the adaptive threshold making the text pages bitonal.
+type BinarizeOptions struct {
+	// Method of the local threshold, empty or none disables the binarization
+	Method ThresholdStyle
+	// Window is the side of the region around each pixel giving its threshold
+	Window int
+	// K tunes the threshold of the method, zero uses its default
+	K float64
+}
+
+func (opts BinarizeOptions) Enabled() bool {
+	return opts.Method != "" && opts.Method != ThresholdNone
+}
+
 type ImageFormat string
 
 const (
@@ -31,24 +140,63 @@ const (
 )
 
 type Options struct {
-	SourceFolder  string
-	OutputFolder  string
-	TargetDevice  deviceprof.DeviceType
-	CropLevel     CropStyle
-	OutputFormat  OutputFormat
-	ReadDirection ReadDirection
-	RotateImage   bool
-	StretchImage  bool
-	AddMargins    bool
-	ColoredPages  bool
-	ImageFormat   ImageFormat
-	ImageQuality  uint8
-}
-
-func (opts Options) AllowStretch() bool {
-	if opts.AddMargins {
-		return true
+	SourceFolder string
+	OutputFolder string
+	TargetDevice deviceprof.DeviceType
+	CropLevel    CropStyle
+	AutoCrop     AutoCropOptions
+	// ConsistentCrop crops all the pages of a chapter by the same box, found from all of them
+	ConsistentCrop bool
+	OutputFormat   OutputFormat
+	ReadDirection  ReadDirection
+	// ForceReadDirection uses ReadDirection on all books, ignoring their metadata
+	ForceReadDirection bool
+	RotateImage        bool
+	// SpreadPolicy applied on double-page spreads, when empty RotateImage chooses
+	// between rotating and splitting them
+	SpreadPolicy SpreadPolicy
+	// NoGutterPolicy is applied on the spreads without gutter, empty keeps them whole
+	NoGutterPolicy SpreadPolicy
+	// Deskew straightens the scanned pages that are slightly rotated
+	Deskew bool
+	// DropBlank removes the blank versos and separator pages
+	DropBlank BlankPageOptions
+	// FitMode tells how the pages are fitted on the screen, empty fits them inside it as
+	// FitLetterbox. The CLI sets FitStretch when no fit mode is given.
+	FitMode      FitMode
+	AddMargins   bool
+	ColoredPages bool
+	// Dithering used to quantize the pages to the device palette, empty disables it
+	Dithering DitherStyle
+	// Binarize makes the text pages bitonal, the illustrations keep their grayscale
+	Binarize BinarizeOptions
+	Sharpen  SharpenOptions
+	// Resampling filter used on the rescale, empty uses a fast bilinear approximation
+	Resampling   ResampleStyle
+	ImageFormat  ImageFormat
+	ImageQuality uint8
+	// PageExclusion drops the scanlator credit and recruitment pages
+	PageExclusion ExclusionOptions
+	// BookMetadata set by the user, which has priority over the metadata found on the sources
+	BookMetadata inktypes.BookMetadata
+}
+
+// BookReadDirection resolves the read direction of a book. The ReadDirection option is
+// used as default, when the book metadata does not tell it, or always when it is forced.
+func (opts Options) BookReadDirection(metadata inktypes.BookMetadata) inktypes.ReadDirection {
+	defaultDirection := inktypes.NewReadDirection(string(opts.ReadDirection))
+	if opts.ForceReadDirection {
+		return defaultDirection
 	}
+	if direction := metadata.InferReadDirection(); direction != inktypes.ReadUnknown {
+		return direction
+	}
+	return defaultDirection
+}
 
-	return opts.StretchImage
+// MergeBookMetadata returns the user metadata, completed with the metadata found on the book.
+func (opts Options) MergeBookMetadata(metadata inktypes.BookMetadata) inktypes.BookMetadata {
+	merged := opts.BookMetadata
+	merged.Merge(metadata)
+	return merged
 }
@@ -0,0 +1,48 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestOptions_BookReadDirection(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		metadata inktypes.BookMetadata
+		expected inktypes.ReadDirection
+	}{
+		{
+			name:     "default without metadata",
+			opts:     Options{ReadDirection: "rtl"},
+			expected: inktypes.ReadRightToLeft,
+		},
+		{
+			name:     "metadata direction",
+			opts:     Options{ReadDirection: "ltr"},
+			metadata: inktypes.BookMetadata{ReadDirection: inktypes.ReadRightToLeft},
+			expected: inktypes.ReadRightToLeft,
+		},
+		{
+			name:     "metadata language",
+			opts:     Options{ReadDirection: "ltr"},
+			metadata: inktypes.BookMetadata{Language: "ja"},
+			expected: inktypes.ReadRightToLeft,
+		},
+		{
+			name:     "forced direction",
+			opts:     Options{ReadDirection: "ltr", ForceReadDirection: true},
+			metadata: inktypes.BookMetadata{ReadDirection: inktypes.ReadRightToLeft},
+			expected: inktypes.ReadLeftToRight,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.opts.BookReadDirection(tt.metadata); result != tt.expected {
+				t.Errorf("BookReadDirection() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
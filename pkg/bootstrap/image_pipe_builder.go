@@ -2,15 +2,20 @@ package bootstrap
 
 import (
 	"errors"
+	"fmt"
 	"image/color"
 	"slices"
 
 	"github.com/Jictyvoo/ink_stream/internal/imageparser"
 	"github.com/Jictyvoo/ink_stream/internal/imageparser/imgpipesteps"
 	"github.com/Jictyvoo/ink_stream/pkg/deviceprof"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
 
+// maxDeskewAngle is the largest skew angle, in degrees, corrected on scanned pages
+const maxDeskewAngle = 3
+
 func BuildPipeline(opts Options) (imageparser.ImagePipeline, error) {
 	targetProfile, ok := deviceprof.Profile(opts.TargetDevice)
 	if !ok {
@@ -18,40 +23,241 @@ func BuildPipeline(opts Options) (imageparser.ImagePipeline, error) {
 	}
 
 	readDirection := inktypes.NewReadDirection(string(opts.ReadDirection))
+	resampleFilter, err := resampleFilter(opts.Resampling)
+	if err != nil {
+		return imageparser.ImagePipeline{}, err
+	}
+	spreadLayout, err := opts.spreadLayout()
+	if err != nil {
+		return imageparser.ImagePipeline{}, err
+	}
+	fitMode, err := fitMode(opts.FitMode)
+	if err != nil {
+		return imageparser.ImagePipeline{}, err
+	}
+	autocropOpts, err := opts.AutoCrop.stepOptions()
+	if err != nil {
+		return imageparser.ImagePipeline{}, err
+	}
+
 	autocropPalette := genPalette(opts.CropLevel, targetProfile.Palette)
 	imgSteps := append(
-		make([]imageparser.PipeStep, 0, 6),
-		imgpipesteps.NewStepAutoCrop(autocropPalette),
-		imgpipesteps.NewStepMarginWrap(targetProfile.Resolution),
+		make([]imageparser.PipeStep, 0, 8),
+		imgpipesteps.NewStepAutoCrop(autocropPalette, autocropOpts),
+		imgpipesteps.NewStepMarginWrap(targetProfile.Resolution, fitMode, spreadLayout),
 		imgpipesteps.NewStepCropOrRotate(
-			opts.RotateImage, color.Palette(targetProfile.Palette),
+			spreadLayout, color.Palette(targetProfile.Palette),
 			readDirection, targetProfile.Resolution.Orientation(),
 		),
-		imgpipesteps.NewStepRescale(targetProfile.Resolution, opts.AllowStretch()),
+		imgpipesteps.NewStepRescale(targetProfile.Resolution, fitMode, resampleFilter),
 		imgpipesteps.NewStepAutoContrast(0, 0),
 	)
 
-	if opts.RotateImage { // Only include the margin first if the image should not rotate
+	// Only include the margin first if the spread is split, so both halves receive it
+	if spreadLayout.Policy != imgpipesteps.SpreadSplit {
 		imgSteps[1], imgSteps[2] = imgSteps[2], imgSteps[1]
 	}
 
+	if opts.Sharpen.Enabled() { // Recover the line art softened by the downsampling
+		rescaleIndex := slices.IndexFunc(imgSteps, func(step imageparser.PipeStep) bool {
+			_, isType := step.(*imgpipesteps.StepRescaleImage)
+			return isType
+		})
+		imgSteps = slices.Insert(
+			imgSteps, rescaleIndex+1,
+			imageparser.PipeStep(imgpipesteps.NewStepUnsharpMask(
+				opts.Sharpen.Radius, opts.Sharpen.Amount, opts.Sharpen.Threshold,
+			)),
+		)
+	}
 	if !opts.AddMargins {
-		imgSteps = slices.DeleteFunc(imgSteps, func(step imageparser.PipeStep) bool {
+		marginIndex := slices.IndexFunc(imgSteps, func(step imageparser.PipeStep) bool {
 			_, isType := step.(*imgpipesteps.StepMarginWrapImage)
 			return isType
 		})
+		if spreadLayout.KeepsSpreads() { // The kept spreads still fit whole on the screen
+			imgSteps[marginIndex] = imgpipesteps.NewStepKeptSpreadWrap(
+				targetProfile.Resolution, fitMode, spreadLayout,
+			)
+		} else {
+			imgSteps = slices.Delete(imgSteps, marginIndex, marginIndex+1)
+		}
+	}
+	if opts.Deskew { // Must run before the autocrop, which can't remove slanted borders
+		imgSteps = append(
+			[]imageparser.PipeStep{imgpipesteps.NewStepDeskew(maxDeskewAngle)}, imgSteps...,
+		)
 	}
 	if !opts.ColoredPages {
 		imgSteps = append([]imageparser.PipeStep{imgpipesteps.NewStepGrayScale()}, imgSteps...)
 	}
+	if opts.DropBlank.Enabled() { // Must be the first step, so no work is done on dropped pages
+		imgSteps = append(
+			[]imageparser.PipeStep{
+				imgpipesteps.NewStepDropBlank(opts.DropBlank.MaxInkRatio, opts.DropBlank.KeepFirst),
+			},
+			imgSteps...,
+		)
+	}
 
-	builtPipe := imageparser.NewImagePipeline(
-		color.Palette(targetProfile.Palette), imgSteps...,
-	)
+	if opts.Binarize.Enabled() { // After the rescale, so no grays are brought back
+		method, thresholdErr := thresholdMethod(opts.Binarize.Method)
+		if thresholdErr != nil {
+			return imageparser.ImagePipeline{}, thresholdErr
+		}
+		imgSteps = append(
+			imgSteps,
+			imgpipesteps.NewStepBinarize(method, opts.Binarize.Window, opts.Binarize.K),
+		)
+	}
+
+	mode, err := ditherMode(opts.Dithering)
+	if err != nil {
+		return imageparser.ImagePipeline{}, err
+	}
+	pipePalette := color.Palette(targetProfile.Palette)
+	if mode != imgpipesteps.DitherNone { // Must be the last step, after all color changes
+		imgSteps = append(imgSteps, imgpipesteps.NewStepDither(mode, pipePalette))
+		// The steps before it draw at full depth, so the quantization error is still there
+		pipePalette = nil
+	}
+
+	builtPipe := imageparser.NewImagePipeline(pipePalette, imgSteps...)
 
 	return builtPipe, nil
 }
 
+func (opts Options) spreadLayout() (imgpipesteps.SpreadLayout, error) {
+	defaultPolicy := SpreadSplit
+	if opts.RotateImage {
+		defaultPolicy = SpreadRotate
+	}
+
+	var (
+		layout imgpipesteps.SpreadLayout
+		err    error
+	)
+	if layout.Policy, err = spreadPolicy(opts.SpreadPolicy, defaultPolicy); err != nil {
+		return layout, err
+	}
+	if opts.NoGutterPolicy == SpreadRotateSplit {
+		return layout, errors.New("spreads without gutter can't be split")
+	}
+	layout.NoGutterPolicy, err = spreadPolicy(opts.NoGutterPolicy, SpreadKeep)
+	return layout, err
+}
+
+func spreadPolicy(
+	policy, defaultPolicy SpreadPolicy,
+) (imgpipesteps.SpreadPolicy, error) {
+	if policy == "" {
+		policy = defaultPolicy
+	}
+
+	switch policy {
+	case SpreadSplit:
+		return imgpipesteps.SpreadSplit, nil
+	case SpreadRotate:
+		return imgpipesteps.SpreadRotate, nil
+	case SpreadRotateSplit:
+		return imgpipesteps.SpreadRotateSplit, nil
+	case SpreadKeep:
+		return imgpipesteps.SpreadKeep, nil
+	}
+
+	return imgpipesteps.SpreadKeep, fmt.Errorf("unknown spread policy `%s`", policy)
+}
+
+func (opts AutoCropOptions) stepOptions() (imgpipesteps.AutoCropOptions, error) {
+	switch {
+	case opts.BlurRadius < 0:
+		return imgpipesteps.AutoCropOptions{}, fmt.Errorf(
+			"invalid autocrop blur radius `%d`", opts.BlurRadius,
+		)
+	case opts.MinRetainedSize < 0 || opts.MinRetainedSize > 1:
+		return imgpipesteps.AutoCropOptions{}, fmt.Errorf(
+			"invalid autocrop minimum retained size `%g`", opts.MinRetainedSize,
+		)
+	case opts.MarginRatio != nil && (*opts.MarginRatio < 0 || *opts.MarginRatio >= 0.5):
+		return imgpipesteps.AutoCropOptions{}, fmt.Errorf(
+			"invalid autocrop margin ratio `%g`", *opts.MarginRatio,
+		)
+	}
+
+	boxOptions := imgutils.BoxEliminateMinimumColor
+	if opts.TrimTransparent {
+		boxOptions = imgutils.BoxEliminateTransparent
+	} else if opts.IgnorePageNumbers {
+		boxOptions |= imgutils.BoxIgnoreEdgeBlobs
+	}
+	return imgpipesteps.AutoCropOptions{
+		BlurRadius:      opts.BlurRadius,
+		MinRetainedSize: opts.MinRetainedSize,
+		MarginRatio:     opts.MarginRatio,
+		BoxOptions:      boxOptions,
+	}, nil
+}
+
+func fitMode(mode FitMode) (imgpipesteps.FitMode, error) {
+	switch mode {
+	case "", FitLetterbox:
+		return imgpipesteps.FitLetterbox, nil
+	case FitFill:
+		return imgpipesteps.FitFill, nil
+	case FitStretch:
+		return imgpipesteps.FitStretch, nil
+	case FitWidth:
+		return imgpipesteps.FitWidth, nil
+	case FitNoUpscale:
+		return imgpipesteps.FitNoUpscale, nil
+	}
+
+	return imgpipesteps.FitLetterbox, fmt.Errorf("unknown fit mode `%s`", mode)
+}
+
+func resampleFilter(style ResampleStyle) (imgpipesteps.ResampleFilter, error) {
+	switch style {
+	case "":
+		return imgpipesteps.ResampleAuto, nil
+	case ResampleNearest:
+		return imgpipesteps.ResampleNearest, nil
+	case ResampleBilinear:
+		return imgpipesteps.ResampleBilinear, nil
+	case ResampleCatmullRom:
+		return imgpipesteps.ResampleCatmullRom, nil
+	case ResampleLanczos3:
+		return imgpipesteps.ResampleLanczos3, nil
+	}
+
+	return imgpipesteps.ResampleApproxBilinear, fmt.Errorf("unknown resampling `%s`", style)
+}
+
+func ditherMode(style DitherStyle) (imgpipesteps.DitherMode, error) {
+	switch style {
+	case "", DitherNone:
+		return imgpipesteps.DitherNone, nil
+	case DitherFloydSteinberg:
+		return imgpipesteps.DitherFloydSteinberg, nil
+	case DitherAtkinson:
+		return imgpipesteps.DitherAtkinson, nil
+	case DitherBayer:
+		return imgpipesteps.DitherBayer, nil
+	}
+
+	return imgpipesteps.DitherNone, fmt.Errorf("unknown dithering `%s`", style)
+}
+
+func thresholdMethod(style ThresholdStyle) (imgutils.ThresholdMethod, error) {
+	switch style {
+	case ThresholdSauvola:
+		return imgutils.ThresholdSauvola, nil
+	case ThresholdNiblack:
+		return imgutils.ThresholdNiblack, nil
+	}
+
+	return imgutils.ThresholdSauvola, fmt.Errorf("unknown binarization `%s`", style)
+}
+
 func genPalette(level CropStyle, palette deviceprof.PaletteType) color.Palette {
 	switch level {
 	case CropBasic:
@@ -1,11 +1,14 @@
 package bootstrap
 
 import (
+	"image"
+	"image/color"
 	"testing"
 
 	"github.com/Jictyvoo/ink_stream/internal/imageparser"
 	"github.com/Jictyvoo/ink_stream/internal/imageparser/imgpipesteps"
 	"github.com/Jictyvoo/ink_stream/pkg/deviceprof"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
 )
 
 func isTypeOf[T imageparser.PipeStep]() func(inputVal any) bool {
@@ -20,6 +23,7 @@ func isTypeOf[T imageparser.PipeStep]() func(inputVal any) bool {
 }
 
 func TestBuildPipeline(t *testing.T) {
+	marginRatio := func(ratio float64) *float64 { return &ratio }
 	testCases := []struct {
 		name          string
 		opts          Options
@@ -75,6 +79,25 @@ func TestBuildPipeline(t *testing.T) {
 				ColoredPages:  true,
 			},
 			expectError: false,
+			expectedSteps: []func(inputVal any) bool{ // Only the kept spreads are wrapped
+				isTypeOf[*imgpipesteps.StepAutoCropImage](),
+				isTypeOf[*imgpipesteps.StepMarginWrapImage](),
+				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
+				isTypeOf[*imgpipesteps.StepRescaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
+			},
+		},
+		{
+			name: "Pipeline with margins disabled and no kept spreads",
+			opts: Options{
+				TargetDevice:   deviceprof.DeviceOther,
+				ReadDirection:  "ltr",
+				CropLevel:      CropNormal,
+				NoGutterPolicy: SpreadRotate,
+				AddMargins:     false,
+				ColoredPages:   true,
+			},
+			expectError: false,
 			expectedSteps: []func(inputVal any) bool{
 				isTypeOf[*imgpipesteps.StepAutoCropImage](),
 				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
@@ -160,6 +183,242 @@ func TestBuildPipeline(t *testing.T) {
 				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
 			},
 		},
+		{
+			name: "Pipeline with dithering",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				AddMargins:    true,
+				ColoredPages:  false,
+				Dithering:     DitherFloydSteinberg,
+			},
+			expectError: false,
+			expectedSteps: []func(inputVal any) bool{
+				isTypeOf[*imgpipesteps.StepGrayScaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoCropImage](),
+				isTypeOf[*imgpipesteps.StepMarginWrapImage](),
+				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
+				isTypeOf[*imgpipesteps.StepRescaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
+				isTypeOf[*imgpipesteps.StepDitherImage](),
+			},
+		},
+		{
+			name: "Pipeline with sharpening",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				RotateImage:   true,
+				AddMargins:    true,
+				ColoredPages:  true,
+				Sharpen:       SharpenOptions{Radius: 1, Amount: 0.5},
+			},
+			expectError: false,
+			expectedSteps: []func(inputVal any) bool{
+				isTypeOf[*imgpipesteps.StepAutoCropImage](),
+				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
+				isTypeOf[*imgpipesteps.StepMarginWrapImage](),
+				isTypeOf[*imgpipesteps.StepRescaleImage](),
+				isTypeOf[*imgpipesteps.StepUnsharpMaskImage](),
+				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
+			},
+		},
+		{
+			name: "Pipeline with deskew and grayscale",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				AddMargins:    true,
+				ColoredPages:  false,
+				Deskew:        true,
+			},
+			expectError: false,
+			expectedSteps: []func(inputVal any) bool{
+				isTypeOf[*imgpipesteps.StepGrayScaleImage](),
+				isTypeOf[*imgpipesteps.StepDeskewImage](),
+				isTypeOf[*imgpipesteps.StepAutoCropImage](),
+				isTypeOf[*imgpipesteps.StepMarginWrapImage](),
+				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
+				isTypeOf[*imgpipesteps.StepRescaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
+			},
+		},
+		{
+			name: "Pipeline dropping blank pages",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				AddMargins:    true,
+				ColoredPages:  false,
+				DropBlank:     BlankPageOptions{MaxInkRatio: 0.005, KeepFirst: 1},
+			},
+			expectError: false,
+			expectedSteps: []func(inputVal any) bool{
+				isTypeOf[*imgpipesteps.StepDropBlankImage](),
+				isTypeOf[*imgpipesteps.StepGrayScaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoCropImage](),
+				isTypeOf[*imgpipesteps.StepMarginWrapImage](),
+				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
+				isTypeOf[*imgpipesteps.StepRescaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
+			},
+		},
+		{
+			name: "Pipeline binarizing the text pages",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				AddMargins:    true,
+				ColoredPages:  false,
+				Binarize:      BinarizeOptions{Method: ThresholdSauvola, Window: 25},
+				Dithering:     DitherAtkinson,
+			},
+			expectError: false,
+			expectedSteps: []func(inputVal any) bool{
+				isTypeOf[*imgpipesteps.StepGrayScaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoCropImage](),
+				isTypeOf[*imgpipesteps.StepMarginWrapImage](),
+				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
+				isTypeOf[*imgpipesteps.StepRescaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
+				isTypeOf[*imgpipesteps.StepBinarizeImage](),
+				isTypeOf[*imgpipesteps.StepDitherImage](),
+			},
+		},
+		{
+			name: "Pipeline with rotate and split spreads",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				SpreadPolicy:  SpreadRotateSplit,
+				AddMargins:    true,
+				ColoredPages:  true,
+			},
+			expectError: false,
+			expectedSteps: []func(inputVal any) bool{
+				isTypeOf[*imgpipesteps.StepAutoCropImage](),
+				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
+				isTypeOf[*imgpipesteps.StepMarginWrapImage](),
+				isTypeOf[*imgpipesteps.StepRescaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
+			},
+		},
+		{
+			name: "Pipeline with spread policy over rotation",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				RotateImage:   true,
+				SpreadPolicy:  SpreadSplit,
+				AddMargins:    true,
+				ColoredPages:  true,
+			},
+			expectError: false,
+			expectedSteps: []func(inputVal any) bool{
+				isTypeOf[*imgpipesteps.StepAutoCropImage](),
+				isTypeOf[*imgpipesteps.StepMarginWrapImage](),
+				isTypeOf[*imgpipesteps.StepCropOrRotateImage](),
+				isTypeOf[*imgpipesteps.StepRescaleImage](),
+				isTypeOf[*imgpipesteps.StepAutoContrastImage](),
+			},
+		},
+		{
+			name: "Pipeline with invalid spread policy",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				SpreadPolicy:  "unknown",
+			},
+			expectError: true,
+		},
+		{
+			name: "Pipeline splitting spreads without gutter",
+			opts: Options{
+				TargetDevice:   deviceprof.DeviceOther,
+				ReadDirection:  "ltr",
+				CropLevel:      CropNormal,
+				NoGutterPolicy: SpreadRotateSplit,
+			},
+			expectError: true,
+		},
+		{
+			name: "Pipeline with invalid no gutter policy",
+			opts: Options{
+				TargetDevice:   deviceprof.DeviceOther,
+				ReadDirection:  "ltr",
+				CropLevel:      CropNormal,
+				NoGutterPolicy: "unknown",
+			},
+			expectError: true,
+		},
+		{
+			name: "Pipeline with invalid resampling",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				Resampling:    "unknown",
+			},
+			expectError: true,
+		},
+		{
+			name: "Pipeline with invalid fit mode",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				FitMode:       "unknown",
+			},
+			expectError: true,
+		},
+		{
+			name: "Pipeline with invalid autocrop options",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				AutoCrop:      AutoCropOptions{MinRetainedSize: 1.5},
+			},
+			expectError: true,
+		},
+		{
+			name: "Pipeline with invalid autocrop margin",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				AutoCrop:      AutoCropOptions{MarginRatio: marginRatio(0.5)},
+			},
+			expectError: true,
+		},
+		{
+			name: "Pipeline with invalid binarization",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				Binarize:      BinarizeOptions{Method: "unknown"},
+			},
+			expectError: true,
+		},
+		{
+			name: "Pipeline with invalid dithering",
+			opts: Options{
+				TargetDevice:  deviceprof.DeviceOther,
+				ReadDirection: "ltr",
+				CropLevel:     CropNormal,
+				Dithering:     "unknown",
+			},
+			expectError: true,
+		},
 		{
 			name: "Pipeline with invalid device",
 			opts: Options{
@@ -219,3 +478,154 @@ func TestBuildPipeline(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildPipeline_Dithering(t *testing.T) {
+	// The gradient has more grays than the device palette, which bands without dithering
+	gradient := image.NewGray(image.Rect(0, 0, 1072, 1448))
+	for x, y := range imgutils.Iterator(gradient) {
+		gradient.SetGray(x, y, color.Gray{Y: uint8(x * 0xFF / 1071)})
+	}
+	processPage := func(t *testing.T, dithering DitherStyle) image.Image {
+		t.Helper()
+		pipeline, err := BuildPipeline(Options{
+			TargetDevice:  deviceprof.DeviceKindlePaperwhite5_SignatureEdition,
+			ReadDirection: "ltr",
+			CropLevel:     CropNormal,
+			Dithering:     dithering,
+		})
+		if err != nil {
+			t.Fatalf("failed to build pipeline: %v", err)
+		}
+		images, err := pipeline.Process(gradient)
+		if err != nil || len(images) != 1 {
+			t.Fatalf("failed to process page: %v, got %d images", err, len(images))
+		}
+		return images[0]
+	}
+
+	undithered := processPage(t, DitherNone)
+	for _, dithering := range []DitherStyle{DitherFloydSteinberg, DitherAtkinson, DitherBayer} {
+		t.Run(string(dithering), func(t *testing.T) {
+			t.Parallel()
+			dithered := processPage(t, dithering)
+			if dithered.Bounds() != undithered.Bounds() {
+				t.Fatalf("expected bounds %v, got %v", undithered.Bounds(), dithered.Bounds())
+			}
+
+			var changedPixels int
+			for x, y := range imgutils.Iterator(dithered) {
+				r, _, _, _ := dithered.At(x, y).RGBA()
+				if (r>>8)%0x11 != 0 { // The device palette has the grays multiple of 0x11
+					t.Fatalf("pixel (%d, %d) = %#x is not on the device palette", x, y, r>>8)
+				}
+				if expected, _, _, _ := undithered.At(x, y).RGBA(); r != expected {
+					changedPixels++
+				}
+			}
+			if changedPixels == 0 {
+				t.Errorf("expected the dithered page to differ from the undithered one")
+			}
+		})
+	}
+}
+
+func TestBuildPipeline_PixelArt(t *testing.T) {
+	// The pixel art has a few flat colors, lost by the grayscale conversion before the rescale
+	pixelArt := image.NewRGBA(image.Rect(0, 0, 24, 32))
+	colors := []color.RGBA{
+		{R: 0xd0, G: 0x30, B: 0x30, A: 0xff},
+		{R: 0x30, G: 0xa0, B: 0x40, A: 0xff},
+		{R: 0x20, G: 0x40, B: 0xc0, A: 0xff},
+		{R: 0xf0, G: 0xe0, B: 0x60, A: 0xff},
+	}
+	for x, y := range imgutils.Iterator(pixelArt) {
+		pixelArt.SetRGBA(x, y, colors[(x/3+y/4)%len(colors)])
+	}
+	processPage := func(t *testing.T, resampling ResampleStyle) image.Image {
+		t.Helper()
+		pipeline, err := BuildPipeline(Options{
+			TargetDevice:  deviceprof.DeviceKindlePaperwhite5_SignatureEdition,
+			ReadDirection: "ltr",
+			CropLevel:     CropNormal,
+			Resampling:    resampling,
+		})
+		if err != nil {
+			t.Fatalf("failed to build pipeline: %v", err)
+		}
+		images, err := pipeline.Process(pixelArt)
+		if err != nil || len(images) != 1 {
+			t.Fatalf("failed to process page: %v, got %d images", err, len(images))
+		}
+		return images[0]
+	}
+
+	auto, nearest := processPage(t, ""), processPage(t, ResampleNearest)
+	if !imgutils.IsImageEqual(auto, nearest) {
+		t.Errorf("expected the automatic resampling to scale the pixel art as nearest neighbor")
+	}
+}
+
+func TestBuildPipeline_KeptSpread(t *testing.T) {
+	// The checkerboard art crosses the gutter, so the spread is kept whole
+	spread := image.NewGray(image.Rect(0, 0, 1000, 500))
+	for x, y := range imgutils.Iterator(spread) {
+		if (x/25+y/25)%2 == 0 {
+			spread.SetGray(x, y, color.Gray{Y: 0xFF})
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "default split layout", opts: Options{}},
+		{name: "keep policy", opts: Options{SpreadPolicy: SpreadKeep}},
+		{
+			name: "keep policy with fit",
+			opts: Options{SpreadPolicy: SpreadKeep, FitMode: FitLetterbox},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.opts.TargetDevice = deviceprof.DeviceKindlePaperwhite5_SignatureEdition
+			tt.opts.ReadDirection = "ltr"
+			tt.opts.CropLevel = CropNormal
+			if tt.opts.FitMode == "" {
+				tt.opts.FitMode = FitStretch
+			}
+			pipeline, err := BuildPipeline(tt.opts)
+			if err != nil {
+				t.Fatalf("failed to build pipeline: %v", err)
+			}
+			images, err := pipeline.Process(spread)
+			if err != nil || len(images) != 1 {
+				t.Fatalf("failed to process spread: %v, got %d images", err, len(images))
+			}
+
+			// The art rows have both the black and the white squares, the margins have one color
+			var (
+				page    = images[0]
+				artRows int
+			)
+			for y := page.Bounds().Min.Y; y < page.Bounds().Max.Y; y++ {
+				minValue, maxValue := uint32(0xFFFF), uint32(0)
+				for x := page.Bounds().Min.X; x < page.Bounds().Max.X; x++ {
+					value, _, _, _ := page.At(x, y).RGBA()
+					minValue, maxValue = min(minValue, value), max(maxValue, value)
+				}
+				if maxValue-minValue > 0x8000 {
+					artRows++
+				}
+			}
+			aspect := float64(page.Bounds().Dx()) / float64(artRows)
+			if aspect < 1.95 || aspect > 2.05 {
+				t.Errorf(
+					"expected the spread to keep its 2:1 aspect, got %dx%d art on a %v page",
+					page.Bounds().Dx(), artRows, page.Bounds(),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package bootstrap
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/Jictyvoo/ink_stream/internal/services/filextract"
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils"
+)
+
+const (
+	// DefaultExcludeGlob drops the scanlator credit pages, which are usually named `credits`
+	DefaultExcludeGlob = "cred*"
+	// defaultMaxHashDistance is the hash distance under which a page is taken as a copy
+	// of a reference image, which survives the re-encoding and rescaling by scanlators.
+	defaultMaxHashDistance = 8
+)
+
+// ExclusionOptions tells which pages extracted from the books are dropped.
+type ExclusionOptions struct {
+	// Include globs, when not empty, only the matching pages are kept
+	Include []string
+	// Exclude globs drop the matching pages, like `cred*`
+	Exclude []string
+	// ReferenceImages are files of known credit or recruitment pages, the pages
+	// looking alike are dropped
+	ReferenceImages []string
+	// MaxHashDistance is the largest perceptual hash distance to a reference image,
+	// nil uses a default distance and zero only drops the exact copies
+	MaxHashDistance *int
+	// DropDuplicates keeps only the first occurrence, in name order, of the pages repeated
+	// on a book
+	DropDuplicates bool
+}
+
+// ExclusionRules loads the reference images and builds the rules applied by the extractor.
+func (opts ExclusionOptions) ExclusionRules() (filextract.ExclusionRules, error) {
+	rules := filextract.ExclusionRules{
+		Include:         opts.Include,
+		Exclude:         opts.Exclude,
+		ReferenceHashes: make([]imgutils.ImageHash, 0, len(opts.ReferenceImages)),
+		MaxHashDistance: defaultMaxHashDistance,
+		DropDuplicates:  opts.DropDuplicates,
+	}
+	if opts.MaxHashDistance != nil {
+		rules.MaxHashDistance = *opts.MaxHashDistance
+	}
+	if rules.MaxHashDistance < 0 || rules.MaxHashDistance > 64 {
+		return rules, fmt.Errorf(
+			"hash distance must be between 0 and 64, got %d", rules.MaxHashDistance,
+		)
+	}
+	if err := rules.Validate(); err != nil {
+		return rules, err
+	}
+
+	for _, imagePath := range opts.ReferenceImages {
+		img, err := loadImage(imagePath)
+		if err != nil {
+			return rules, fmt.Errorf("failed to load reference image `%s`: %w", imagePath, err)
+		}
+		rules.ReferenceHashes = append(rules.ReferenceHashes, imgutils.PerceptualHash(img))
+	}
+
+	return rules, nil
+}
+
+func loadImage(imagePath string) (img image.Image, err error) {
+	var file *os.File
+	if file, err = os.Open(imagePath); err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err = image.Decode(file)
+	return img, err
+}
@@ -0,0 +1,49 @@
+package bootstrap
+
+import "testing"
+
+func TestExclusionOptions_ExclusionRules(t *testing.T) {
+	distance := func(value int) *int { return &value }
+	tests := []struct {
+		name             string
+		opts             ExclusionOptions
+		expectedDistance int
+		expectError      bool
+	}{
+		{name: "default distance", opts: ExclusionOptions{}, expectedDistance: 8},
+		{
+			name:             "exact copies only",
+			opts:             ExclusionOptions{MaxHashDistance: distance(0)},
+			expectedDistance: 0,
+		},
+		{
+			name:             "custom distance",
+			opts:             ExclusionOptions{MaxHashDistance: distance(12)},
+			expectedDistance: 12,
+		},
+		{
+			name:        "distance out of range",
+			opts:        ExclusionOptions{MaxHashDistance: distance(65)},
+			expectError: true,
+		},
+		{
+			name:        "invalid glob",
+			opts:        ExclusionOptions{Exclude: []string{DefaultExcludeGlob, "[cred"}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := tt.opts.ExclusionRules()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("ExclusionRules() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && rules.MaxHashDistance != tt.expectedDistance {
+				t.Errorf(
+					"expected hash distance %d, got %d", tt.expectedDistance, rules.MaxHashDistance,
+				)
+			}
+		})
+	}
+}
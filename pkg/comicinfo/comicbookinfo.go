@@ -0,0 +1,85 @@
+package comicinfo
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// comicBookInfoKey is the key holding the metadata on the ComicBookInfo zip comment.
+const comicBookInfoKey = "ComicBookInfo/1.0"
+
+type (
+	Credit struct {
+		Person  string `json:"person"`
+		Role    string `json:"role"`
+		Primary bool   `json:"primary,omitempty"`
+	}
+	// ComicBookInfo holds the subset of the ComicBookInfo schema, stored as JSON on the
+	// archive comment, used by ink_stream.
+	ComicBookInfo struct {
+		Series   string `json:"series,omitempty"`
+		Title    string `json:"title,omitempty"`
+		Issue    any    `json:"issue,omitempty"` // Either a number or a string
+		Language string `json:"language,omitempty"`
+		Comments string `json:"comments,omitempty"`
+		// Publisher and the publication date fields
+		Publisher        string   `json:"publisher,omitempty"`
+		PublicationYear  int      `json:"publicationYear,omitempty"`
+		PublicationMonth int      `json:"publicationMonth,omitempty"`
+		Credits          []Credit `json:"credits,omitempty"`
+	}
+)
+
+// DecodeComicBookInfo reads the ComicBookInfo from the archive comment.
+// It reports false when the comment does not hold a ComicBookInfo.
+func DecodeComicBookInfo(comment string) (ComicBookInfo, bool) {
+	comment = strings.TrimSpace(comment)
+	if !strings.HasPrefix(comment, "{") {
+		return ComicBookInfo{}, false
+	}
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(comment), &envelope); err != nil {
+		return ComicBookInfo{}, false
+	}
+	rawInfo, found := envelope[comicBookInfoKey]
+	if !found {
+		return ComicBookInfo{}, false
+	}
+
+	var info ComicBookInfo
+	if err := json.Unmarshal(rawInfo, &info); err != nil {
+		return ComicBookInfo{}, false
+	}
+	return info, true
+}
+
+// BookMetadata converts the ComicBookInfo into the book metadata.
+func (cbi ComicBookInfo) BookMetadata() inktypes.BookMetadata {
+	metadata := inktypes.BookMetadata{
+		Title:     strings.TrimSpace(cbi.Title),
+		Series:    strings.TrimSpace(cbi.Series),
+		Language:  strings.TrimSpace(cbi.Language),
+		Summary:   strings.TrimSpace(cbi.Comments),
+		Publisher: strings.TrimSpace(cbi.Publisher),
+		PublishDate: ComicInfo{
+			Year: cbi.PublicationYear, Month: cbi.PublicationMonth,
+		}.PublishDate(),
+	}
+	switch issue := cbi.Issue.(type) {
+	case float64:
+		metadata.Number = strconv.FormatFloat(issue, 'f', -1, 64)
+	case string:
+		metadata.Number = strings.TrimSpace(issue)
+	}
+	for _, credit := range cbi.Credits {
+		if strings.EqualFold(credit.Role, "Writer") && credit.Person != "" {
+			metadata.Writers = append(metadata.Writers, strings.TrimSpace(credit.Person))
+		}
+	}
+
+	return metadata
+}
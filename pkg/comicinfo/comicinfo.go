@@ -0,0 +1,174 @@
+package comicinfo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// FileName is the name expected by comic readers for the metadata file inside the archive.
+const FileName = "ComicInfo.xml"
+
+type MangaType string
+
+const (
+	MangaUnknown           MangaType = "Unknown"
+	MangaNo                MangaType = "No"
+	MangaYes               MangaType = "Yes"
+	MangaYesAndRightToLeft MangaType = "YesAndRightToLeft"
+)
+
+type PageType = inktypes.PageType
+
+const (
+	PageFrontCover = inktypes.PageFrontCover
+	PageStory      = inktypes.PageStory
+	PageDeleted    = inktypes.PageDeleted
+)
+
+type (
+	Page struct {
+		Image       int      `xml:"Image,attr"`
+		Type        PageType `xml:"Type,attr,omitempty"`
+		ImageSize   int64    `xml:"ImageSize,attr,omitempty"`
+		ImageWidth  int      `xml:"ImageWidth,attr,omitempty"`
+		ImageHeight int      `xml:"ImageHeight,attr,omitempty"`
+		Bookmark    string   `xml:"Bookmark,attr,omitempty"`
+	}
+	// ComicInfo holds the subset of the ComicRack schema used by ink_stream.
+	ComicInfo struct {
+		XMLName     xml.Name  `xml:"ComicInfo"`
+		Title       string    `xml:"Title,omitempty"`
+		Series      string    `xml:"Series,omitempty"`
+		Number      string    `xml:"Number,omitempty"`
+		Summary     string    `xml:"Summary,omitempty"`
+		Year        int       `xml:"Year,omitempty"`
+		Month       int       `xml:"Month,omitempty"`
+		Day         int       `xml:"Day,omitempty"`
+		Writer      string    `xml:"Writer,omitempty"`
+		Publisher   string    `xml:"Publisher,omitempty"`
+		LanguageISO string    `xml:"LanguageISO,omitempty"`
+		PageCount   int       `xml:"PageCount,omitempty"`
+		Manga       MangaType `xml:"Manga,omitempty"`
+		Pages       []Page    `xml:"Pages>Page,omitempty"`
+	}
+)
+
+// NewMangaType converts the read direction into the equivalent Manga field value.
+func NewMangaType(direction inktypes.ReadDirection) MangaType {
+	switch direction {
+	case inktypes.ReadRightToLeft:
+		return MangaYesAndRightToLeft
+	case inktypes.ReadLeftToRight, inktypes.ReadVertical:
+		return MangaNo
+	}
+
+	return MangaUnknown
+}
+
+// ReadDirection returns the read direction described by the Manga field. A plain Yes only
+// tells the book is a manga, not its direction, so it's unknown.
+func (mt MangaType) ReadDirection() inktypes.ReadDirection {
+	switch mt {
+	case MangaYesAndRightToLeft:
+		return inktypes.ReadRightToLeft
+	case MangaNo:
+		return inktypes.ReadLeftToRight
+	}
+
+	return inktypes.ReadUnknown
+}
+
+// PublishDate returns the Year, Month and Day fields as an ISO 8601 date,
+// omitting the fields that are not set.
+func (ci ComicInfo) PublishDate() string {
+	if ci.Year <= 0 {
+		return ""
+	}
+
+	date := fmt.Sprintf("%04d", ci.Year)
+	if ci.Month > 0 {
+		date += fmt.Sprintf("-%02d", ci.Month)
+		if ci.Day > 0 {
+			date += fmt.Sprintf("-%02d", ci.Day)
+		}
+	}
+	return date
+}
+
+// SetPublishDate fills the Year, Month and Day fields from an ISO 8601 date,
+// which may be partial. Parsing stops at the first invalid part.
+func (ci *ComicInfo) SetPublishDate(date string) {
+	ci.Year, ci.Month, ci.Day = 0, 0, 0
+	parts := strings.SplitN(date, "-", 3)
+	fields := []*int{&ci.Year, &ci.Month, &ci.Day}
+	for index, part := range parts {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value <= 0 {
+			return
+		}
+		*fields[index] = value
+	}
+}
+
+// BookMetadata converts the ComicInfo into the book metadata. The page indexes refer
+// to the images of the archive in the given order, as ComicRack does.
+func (ci ComicInfo) BookMetadata(imageNames []string) inktypes.BookMetadata {
+	metadata := inktypes.BookMetadata{
+		Title:         strings.TrimSpace(ci.Title),
+		Series:        strings.TrimSpace(ci.Series),
+		Number:        strings.TrimSpace(ci.Number),
+		Language:      strings.TrimSpace(ci.LanguageISO),
+		Summary:       strings.TrimSpace(ci.Summary),
+		Publisher:     strings.TrimSpace(ci.Publisher),
+		PublishDate:   ci.PublishDate(),
+		ReadDirection: ci.Manga.ReadDirection(),
+	}
+	for writer := range strings.SplitSeq(ci.Writer, ",") {
+		if writer = strings.TrimSpace(writer); writer != "" {
+			metadata.Writers = append(metadata.Writers, writer)
+		}
+	}
+
+	for _, page := range ci.Pages {
+		if page.Image < 0 || page.Image >= len(imageNames) {
+			continue
+		}
+		if page.Type == "" && page.Bookmark == "" {
+			continue
+		}
+		if metadata.Pages == nil {
+			metadata.Pages = make(map[string]inktypes.PageInfo, len(ci.Pages))
+		}
+		metadata.Pages[inktypes.PageKey(imageNames[page.Image])] = inktypes.PageInfo{
+			Type:     page.Type,
+			Bookmark: page.Bookmark,
+		}
+	}
+
+	return metadata
+}
+
+// Encode writes the ComicInfo as an indented XML document.
+func (ci ComicInfo) Encode(writer io.Writer) error {
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(ci); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Decode reads a ComicInfo XML document.
+func Decode(reader io.Reader) (ci ComicInfo, err error) {
+	err = xml.NewDecoder(reader).Decode(&ci)
+	return ci, err
+}
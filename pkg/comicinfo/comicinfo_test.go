@@ -0,0 +1,251 @@
+package comicinfo
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestNewMangaType(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction inktypes.ReadDirection
+		expected  MangaType
+	}{
+		{
+			name:      "right to left",
+			direction: inktypes.ReadRightToLeft,
+			expected:  MangaYesAndRightToLeft,
+		},
+		{name: "left to right", direction: inktypes.ReadLeftToRight, expected: MangaNo},
+		{name: "unknown", direction: inktypes.ReadUnknown, expected: MangaUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewMangaType(tt.direction)
+			if result != tt.expected {
+				t.Errorf("NewMangaType(%v) = %v, want %v", tt.direction, result, tt.expected)
+			}
+			if tt.direction != inktypes.ReadUnknown && result.ReadDirection() != tt.direction {
+				t.Errorf("ReadDirection() = %v, want %v", result.ReadDirection(), tt.direction)
+			}
+		})
+	}
+}
+
+func TestMangaType_ReadDirection(t *testing.T) {
+	tests := []struct {
+		name             string
+		info             ComicInfo
+		expected         inktypes.ReadDirection
+		expectedInferred inktypes.ReadDirection
+	}{
+		{
+			name:             "right to left manga",
+			info:             ComicInfo{Manga: MangaYesAndRightToLeft, LanguageISO: "en"},
+			expected:         inktypes.ReadRightToLeft,
+			expectedInferred: inktypes.ReadRightToLeft,
+		},
+		{
+			name:             "not a manga",
+			info:             ComicInfo{Manga: MangaNo, LanguageISO: "ja"},
+			expected:         inktypes.ReadLeftToRight,
+			expectedInferred: inktypes.ReadLeftToRight,
+		},
+		{
+			name:             "manga direction comes from the language",
+			info:             ComicInfo{Manga: MangaYes, LanguageISO: "ja"},
+			expected:         inktypes.ReadUnknown,
+			expectedInferred: inktypes.ReadRightToLeft,
+		},
+		{
+			name:             "manga without language",
+			info:             ComicInfo{Manga: MangaYes},
+			expected:         inktypes.ReadUnknown,
+			expectedInferred: inktypes.ReadUnknown,
+		},
+		{
+			name:             "unknown",
+			info:             ComicInfo{LanguageISO: "en"},
+			expected:         inktypes.ReadUnknown,
+			expectedInferred: inktypes.ReadUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.info.Manga.ReadDirection(); result != tt.expected {
+				t.Errorf("ReadDirection() = %v, want %v", result, tt.expected)
+			}
+			inferred := tt.info.BookMetadata(nil).InferReadDirection()
+			if inferred != tt.expectedInferred {
+				t.Errorf("InferReadDirection() = %v, want %v", inferred, tt.expectedInferred)
+			}
+		})
+	}
+}
+
+func TestComicInfo_EncodeDecode(t *testing.T) {
+	info := ComicInfo{
+		Title:     "Sample",
+		PageCount: 2,
+		Manga:     MangaYesAndRightToLeft,
+		Pages: []Page{
+			{Image: 0, Type: PageFrontCover, ImageWidth: 1072, ImageHeight: 1448},
+			{Image: 1, ImageSize: 2048, ImageWidth: 1072, ImageHeight: 1448},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := info.Encode(&buffer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buffer.String(), `<Page Image="0" Type="FrontCover"`) {
+		t.Errorf("cover page not found in output:\n%s", buffer.String())
+	}
+
+	decoded, err := Decode(&buffer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded.XMLName = info.XMLName
+	if !reflect.DeepEqual(decoded, info) {
+		t.Errorf("decoded = %+v, want %+v", decoded, info)
+	}
+}
+
+func TestComicInfo_BookMetadata(t *testing.T) {
+	info := ComicInfo{
+		Title:       " Title ",
+		Series:      "Series",
+		Number:      "3",
+		Writer:      "First Writer, Second Writer",
+		LanguageISO: "ja",
+		Manga:       MangaYesAndRightToLeft,
+		Pages: []Page{
+			{Image: 0, Type: PageFrontCover},
+			{Image: 1, Type: PageDeleted},
+			{Image: 2, Bookmark: "Chapter 1"},
+			{Image: 3},
+			{Image: 10, Type: PageStory}, // Out of range
+		},
+	}
+
+	expected := inktypes.BookMetadata{
+		Title:         "Title",
+		Series:        "Series",
+		Number:        "3",
+		Writers:       []string{"First Writer", "Second Writer"},
+		Language:      "ja",
+		ReadDirection: inktypes.ReadRightToLeft,
+		Pages: map[string]inktypes.PageInfo{
+			"001":        {Type: PageFrontCover},
+			"002":        {Type: PageDeleted},
+			"chapter/03": {Bookmark: "Chapter 1"},
+		},
+	}
+	metadata := info.BookMetadata([]string{"001.jpg", "002.jpg", "chapter/03.png", "004.jpg"})
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("BookMetadata() = %+v, want %+v", metadata, expected)
+	}
+}
+
+func TestDecodeComicBookInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		comment  string
+		found    bool
+		expected inktypes.BookMetadata
+	}{
+		{
+			name: "numeric issue",
+			comment: `{"appID":"ComicTagger/1.0","ComicBookInfo/1.0":{"series":"Series",` +
+				`"title":"Title","issue":7,"language":"English","comments":"Summary",` +
+				`"publisher":"Publisher","publicationYear":2020,"publicationMonth":5,` +
+				`"credits":[{"person":"Writer Name","role":"Writer","primary":true},` +
+				`{"person":"Artist Name","role":"Artist"}]}}`,
+			found: true,
+			expected: inktypes.BookMetadata{
+				Title:       "Title",
+				Series:      "Series",
+				Number:      "7",
+				Writers:     []string{"Writer Name"},
+				Language:    "English",
+				Summary:     "Summary",
+				Publisher:   "Publisher",
+				PublishDate: "2020-05",
+			},
+		},
+		{
+			name:     "text issue",
+			comment:  `{"ComicBookInfo/1.0":{"series":"Series","issue":"7a"}}`,
+			found:    true,
+			expected: inktypes.BookMetadata{Series: "Series", Number: "7a"},
+		},
+		{name: "plain comment", comment: "Created by some tool", found: false},
+		{name: "json without key", comment: `{"other":{}}`, found: false},
+		{name: "broken json", comment: `{"ComicBookInfo/1.0":`, found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, found := DecodeComicBookInfo(tt.comment)
+			if found != tt.found {
+				t.Fatalf("DecodeComicBookInfo() found = %v, want %v", found, tt.found)
+			}
+			if !found {
+				return
+			}
+			if metadata := info.BookMetadata(); !reflect.DeepEqual(metadata, tt.expected) {
+				t.Errorf("BookMetadata() = %+v, want %+v", metadata, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComicInfo_PublishDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     string
+		expected string
+		info     ComicInfo
+	}{
+		{
+			name:     "full date",
+			date:     "2021-03-09",
+			expected: "2021-03-09",
+			info:     ComicInfo{Year: 2021, Month: 3, Day: 9},
+		},
+		{
+			name:     "year and month",
+			date:     "2021-03",
+			expected: "2021-03",
+			info:     ComicInfo{Year: 2021, Month: 3},
+		},
+		{name: "year only", date: "2021", expected: "2021", info: ComicInfo{Year: 2021}},
+		{
+			name:     "timestamp",
+			date:     "2021-03-09T10:00:00",
+			expected: "2021-03",
+			info:     ComicInfo{Year: 2021, Month: 3},
+		},
+		{name: "invalid", date: "unknown", expected: "", info: ComicInfo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info ComicInfo
+			info.SetPublishDate(tt.date)
+			if !reflect.DeepEqual(info, tt.info) {
+				t.Errorf("SetPublishDate(%q) = %+v, want %+v", tt.date, info, tt.info)
+			}
+			if result := info.PublishDate(); result != tt.expected {
+				t.Errorf("PublishDate() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
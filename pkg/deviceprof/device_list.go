@@ -1,5 +1,7 @@
 package deviceprof
 
+import "strings"
+
 type DeviceType string
 
 //goland:noinspection GoSnakeCaseUsage
@@ -34,3 +36,29 @@ const (
 	DeviceKoboElipsa                         DeviceType = "KoE"
 	DeviceOther                              DeviceType = "OTHER"
 )
+
+// canonical returns the declared DeviceType matching the name, ignoring its case.
+func (dt DeviceType) canonical() DeviceType {
+	if _, found := defaultProfiles[dt]; found {
+		return dt
+	}
+	for key := range defaultProfiles {
+		if strings.EqualFold(string(key), string(dt)) {
+			return key
+		}
+	}
+	return dt
+}
+
+// IsKindle reports whether the device is one of the Amazon Kindle readers.
+func (dt DeviceType) IsKindle() bool {
+	switch dt.canonical() {
+	case DeviceKindle1, DeviceKindle11, DeviceKindle2, DeviceKindleKeyboardTouch,
+		DeviceKindle, DeviceKindleDXDXG, DeviceKindlePaperwhite1_2,
+		DeviceKindlePaperwhite3_4_Voyage_Oasis, DeviceKindlePaperwhite5_SignatureEdition,
+		DeviceKindlePaperwhite6, DeviceKindleColorsoft, DeviceKindleOasis_2_3,
+		DeviceKindleScribe:
+		return true
+	}
+	return false
+}
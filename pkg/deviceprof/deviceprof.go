@@ -1,10 +1,6 @@
 package deviceprof
 
-import (
-	"strings"
-
-	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
-)
+import "github.com/Jictyvoo/ink_stream/pkg/inktypes"
 
 type (
 	Resolution    = inktypes.ImageDimensions
@@ -79,13 +75,6 @@ var defaultProfiles = map[DeviceType]DeviceProfile{
 }
 
 func Profile(name DeviceType) (DeviceProfile, bool) {
-	prof, found := defaultProfiles[name]
-	if !found {
-		for key, dProf := range defaultProfiles {
-			if strings.EqualFold(string(key), string(name)) {
-				return dProf, true
-			}
-		}
-	}
+	prof, found := defaultProfiles[name.canonical()]
 	return prof, found
 }
@@ -0,0 +1,86 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// ThresholdMethod is the formula of the local threshold used on the adaptive binarization.
+type ThresholdMethod uint8
+
+const (
+	// ThresholdSauvola lowers the threshold on the flat regions, keeping the paper noise out:
+	// T = mean * (1 + k * (deviation/128 - 1)). Usual k values are between 0.2 and 0.5.
+	ThresholdSauvola ThresholdMethod = iota
+	// ThresholdNiblack follows the local contrast: T = mean + k * deviation.
+	// Usual k values are between -0.2 and -0.1.
+	ThresholdNiblack
+)
+
+// sauvolaDynamicRange is the dynamic range of the standard deviation on the Sauvola formula
+const sauvolaDynamicRange = 128
+
+// AdaptiveThreshold binarizes the image with a threshold computed for each pixel, from
+// the luminance mean and standard deviation of the window around it. Unlike a global
+// threshold, it keeps the text legible over the uneven paper and lighting of the scans.
+// The pixels from the threshold up are white, and the others black.
+func AdaptiveThreshold(
+	img image.Image, method ThresholdMethod, window int, k float64,
+) *image.Gray {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	binarized := image.NewGray(bounds)
+	if width == 0 || height == 0 {
+		return binarized
+	}
+
+	// Integral images of the luminance and its square, giving any window sum in constant time
+	var (
+		stride     = width + 1
+		sums       = make([]float64, stride*(height+1))
+		squareSums = make([]float64, stride*(height+1))
+		luminances = make([]uint8, width*height)
+	)
+	for y := range height {
+		var rowSum, rowSquareSum float64
+		for x := range width {
+			pixel := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			luminance := color.GrayModel.Convert(pixel).(color.Gray).Y
+			luminances[y*width+x] = luminance
+			rowSum += float64(luminance)
+			rowSquareSum += float64(luminance) * float64(luminance)
+			sums[(y+1)*stride+x+1] = sums[y*stride+x+1] + rowSum
+			squareSums[(y+1)*stride+x+1] = squareSums[y*stride+x+1] + rowSquareSum
+		}
+	}
+	windowSum := func(integral []float64, region image.Rectangle) float64 {
+		return integral[region.Max.Y*stride+region.Max.X] -
+			integral[region.Min.Y*stride+region.Max.X] -
+			integral[region.Max.Y*stride+region.Min.X] +
+			integral[region.Min.Y*stride+region.Min.X]
+	}
+
+	halfWindow := max(window/2, 1)
+	for y := range height {
+		for x := range width {
+			region := image.Rect(
+				max(x-halfWindow, 0), max(y-halfWindow, 0),
+				min(x+halfWindow+1, width), min(y+halfWindow+1, height),
+			)
+			area := float64(region.Dx() * region.Dy())
+			mean := windowSum(sums, region) / area
+			deviation := math.Sqrt(max(windowSum(squareSums, region)/area-mean*mean, 0))
+
+			threshold := mean + k*deviation
+			if method == ThresholdSauvola {
+				threshold = mean * (1 + k*(deviation/sauvolaDynamicRange-1))
+			}
+			if float64(luminances[y*width+x]) >= threshold {
+				binarized.Pix[y*binarized.Stride+x] = MaxPixelValue
+			}
+		}
+	}
+
+	return binarized
+}
@@ -0,0 +1,66 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// newTextPage draws lines of glyph strokes on a white page, the strokes are darker than
+// the local paper by the given contrast, and the paper luminance follows paperAt.
+func newTextPage(width, height int, contrast uint8, paperAt func(x int) uint8) (
+	page *image.Gray, strokes *image.Gray,
+) {
+	page = image.NewGray(image.Rect(0, 0, width, height))
+	strokes = image.NewGray(page.Bounds())
+	for x, y := range Iterator(page) {
+		paper := paperAt(x)
+		isStroke := y%16 >= 3 && y%16 < 13 && x%6 < 2 && x >= 4 && x < width-4
+		page.SetGray(x, y, color.Gray{Y: paper})
+		strokes.SetGray(x, y, color.Gray{Y: MaxPixelValue})
+		if isStroke {
+			page.SetGray(x, y, color.Gray{Y: paper - contrast})
+			strokes.SetGray(x, y, color.Gray{})
+		}
+	}
+	return page, strokes
+}
+
+func TestAdaptiveThreshold(t *testing.T) {
+	// The paper gets darker to the left, so no global threshold separates the text
+	unevenPaper := func(x int) uint8 { return uint8(0x60 + x*0x90/200) }
+	page, strokes := newTextPage(200, 96, 0x50, unevenPaper)
+
+	testCases := []struct {
+		name   string
+		method ThresholdMethod
+		k      float64
+	}{
+		{name: "Sauvola", method: ThresholdSauvola, k: 0.34},
+		{name: "Niblack", method: ThresholdNiblack, k: -0.2},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			binarized := AdaptiveThreshold(page, tCase.method, 15, tCase.k)
+			if binarized.Bounds() != page.Bounds() {
+				t.Fatalf("expected bounds %v, got %v", page.Bounds(), binarized.Bounds())
+			}
+			for x, y := range Iterator(binarized) {
+				if binarized.GrayAt(x, y) != strokes.GrayAt(x, y) {
+					t.Fatalf(
+						"pixel (%d, %d): expected %v, got %v",
+						x, y, strokes.GrayAt(x, y), binarized.GrayAt(x, y),
+					)
+				}
+			}
+		})
+	}
+
+	t.Run("Empty image", func(t *testing.T) {
+		binarized := AdaptiveThreshold(image.NewGray(image.Rectangle{}), ThresholdSauvola, 15, 0.34)
+		if !binarized.Bounds().Empty() {
+			t.Errorf("expected empty image, got %v", binarized.Bounds())
+		}
+	})
+}
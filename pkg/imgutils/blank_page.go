@@ -0,0 +1,50 @@
+package imgutils
+
+import "image"
+
+// BlankInkTolerance is the distance to the paper color under which a pixel is still
+// taken as paper, which ignores the scan noise and the show-through of the other side.
+const BlankInkTolerance = 0x40
+
+// InkRatio returns the fraction of the pixels whose color is farther than the tolerance
+// from the paper color, which is the most frequent value on each channel.
+// The ratio of the channel with most ink is returned.
+func (histogram ImageHistogram) InkRatio(tolerance uint8) float64 {
+	var inkRatio float64
+	for _, channel := range histogram.data {
+		var (
+			paperLevel             int
+			totalPixels, inkPixels uint64
+		)
+		for level, count := range channel {
+			totalPixels += uint64(count)
+			if count > channel[paperLevel] {
+				paperLevel = level
+			}
+		}
+		if totalPixels == 0 {
+			continue
+		}
+
+		for level, count := range channel {
+			if distance := level - paperLevel; max(distance, -distance) > int(tolerance) {
+				inkPixels += uint64(count)
+			}
+		}
+		inkRatio = max(inkRatio, float64(inkPixels)/float64(totalPixels))
+	}
+
+	return inkRatio
+}
+
+// IsBlankPage tells if the page is blank, or nearly blank, having at most the given ratio
+// of ink pixels. Pages of a single dark color are also blank, as the paper color is the
+// most frequent one.
+func IsBlankPage(img image.Image, maxInkRatio float64) bool {
+	if img.Bounds().Empty() {
+		return true
+	}
+
+	histogram := CalculateHistogram(img)
+	return histogram.InkRatio(BlankInkTolerance) <= maxInkRatio
+}
@@ -0,0 +1,76 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+)
+
+func TestIsBlankPage(t *testing.T) {
+	// pageWithInk draws a white page with dark ink on the given region
+	pageWithInk := func(ink image.Rectangle, inkColor color.Color) image.Image {
+		img := image.NewGray(image.Rect(0, 0, 100, 100))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(img, ink, image.NewUniform(inkColor), image.Point{}, draw.Src)
+		return img
+	}
+
+	testCases := []struct {
+		name          string
+		img           image.Image
+		maxInkRatio   float64
+		expectedRatio float64
+		expected      bool
+	}{
+		{
+			name:          "White page",
+			img:           testimgs.NewSolidImage(image.Rect(0, 0, 50, 50), color.White),
+			maxInkRatio:   0.005,
+			expectedRatio: 0,
+			expected:      true,
+		},
+		{
+			name:          "Black separator page",
+			img:           testimgs.NewSolidImage(image.Rect(0, 0, 50, 50), color.Black),
+			maxInkRatio:   0.005,
+			expectedRatio: 0,
+			expected:      true,
+		},
+		{
+			name:          "Page with faint show-through",
+			img:           pageWithInk(image.Rect(10, 10, 90, 90), color.Gray{Y: 0xd0}),
+			maxInkRatio:   0.005,
+			expectedRatio: 0,
+			expected:      true,
+		},
+		{
+			name:          "Page with a small page number",
+			img:           pageWithInk(image.Rect(48, 94, 52, 98), color.Black),
+			maxInkRatio:   0.005,
+			expectedRatio: 0.0016,
+			expected:      true,
+		},
+		{
+			name:          "Page with a drawing",
+			img:           pageWithInk(image.Rect(20, 20, 40, 60), color.Black),
+			maxInkRatio:   0.005,
+			expectedRatio: 0.08,
+			expected:      false,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			histogram := CalculateHistogram(tCase.img)
+			if ratio := histogram.InkRatio(BlankInkTolerance); ratio != tCase.expectedRatio {
+				t.Errorf("expected ink ratio %v, got %v", tCase.expectedRatio, ratio)
+			}
+			if result := IsBlankPage(tCase.img, tCase.maxInkRatio); result != tCase.expected {
+				t.Errorf("expected blank %v, got %v", tCase.expected, result)
+			}
+		})
+	}
+}
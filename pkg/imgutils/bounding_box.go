@@ -16,6 +16,9 @@ func (bo BoxOptions) Has(opt BoxOptions) bool {
 const (
 	BoxEliminateTransparent BoxOptions = 1 << iota
 	BoxEliminateMinimumColor
+	// BoxIgnoreEdgeBlobs ignores, with BoxEliminateMinimumColor, the small blobs isolated
+	// near the page edges, like the page numbers and the scan specks.
+	BoxIgnoreEdgeBlobs
 )
 
 // CropBox calculates a bounding box for the given image by analyzing transparent pixels or minimum color values.
@@ -24,6 +27,9 @@ func CropBox(img image.Image, colorConverter ColorConverter, opts BoxOptions) im
 	if colorConverter == nil {
 		colorConverter = color.GrayModel
 	}
+	if opts.Has(BoxEliminateMinimumColor | BoxIgnoreEdgeBlobs) {
+		return artBox(img, colorConverter)
+	}
 
 	// Initialize bounding box and image dimensions.
 	var (
@@ -60,9 +66,7 @@ func CropBox(img image.Image, colorConverter ColorConverter, opts BoxOptions) im
 
 		// Update minimum color analysis if enabled.
 		if opts.Has(BoxEliminateMinimumColor) {
-			convertedPixel := colorConverter.Convert(pixel)
-			r, g, b, _ := convertedPixel.RGBA()
-			pixelValue := (uint64(r) << 8) | uint64(g) | uint64(b>>8)
+			pixelValue := boxPixelValue(colorConverter, pixel)
 
 			// Track rows and columns matching the current "white" value.
 			if whiteValue == 0 || pixelValue >= whiteValue {
@@ -84,6 +88,13 @@ func CropBox(img image.Image, colorConverter ColorConverter, opts BoxOptions) im
 	return bbox
 }
 
+// boxPixelValue returns the value of the pixel compared against the paper color, which is
+// the highest value found on the page.
+func boxPixelValue(colorConverter ColorConverter, pixel color.Color) uint64 {
+	r, g, b, _ := colorConverter.Convert(pixel).RGBA()
+	return (uint64(r) << 8) | uint64(g) | uint64(b>>8)
+}
+
 // cutBoxBasedOn refines the bounding box by removing rows and columns
 // that match the background criteria defined in analysisSlices.
 func cutBoxBasedOn(
@@ -160,13 +171,38 @@ func HalfSplit(
 ) (halfBounds Margins[image.Rectangle]) {
 	switch imgOrientation {
 	case inktypes.OrientationLandscape:
-		midX := inputBonds.Min.X + inputBonds.Dx()/2
-		halfBounds.Left = image.Rect(inputBonds.Min.X, inputBonds.Min.Y, midX, inputBonds.Max.Y)
-		halfBounds.Right = image.Rect(midX, inputBonds.Min.Y, inputBonds.Max.X, inputBonds.Max.Y)
+		return SplitAt(inputBonds, imgOrientation, inputBonds.Min.X+inputBonds.Dx()/2)
+	case inktypes.OrientationPortrait:
+		return SplitAt(inputBonds, imgOrientation, inputBonds.Min.Y+inputBonds.Dy()/2)
+	}
+
+	return halfBounds
+}
+
+// SplitAt splits the bounds in two at the given position, which is a column on
+// landscape images and a row on portrait ones.
+func SplitAt(
+	inputBonds image.Rectangle, imgOrientation inktypes.ImageOrientation, position int,
+) (halfBounds Margins[image.Rectangle]) {
+	switch imgOrientation {
+	case inktypes.OrientationLandscape:
+		position = min(max(position, inputBonds.Min.X), inputBonds.Max.X)
+		halfBounds.Left = image.Rect(inputBonds.Min.X, inputBonds.Min.Y, position, inputBonds.Max.Y)
+		halfBounds.Right = image.Rect(
+			position,
+			inputBonds.Min.Y,
+			inputBonds.Max.X,
+			inputBonds.Max.Y,
+		)
 	case inktypes.OrientationPortrait:
-		midY := inputBonds.Min.Y + inputBonds.Dy()/2
-		halfBounds.Top = image.Rect(inputBonds.Min.X, inputBonds.Min.Y, inputBonds.Max.X, midY)
-		halfBounds.Bottom = image.Rect(inputBonds.Min.X, midY, inputBonds.Max.X, inputBonds.Max.Y)
+		position = min(max(position, inputBonds.Min.Y), inputBonds.Max.Y)
+		halfBounds.Top = image.Rect(inputBonds.Min.X, inputBonds.Min.Y, inputBonds.Max.X, position)
+		halfBounds.Bottom = image.Rect(
+			inputBonds.Min.X,
+			position,
+			inputBonds.Max.X,
+			inputBonds.Max.Y,
+		)
 	}
 
 	return halfBounds
@@ -2,6 +2,7 @@ package imgutils
 
 import (
 	"image"
+	"image/draw"
 	"testing"
 
 	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
@@ -165,3 +166,53 @@ func TestHalfSplit(t *testing.T) {
 		})
 	}
 }
+
+func TestCropBox_IgnoreEdgeBlobs(t *testing.T) {
+	// newPage draws black rectangles on a white 200x300 page
+	newPage := func(blobs ...image.Rectangle) image.Image {
+		img := image.NewGray(image.Rect(0, 0, 200, 300))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		for _, blob := range blobs {
+			draw.Draw(img, blob, image.Black, image.Point{}, draw.Src)
+		}
+		return img
+	}
+	art := image.Rect(20, 20, 180, 260)
+
+	tests := []struct {
+		name     string
+		img      image.Image
+		expected image.Rectangle
+	}{
+		{name: "Art alone", img: newPage(art), expected: art},
+		{
+			name:     "Page number and scan speck are ignored",
+			img:      newPage(art, image.Rect(95, 282, 105, 290), image.Rect(3, 3, 5, 5)),
+			expected: art,
+		},
+		{
+			name:     "Caption close to the art is kept",
+			img:      newPage(art, image.Rect(95, 262, 105, 268)),
+			expected: image.Rect(20, 20, 180, 268),
+		},
+		{
+			name:     "Large blob near the edge is art",
+			img:      newPage(art, image.Rect(50, 270, 150, 295)),
+			expected: image.Rect(20, 20, 180, 295),
+		},
+		{
+			name:     "Page with only specks is not cropped",
+			img:      newPage(image.Rect(3, 3, 5, 5)),
+			expected: image.Rect(0, 0, 200, 300),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := CropBox(tt.img, nil, BoxEliminateMinimumColor|BoxIgnoreEdgeBlobs)
+			if actual != tt.expected {
+				t.Errorf("Expected %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
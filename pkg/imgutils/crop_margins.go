@@ -0,0 +1,65 @@
+package imgutils
+
+import (
+	"image"
+	"math"
+	"slices"
+)
+
+// CropMargins returns the fraction of the page cut from each of its sides by the box.
+// Unlike the box, the fractions can be applied on pages of other sizes.
+func CropMargins(page, box image.Rectangle) (margins Margins[float64]) {
+	if page.Empty() {
+		return margins
+	}
+
+	width, height := float64(page.Dx()), float64(page.Dy())
+	box = box.Intersect(page)
+	return Margins[float64]{
+		Top:    float64(box.Min.Y-page.Min.Y) / height,
+		Bottom: float64(page.Max.Y-box.Max.Y) / height,
+		Left:   float64(box.Min.X-page.Min.X) / width,
+		Right:  float64(page.Max.X-box.Max.X) / width,
+	}
+}
+
+// ApplyCropMargins returns the box left on the page after cutting the fractions from its sides.
+func ApplyCropMargins(page image.Rectangle, margins Margins[float64]) image.Rectangle {
+	width, height := float64(page.Dx()), float64(page.Dy())
+	cut := func(fraction, size float64) int { return int(math.Round(fraction * size)) }
+	box := image.Rect(
+		page.Min.X+cut(margins.Left, width), page.Min.Y+cut(margins.Top, height),
+		page.Max.X-cut(margins.Right, width), page.Max.Y-cut(margins.Bottom, height),
+	)
+	if box.Empty() {
+		return page
+	}
+	return box
+}
+
+// MedianCropMargins returns the median of each side of the margins, which gives a crop
+// shared by many pages, ignoring the pages cropped unlike the others, like the covers.
+func MedianCropMargins(margins []Margins[float64]) (median Margins[float64]) {
+	if len(margins) == 0 {
+		return median
+	}
+
+	sideMedian := func(side func(Margins[float64]) float64) float64 {
+		values := make([]float64, 0, len(margins))
+		for _, pageMargins := range margins {
+			values = append(values, side(pageMargins))
+		}
+		slices.Sort(values)
+		if len(values)%2 == 0 {
+			return (values[len(values)/2-1] + values[len(values)/2]) / 2
+		}
+		return values[len(values)/2]
+	}
+
+	return Margins[float64]{
+		Top:    sideMedian(func(m Margins[float64]) float64 { return m.Top }),
+		Bottom: sideMedian(func(m Margins[float64]) float64 { return m.Bottom }),
+		Left:   sideMedian(func(m Margins[float64]) float64 { return m.Left }),
+		Right:  sideMedian(func(m Margins[float64]) float64 { return m.Right }),
+	}
+}
@@ -0,0 +1,106 @@
+package imgutils
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCropMargins(t *testing.T) {
+	testCases := []struct {
+		name     string
+		page     image.Rectangle
+		box      image.Rectangle
+		expected Margins[float64]
+	}{
+		{
+			name:     "Uncropped page",
+			page:     image.Rect(0, 0, 100, 200),
+			box:      image.Rect(0, 0, 100, 200),
+			expected: Margins[float64]{},
+		},
+		{
+			name:     "Cropped page",
+			page:     image.Rect(0, 0, 100, 200),
+			box:      image.Rect(10, 20, 80, 190),
+			expected: Margins[float64]{Top: 0.1, Bottom: 0.05, Left: 0.1, Right: 0.2},
+		},
+		{
+			name:     "Page out of the origin",
+			page:     image.Rect(50, 100, 150, 300),
+			box:      image.Rect(60, 120, 130, 290),
+			expected: Margins[float64]{Top: 0.1, Bottom: 0.05, Left: 0.1, Right: 0.2},
+		},
+		{
+			name:     "Empty page",
+			page:     image.Rectangle{},
+			box:      image.Rect(10, 20, 80, 190),
+			expected: Margins[float64]{},
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			margins := CropMargins(tCase.page, tCase.box)
+			if margins != tCase.expected {
+				t.Errorf("expected: %+v, actual: %+v", tCase.expected, margins)
+			}
+			if tCase.page.Empty() {
+				return
+			}
+			if box := ApplyCropMargins(tCase.page, margins); box != tCase.box {
+				t.Errorf("expected box: %v, actual: %v", tCase.box, box)
+			}
+		})
+	}
+
+	t.Run("Margins on a bigger page", func(t *testing.T) {
+		margins := Margins[float64]{Top: 0.1, Bottom: 0.05, Left: 0.1, Right: 0.2}
+		expected := image.Rect(20, 40, 160, 380)
+		if box := ApplyCropMargins(image.Rect(0, 0, 200, 400), margins); box != expected {
+			t.Errorf("expected box: %v, actual: %v", expected, box)
+		}
+	})
+}
+
+func TestMedianCropMargins(t *testing.T) {
+	testCases := []struct {
+		name     string
+		margins  []Margins[float64]
+		expected Margins[float64]
+	}{
+		{name: "No margins", expected: Margins[float64]{}},
+		{
+			name: "Odd number of pages ignores the cover",
+			margins: []Margins[float64]{
+				{}, // Full bleed cover
+				{Top: 0.1, Bottom: 0.1, Left: 0.05, Right: 0.1},
+				{Top: 0.12, Bottom: 0.08, Left: 0.06, Right: 0.1},
+			},
+			expected: Margins[float64]{Top: 0.1, Bottom: 0.08, Left: 0.05, Right: 0.1},
+		},
+		{
+			name: "Even number of pages",
+			margins: []Margins[float64]{
+				{Top: 0.1, Bottom: 0.2, Left: 0.1, Right: 0.1},
+				{Top: 0.2, Bottom: 0.1, Left: 0.3, Right: 0.1},
+			},
+			expected: Margins[float64]{Top: 0.15, Bottom: 0.15, Left: 0.2, Right: 0.1},
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			median := MedianCropMargins(tCase.margins)
+			const epsilon = 1e-9
+			for _, diff := range []float64{
+				median.Top - tCase.expected.Top, median.Bottom - tCase.expected.Bottom,
+				median.Left - tCase.expected.Left, median.Right - tCase.expected.Right,
+			} {
+				if max(diff, -diff) > epsilon {
+					t.Errorf("expected: %+v, actual: %+v", tCase.expected, median)
+					break
+				}
+			}
+		})
+	}
+}
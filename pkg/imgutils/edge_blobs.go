@@ -0,0 +1,116 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+)
+
+const (
+	// edgeBlobMaxSize is the largest size of an ignored blob, relative to the smaller page side
+	edgeBlobMaxSize = 0.06
+	// edgeBlobBand is the fraction of the page, from each side, where the blobs can be ignored
+	edgeBlobBand = 0.12
+	// edgeBlobMinGap is the smallest distance, relative to the smaller page side, between
+	// an ignored blob and the art. Closer blobs, like the captions, are part of the art.
+	edgeBlobMinGap = 0.015
+)
+
+// artBox returns the box of the ink on the page, ignoring the small blobs isolated near the
+// page edges, like the page numbers and the scan specks. The paper is the highest pixel
+// value, as on CropBox, and the transparent pixels are taken as paper.
+func artBox(img image.Image, colorConverter ColorConverter) image.Rectangle {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return bounds
+	}
+
+	// The paper value is found first, so only the ink mask is kept for each pixel
+	var paperValue uint64
+	for x, y := range RegionIterator(bounds) {
+		if pixel := img.At(x, y); !isTransparent(pixel) {
+			paperValue = max(paperValue, boxPixelValue(colorConverter, pixel))
+		}
+	}
+	ink := make([]bool, width*height)
+	for x, y := range RegionIterator(bounds) {
+		pixel := img.At(x, y)
+		ink[(y-bounds.Min.Y)*width+(x-bounds.Min.X)] = !isTransparent(pixel) &&
+			boxPixelValue(colorConverter, pixel) < paperValue
+	}
+
+	var (
+		smallerSide = float64(min(width, height))
+		maxBlobSize = int(smallerSide * edgeBlobMaxSize)
+		bandWidth   = int(float64(width) * edgeBlobBand)
+		bandHeight  = int(float64(height) * edgeBlobBand)
+		innerPage   = image.Rect(bandWidth, bandHeight, width-bandWidth, height-bandHeight)
+		art         image.Rectangle
+		edgeBlobs   []image.Rectangle
+	)
+	for _, blob := range inkBlobs(ink, width, height) {
+		isSmall := blob.Dx() <= maxBlobSize && blob.Dy() <= maxBlobSize
+		if isSmall && !blob.In(innerPage) {
+			edgeBlobs = append(edgeBlobs, blob)
+			continue
+		}
+		art = art.Union(blob)
+	}
+	if art.Empty() {
+		return bounds
+	}
+
+	// The edge blobs close to the art are kept, which may bring other blobs close to it
+	gap := int(smallerSide * edgeBlobMinGap)
+	for merged := true; merged; {
+		merged = false
+		for index := 0; index < len(edgeBlobs); index++ {
+			if edgeBlobs[index].Overlaps(art.Inset(-gap)) {
+				art = art.Union(edgeBlobs[index])
+				edgeBlobs = append(edgeBlobs[:index], edgeBlobs[index+1:]...)
+				index--
+				merged = true
+			}
+		}
+	}
+
+	return art.Add(bounds.Min)
+}
+
+// inkBlobs returns the bounding box of each 8-connected group of ink pixels on the mask.
+// The mask is cleared while the groups are visited.
+func inkBlobs(ink []bool, width, height int) (blobs []image.Rectangle) {
+	var stack []int
+	for start, isInk := range ink {
+		if !isInk {
+			continue
+		}
+
+		blob := image.Rect(start%width, start/width, start%width+1, start/width+1)
+		ink[start] = false
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			index := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := index%width, index/width
+			blob = blob.Union(image.Rect(x, y, x+1, y+1))
+			for neighborY := max(y-1, 0); neighborY <= min(y+1, height-1); neighborY++ {
+				for neighborX := max(x-1, 0); neighborX <= min(x+1, width-1); neighborX++ {
+					if neighbor := neighborY*width + neighborX; ink[neighbor] {
+						ink[neighbor] = false
+						stack = append(stack, neighbor)
+					}
+				}
+			}
+		}
+		blobs = append(blobs, blob)
+	}
+
+	return blobs
+}
+
+// isTransparent tells if the pixel is fully transparent, which is taken as paper.
+func isTransparent(pixel color.Color) bool {
+	_, _, _, alpha := pixel.RGBA()
+	return alpha == 0
+}
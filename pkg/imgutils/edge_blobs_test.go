@@ -0,0 +1,150 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestArtBox(t *testing.T) {
+	// newPage draws black rectangles on a white page with the given bounds
+	newPage := func(bounds image.Rectangle, blobs ...image.Rectangle) image.Image {
+		img := image.NewGray(bounds)
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		for _, blob := range blobs {
+			draw.Draw(img, blob, image.Black, image.Point{}, draw.Src)
+		}
+		return img
+	}
+	var (
+		page       = image.Rect(0, 0, 200, 300)
+		art        = image.Rect(20, 20, 180, 260)
+		offset     = image.Pt(100, 100)
+		framedPage = image.NewNRGBA(page)
+	)
+	draw.Draw(framedPage, image.Rect(10, 10, 190, 290), image.White, image.Point{}, draw.Src)
+	draw.Draw(framedPage, art, image.Black, image.Point{}, draw.Src)
+
+	tests := []struct {
+		name     string
+		img      image.Image
+		expected image.Rectangle
+	}{
+		{name: "Blank page", img: newPage(page), expected: page},
+		{name: "Art alone", img: newPage(page, art), expected: art},
+		{
+			name: "Page numbers on the bottom corners",
+			img: newPage(
+				page, art, image.Rect(5, 285, 15, 293), image.Rect(185, 285, 195, 293),
+			),
+			expected: art,
+		},
+		{
+			name:     "Page number on the top corner",
+			img:      newPage(page, art, image.Rect(185, 4, 195, 12)),
+			expected: art,
+		},
+		{
+			name:     "Specks on the sides",
+			img:      newPage(page, art, image.Rect(2, 150, 4, 152), image.Rect(197, 5, 199, 7)),
+			expected: art,
+		},
+		{
+			name:     "Blob touching the art is kept",
+			img:      newPage(page, art, image.Rect(95, 260, 105, 268)),
+			expected: image.Rect(20, 20, 180, 268),
+		},
+		{
+			name: "Blob close to a kept blob is kept",
+			img: newPage(
+				page, art, image.Rect(95, 262, 105, 268), image.Rect(95, 270, 105, 276),
+			),
+			expected: image.Rect(20, 20, 180, 276),
+		},
+		{
+			name:     "Blob inside the page is art",
+			img:      newPage(page, image.Rect(95, 145, 105, 155)),
+			expected: image.Rect(95, 145, 105, 155),
+		},
+		{
+			name:     "Only specks",
+			img:      newPage(page, image.Rect(3, 3, 5, 5), image.Rect(195, 295, 197, 297)),
+			expected: page,
+		},
+		{
+			name: "Page not on the origin",
+			img: newPage(
+				page.Add(offset), art.Add(offset), image.Rect(195, 382, 205, 390),
+			),
+			expected: art.Add(offset),
+		},
+		{name: "Transparent frame is paper", img: framedPage, expected: art},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := artBox(tt.img, color.GrayModel)
+			if actual != tt.expected {
+				t.Errorf("artBox() = %v, expected %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInkBlobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     []string
+		expected []image.Rectangle
+	}{
+		{name: "Empty mask", rows: []string{"...", "..."}},
+		{
+			name:     "Diagonal pixels are connected",
+			rows:     []string{"#..", ".#.", "..#"},
+			expected: []image.Rectangle{image.Rect(0, 0, 3, 3)},
+		},
+		{
+			name:     "Shape turning back up is one blob",
+			rows:     []string{"#.#", "#.#", "###"},
+			expected: []image.Rectangle{image.Rect(0, 0, 3, 3)},
+		},
+		{
+			name: "Separate blobs in scan order",
+			rows: []string{"#.#.", "....", "##..", "...#"},
+			expected: []image.Rectangle{
+				image.Rect(0, 0, 1, 1),
+				image.Rect(2, 0, 3, 1),
+				image.Rect(0, 2, 2, 3),
+				image.Rect(3, 3, 4, 4),
+			},
+		},
+		{
+			name:     "Blobs don't wrap around the rows",
+			rows:     []string{"..#", "#.."},
+			expected: []image.Rectangle{image.Rect(2, 0, 3, 1), image.Rect(0, 1, 1, 2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height := len(tt.rows[0]), len(tt.rows)
+			ink := make([]bool, 0, width*height)
+			for _, row := range tt.rows {
+				for _, pixel := range row {
+					ink = append(ink, pixel == '#')
+				}
+			}
+
+			blobs := inkBlobs(ink, width, height)
+			if !reflect.DeepEqual(blobs, tt.expected) {
+				t.Errorf("inkBlobs() = %v, expected %v", blobs, tt.expected)
+			}
+			if slices.Contains(ink, true) {
+				t.Errorf("expected the ink mask to be cleared")
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package imgutils
 
 import (
 	"image"
+	"image/color"
 	"image/draw"
+	"math"
 
 	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
 )
@@ -60,3 +62,61 @@ func RotateImage(img image.Image, degrees RotationDegrees) image.Image {
 
 	return rotated
 }
+
+// RotateImageAngle rotates the given image clockwise by an arbitrary angle in degrees,
+// around its center. The image keeps its bounds, so the corners that are uncovered by
+// the rotation are painted with the fill color. Pixels are sampled with bilinear interpolation.
+func RotateImageAngle(img image.Image, degrees float64, fill color.Color) image.Image {
+	bounds := img.Bounds()
+	rotated := NewDrawFromImgColorModel(img.ColorModel(), bounds)
+
+	var (
+		radians    = degrees * math.Pi / 180
+		sin, cos   = math.Sincos(radians)
+		centerX    = float64(bounds.Min.X+bounds.Max.X-1) / 2
+		centerY    = float64(bounds.Min.Y+bounds.Max.Y-1) / 2
+		fillColor  = color.RGBA64Model.Convert(fill).(color.RGBA64)
+		pixelColor = func(x, y int) color.RGBA64 {
+			if !(image.Point{X: x, Y: y}).In(bounds) {
+				return fillColor
+			}
+			return color.RGBA64Model.Convert(img.At(x, y)).(color.RGBA64)
+		}
+	)
+
+	for x, y := range Iterator(rotated) {
+		// Apply the inverse rotation to find the source pixel of each destination pixel
+		deltaX, deltaY := float64(x)-centerX, float64(y)-centerY
+		srcX := centerX + deltaX*cos + deltaY*sin
+		srcY := centerY - deltaX*sin + deltaY*cos
+
+		baseX, baseY := math.Floor(srcX), math.Floor(srcY)
+		weightX, weightY := srcX-baseX, srcY-baseY
+		x0, y0 := int(baseX), int(baseY)
+		neighbors := [4]struct {
+			pixel  color.RGBA64
+			weight float64
+		}{
+			{pixel: pixelColor(x0, y0), weight: (1 - weightX) * (1 - weightY)},
+			{pixel: pixelColor(x0+1, y0), weight: weightX * (1 - weightY)},
+			{pixel: pixelColor(x0, y0+1), weight: (1 - weightX) * weightY},
+			{pixel: pixelColor(x0+1, y0+1), weight: weightX * weightY},
+		}
+
+		var sums [4]float64
+		for _, neighbor := range neighbors {
+			sums[0] += float64(neighbor.pixel.R) * neighbor.weight
+			sums[1] += float64(neighbor.pixel.G) * neighbor.weight
+			sums[2] += float64(neighbor.pixel.B) * neighbor.weight
+			sums[3] += float64(neighbor.pixel.A) * neighbor.weight
+		}
+		rotated.Set(x, y, color.RGBA64{
+			R: uint16(math.Round(sums[0])),
+			G: uint16(math.Round(sums[1])),
+			B: uint16(math.Round(sums[2])),
+			A: uint16(math.Round(sums[3])),
+		})
+	}
+
+	return rotated
+}
@@ -140,3 +140,53 @@ func TestRotateImage(t *testing.T) {
 		})
 	}
 }
+
+func TestRotateImageAngle(t *testing.T) {
+	originalImage := testimgs.ImageMultiColorSquare()
+	tests := []struct {
+		name     string
+		degrees  float64
+		fill     color.Color
+		expected image.Image
+	}{
+		{
+			name:     "No rotation keeps the image",
+			degrees:  0,
+			fill:     color.White,
+			expected: originalImage,
+		},
+		{
+			name:     "Right angle matches the fixed rotation",
+			degrees:  90,
+			fill:     color.White,
+			expected: RotateImage(originalImage, Rotation90Degrees),
+		},
+		{
+			name:     "Half turn matches the fixed rotation",
+			degrees:  -180,
+			fill:     color.White,
+			expected: RotateImage(originalImage, Rotation180Degrees),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rotated := RotateImageAngle(originalImage, tt.degrees, tt.fill)
+			if !IsImageEqual(rotated, tt.expected) {
+				t.Errorf("got %#v, want %#v", rotated, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Uncovered corners are filled", func(t *testing.T) {
+		fill := color.RGBA{R: 0xff, A: 0xff}
+		img := testimgs.NewSolidImage(image.Rect(0, 0, 20, 20), color.Black)
+		rotated := RotateImageAngle(img, 45, fill)
+		if got := rotated.At(0, 0); got != color.Color(fill) {
+			t.Errorf("corner got %v, want %v", got, fill)
+		}
+		if got := rotated.At(10, 10); got != color.Color(color.RGBA{A: 0xff}) {
+			t.Errorf("center got %v, want black", got)
+		}
+	})
+}
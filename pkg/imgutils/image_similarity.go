@@ -0,0 +1,116 @@
+package imgutils
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// ssimWindow is the side of the square windows compared by the SSIM
+	ssimWindow = 8
+	// ssimStride is the distance between two neighbor windows, so they overlap
+	ssimStride = 4
+)
+
+// ErrSizeMismatch is returned when images of different sizes are compared.
+var ErrSizeMismatch = errors.New("images have different sizes")
+
+// PSNR returns the peak signal-to-noise ratio, in decibels, between the luminance of both
+// images. Higher is more similar, and equal images have an infinite ratio. Pages re-encoded
+// on a good JPEG quality are usually above 30dB.
+func PSNR(a, b image.Image) (float64, error) {
+	lumaA, lumaB, err := lumaPlanes(a, b)
+	if err != nil {
+		return 0, err
+	}
+
+	var squaredError float64
+	for index := range lumaA {
+		diff := lumaA[index] - lumaB[index]
+		squaredError += diff * diff
+	}
+	if squaredError == 0 {
+		return math.Inf(1), nil
+	}
+
+	meanSquaredError := squaredError / float64(len(lumaA))
+	return 10 * math.Log10(MaxPixelValue*MaxPixelValue/meanSquaredError), nil
+}
+
+// SSIM returns the mean structural similarity index between the luminance of both images,
+// comparing the brightness, contrast and structure of overlapping 8x8 windows.
+// It goes from -1 to 1, where 1 is returned for equal images.
+// Unlike the PSNR, it follows how the eye perceives the differences.
+func SSIM(a, b image.Image) (float64, error) {
+	lumaA, lumaB, err := lumaPlanes(a, b)
+	if err != nil {
+		return 0, err
+	}
+
+	const (
+		stabilizerLuminance = (0.01 * MaxPixelValue) * (0.01 * MaxPixelValue)
+		stabilizerContrast  = (0.03 * MaxPixelValue) * (0.03 * MaxPixelValue)
+	)
+	width, height := a.Bounds().Dx(), a.Bounds().Dy()
+	windowWidth, windowHeight := min(ssimWindow, width), min(ssimWindow, height)
+
+	var (
+		totalIndex   float64
+		totalWindows int
+	)
+	for top := 0; top+windowHeight <= height; top += ssimStride {
+		for left := 0; left+windowWidth <= width; left += ssimStride {
+			var sumA, sumB, sumSquaresA, sumSquaresB, sumProducts float64
+			for y := top; y < top+windowHeight; y++ {
+				for x := left; x < left+windowWidth; x++ {
+					valueA, valueB := lumaA[y*width+x], lumaB[y*width+x]
+					sumA += valueA
+					sumB += valueB
+					sumSquaresA += valueA * valueA
+					sumSquaresB += valueB * valueB
+					sumProducts += valueA * valueB
+				}
+			}
+
+			pixels := float64(windowWidth * windowHeight)
+			meanA, meanB := sumA/pixels, sumB/pixels
+			varianceA := sumSquaresA/pixels - meanA*meanA
+			varianceB := sumSquaresB/pixels - meanB*meanB
+			covariance := sumProducts/pixels - meanA*meanB
+
+			totalIndex += (2*meanA*meanB + stabilizerLuminance) *
+				(2*covariance + stabilizerContrast) /
+				((meanA*meanA + meanB*meanB + stabilizerLuminance) *
+					(varianceA + varianceB + stabilizerContrast))
+			totalWindows++
+		}
+	}
+	if totalWindows == 0 { // Empty images
+		return 1, nil
+	}
+
+	return totalIndex / float64(totalWindows), nil
+}
+
+// lumaPlanes returns the luminance of both images, which must have the same size,
+// in row-major order.
+func lumaPlanes(a, b image.Image) (lumaA, lumaB []float64, err error) {
+	boundsA, boundsB := a.Bounds(), b.Bounds()
+	if boundsA.Size() != boundsB.Size() {
+		return nil, nil, ErrSizeMismatch
+	}
+
+	lumaA = make([]float64, 0, boundsA.Dx()*boundsA.Dy())
+	lumaB = make([]float64, 0, boundsA.Dx()*boundsA.Dy())
+	for y := range boundsA.Dy() {
+		for x := range boundsA.Dx() {
+			pixelA := a.At(boundsA.Min.X+x, boundsA.Min.Y+y)
+			pixelB := b.At(boundsB.Min.X+x, boundsB.Min.Y+y)
+			lumaA = append(lumaA, float64(color.GrayModel.Convert(pixelA).(color.Gray).Y))
+			lumaB = append(lumaB, float64(color.GrayModel.Convert(pixelB).(color.Gray).Y))
+		}
+	}
+	return lumaA, lumaB, nil
+}
@@ -0,0 +1,109 @@
+package imgutils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"math"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+)
+
+func TestImageSimilarity(t *testing.T) {
+	sourcePage := testimgs.ImageGenericMangaPage()
+	mangaPage := image.NewGray(sourcePage.Bounds())
+	draw.Draw(mangaPage, mangaPage.Bounds(), sourcePage, sourcePage.Bounds().Min, draw.Src)
+	pageBounds := mangaPage.Bounds()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, mangaPage, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	reencodedPage, err := jpeg.Decode(&encoded)
+	if err != nil {
+		t.Fatalf("jpeg.Decode: %v", err)
+	}
+
+	// The same page, drawn from another origin, must be compared pixel by pixel
+	shiftedBounds := pageBounds.Add(image.Pt(10, 20))
+	shiftedPage := image.NewGray(shiftedBounds)
+	for x, y := range Iterator(mangaPage) {
+		shiftedPage.Set(x+10, y+20, mangaPage.At(x, y))
+	}
+
+	testCases := []struct {
+		name        string
+		other       image.Image
+		minSSIM     float64
+		maxSSIM     float64
+		minPSNR     float64
+		maxPSNR     float64
+		expectedErr error
+	}{
+		{
+			name:    "Same image",
+			other:   mangaPage,
+			minSSIM: 1, maxSSIM: 1,
+			minPSNR: math.Inf(1), maxPSNR: math.Inf(1),
+		},
+		{
+			name:    "Same image on other origin",
+			other:   shiftedPage,
+			minSSIM: 1, maxSSIM: 1,
+			minPSNR: math.Inf(1), maxPSNR: math.Inf(1),
+		},
+		{
+			name:    "Re-encoded image",
+			other:   reencodedPage,
+			minSSIM: 0.9, maxSSIM: 1,
+			minPSNR: 30, maxPSNR: 60,
+		},
+		{
+			name:    "Solid image",
+			other:   testimgs.NewSolidImage(pageBounds, color.White),
+			minSSIM: -1, maxSSIM: 0.5,
+			minPSNR: 0, maxPSNR: 15,
+		},
+		{
+			name:        "Different size",
+			other:       testimgs.NewSolidImage(image.Rect(0, 0, 10, 10), color.White),
+			expectedErr: ErrSizeMismatch,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			ssim, ssimErr := SSIM(mangaPage, tCase.other)
+			psnr, psnrErr := PSNR(mangaPage, tCase.other)
+			if !errors.Is(ssimErr, tCase.expectedErr) || !errors.Is(psnrErr, tCase.expectedErr) {
+				t.Fatalf(
+					"expected error: %v, actual: %v and %v", tCase.expectedErr, ssimErr, psnrErr,
+				)
+			}
+			if tCase.expectedErr != nil {
+				return
+			}
+
+			if ssim < tCase.minSSIM-1e-9 || ssim > tCase.maxSSIM+1e-9 {
+				t.Errorf(
+					"expected SSIM in [%v, %v], actual: %v",
+					tCase.minSSIM,
+					tCase.maxSSIM,
+					ssim,
+				)
+			}
+			if psnr < tCase.minPSNR || psnr > tCase.maxPSNR {
+				t.Errorf(
+					"expected PSNR in [%v, %v], actual: %v",
+					tCase.minPSNR,
+					tCase.maxPSNR,
+					psnr,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+)
+
+// PageKind is the content of a page, which tells how it's best shown on the device.
+type PageKind uint8
+
+const (
+	// PageText is mostly text, or line art, legible on a bitonal output
+	PageText PageKind = iota
+	// PageIllustration has grays and filled areas, which must keep the grayscale
+	PageIllustration
+)
+
+const (
+	// classifySamplePixels is the approximate number of pixels analyzed to classify the page
+	classifySamplePixels = 250_000
+	// classifyDarkThreshold is the luminance under which a pixel is taken as ink
+	classifyDarkThreshold = 0x80
+	// classifyMidtoneRange is the luminance distance to the middle gray taken as a midtone
+	classifyMidtoneRange = 0x40
+	// classifyEdgeContrast is the luminance difference between neighbors taken as an edge
+	classifyEdgeContrast = 0x60
+	// textMaxMidtones is the largest fraction of midtones on a text page
+	textMaxMidtones = 0.15
+	// textMaxInk is the largest fraction of ink on a text page
+	textMaxInk = 0.25
+	// textMinEdgesPerInk is the smallest number of edges for each ink pixel on a text page,
+	// as the text strokes are thin, unlike the filled areas of the illustrations
+	textMinEdgesPerInk = 0.3
+)
+
+// ClassifyPage tells if the page is text or illustration, by its luminance histogram and
+// its edge density. Text pages are mostly paper and ink, without midtones, and their ink
+// is made of thin strokes, with an edge for almost every ink pixel. Blank pages are text.
+func ClassifyPage(img image.Image) PageKind {
+	bounds := img.Bounds()
+	if bounds.Dx() < 2 || bounds.Dy() == 0 {
+		return PageText
+	}
+
+	// Sample whole rows, so the edges are searched between neighbor pixels
+	rowStride := max(1, bounds.Dx()*bounds.Dy()/classifySamplePixels)
+	var totalPixels, inkPixels, midtonePixels, edges int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += rowStride {
+		var previous int
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			luminance := int(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			totalPixels++
+			if luminance < classifyDarkThreshold {
+				inkPixels++
+			}
+			if distance := luminance - 0x80; max(distance, -distance) < classifyMidtoneRange {
+				midtonePixels++
+			}
+			diff := luminance - previous
+			if x > bounds.Min.X && max(diff, -diff) > classifyEdgeContrast {
+				edges++
+			}
+			previous = luminance
+		}
+	}
+
+	inkRatio := float64(inkPixels) / float64(totalPixels)
+	midtoneRatio := float64(midtonePixels) / float64(totalPixels)
+	if midtoneRatio > textMaxMidtones || inkRatio > textMaxInk {
+		return PageIllustration
+	}
+	if inkPixels > 0 && float64(edges) < textMinEdgesPerInk*float64(inkPixels) {
+		return PageIllustration
+	}
+
+	return PageText
+}
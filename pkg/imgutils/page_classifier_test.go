@@ -0,0 +1,48 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+)
+
+func TestClassifyPage(t *testing.T) {
+	whitePaper := func(int) uint8 { return MaxPixelValue }
+	textPage, _ := newTextPage(200, 300, MaxPixelValue, whitePaper)
+
+	filledPage := image.NewGray(image.Rect(0, 0, 200, 300))
+	draw.Draw(filledPage, filledPage.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(filledPage, image.Rect(60, 100, 100, 140), image.Black, image.Point{}, draw.Src)
+
+	gradientPage := image.NewGray(image.Rect(0, 0, 200, 300))
+	for x, y := range Iterator(gradientPage) {
+		gradientPage.SetGray(x, y, color.Gray{Y: uint8(y * MaxPixelValue / 300)})
+	}
+
+	testCases := []struct {
+		name     string
+		img      image.Image
+		expected PageKind
+	}{
+		{name: "Text page", img: textPage, expected: PageText},
+		{
+			name:     "Blank page",
+			img:      testimgs.NewSolidImage(image.Rect(0, 0, 200, 300), color.White),
+			expected: PageText,
+		},
+		{name: "Filled area", img: filledPage, expected: PageIllustration},
+		{name: "Gradient", img: gradientPage, expected: PageIllustration},
+		{name: "Manga page", img: testimgs.ImageGenericMangaPage(), expected: PageIllustration},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			if kind := ClassifyPage(tCase.img); kind != tCase.expected {
+				t.Errorf("expected page kind %d, got %d", tCase.expected, kind)
+			}
+		})
+	}
+}
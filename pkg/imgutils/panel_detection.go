@@ -0,0 +1,213 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"slices"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+const (
+	// panelBackgroundTolerance is the maximum gray distance from the gutter color
+	// for a pixel to still be considered as part of the gutter.
+	panelBackgroundTolerance = 32
+	// panelMinGutterRatio is the minimum gutter thickness, relative to the page size.
+	panelMinGutterRatio = 0.005
+	// panelMinSizeRatio discards regions smaller than this ratio of the page on any
+	// axis, which are usually page numbers or small artifacts.
+	panelMinSizeRatio = 0.08
+	// panelLineNoiseRatio is the ratio of pixels allowed to differ from the gutter color
+	// on a gutter line, so JPEG artifacts do not break the gutter.
+	panelLineNoiseRatio = 0.001
+)
+
+// panelGrid keeps an integral image counting the pixels that are not gutter,
+// allowing to count the content pixels on any region in constant time.
+type panelGrid struct {
+	width    int
+	integral []uint32
+}
+
+func newPanelGrid(img image.Image) panelGrid {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	grayValues := make([]uint8, width*height)
+	for x, y := range RegionIterator(bounds) {
+		grayPixel := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+		grayValues[(y-bounds.Min.Y)*width+(x-bounds.Min.X)] = grayPixel.Y
+	}
+
+	background := borderDominantValue(grayValues, width, height)
+	grid := panelGrid{
+		width:    width,
+		integral: make([]uint32, (width+1)*(height+1)),
+	}
+	for y := range height {
+		var rowSum uint32
+		for x := range width {
+			value := int(grayValues[y*width+x])
+			if value-background > panelBackgroundTolerance ||
+				background-value > panelBackgroundTolerance {
+				rowSum++
+			}
+			grid.integral[(y+1)*(width+1)+x+1] = grid.integral[y*(width+1)+x+1] + rowSum
+		}
+	}
+
+	return grid
+}
+
+// borderDominantValue returns the most common gray value on the page border,
+// which is taken as the gutter color.
+func borderDominantValue(grayValues []uint8, width, height int) int {
+	var histogram [MaxPixelValue + 1]uint32
+	for x := range width {
+		histogram[grayValues[x]]++
+		histogram[grayValues[(height-1)*width+x]]++
+	}
+	for y := range height {
+		histogram[grayValues[y*width]]++
+		histogram[grayValues[y*width+width-1]]++
+	}
+
+	var dominant int
+	for value, count := range histogram {
+		if count > histogram[dominant] {
+			dominant = value
+		}
+	}
+	return dominant
+}
+
+// content counts the content pixels in the region, given in grid coordinates.
+func (grid panelGrid) content(region image.Rectangle) uint32 {
+	stride := grid.width + 1
+	return grid.integral[region.Max.Y*stride+region.Max.X] -
+		grid.integral[region.Min.Y*stride+region.Max.X] -
+		grid.integral[region.Max.Y*stride+region.Min.X] +
+		grid.integral[region.Min.Y*stride+region.Min.X]
+}
+
+func (grid panelGrid) isRowGutter(region image.Rectangle, y int) bool {
+	row := image.Rect(region.Min.X, y, region.Max.X, y+1)
+	return grid.content(row) <= uint32(float64(region.Dx())*panelLineNoiseRatio)
+}
+
+func (grid panelGrid) isColumnGutter(region image.Rectangle, x int) bool {
+	column := image.Rect(x, region.Min.Y, x+1, region.Max.Y)
+	return grid.content(column) <= uint32(float64(region.Dy())*panelLineNoiseRatio)
+}
+
+// trim removes the gutter lines around the region content.
+func (grid panelGrid) trim(region image.Rectangle) image.Rectangle {
+	for region.Min.Y < region.Max.Y && grid.isRowGutter(region, region.Min.Y) {
+		region.Min.Y++
+	}
+	for region.Max.Y > region.Min.Y && grid.isRowGutter(region, region.Max.Y-1) {
+		region.Max.Y--
+	}
+	for region.Min.X < region.Max.X && grid.isColumnGutter(region, region.Min.X) {
+		region.Min.X++
+	}
+	for region.Max.X > region.Min.X && grid.isColumnGutter(region, region.Max.X-1) {
+		region.Max.X--
+	}
+	return region
+}
+
+// splitSegments splits the [start, end) range on the gutters at least minGutter thick.
+func splitSegments(start, end, minGutter int, isGutter func(int) bool) [][2]int {
+	var (
+		segments     [][2]int
+		segmentStart = start
+		gutterStart  = -1
+	)
+	for position := start; position < end; position++ {
+		if isGutter(position) {
+			if gutterStart < 0 {
+				gutterStart = position
+			}
+			continue
+		}
+		if gutterStart >= 0 && position-gutterStart >= minGutter && gutterStart > segmentStart {
+			segments = append(segments, [2]int{segmentStart, gutterStart})
+			segmentStart = position
+		}
+		gutterStart = -1
+	}
+
+	return append(segments, [2]int{segmentStart, end})
+}
+
+// cut recursively splits the region on its gutters, first on rows and then on
+// columns, returning the panels in reading order.
+func (grid panelGrid) cut(
+	region image.Rectangle, minGutter image.Point, readDirection inktypes.ReadDirection,
+) []image.Rectangle {
+	region = grid.trim(region)
+	if region.Empty() {
+		return nil
+	}
+
+	rows := splitSegments(region.Min.Y, region.Max.Y, minGutter.Y, func(y int) bool {
+		return grid.isRowGutter(region, y)
+	})
+	if len(rows) > 1 {
+		var panels []image.Rectangle
+		for _, row := range rows {
+			band := image.Rect(region.Min.X, row[0], region.Max.X, row[1])
+			panels = append(panels, grid.cut(band, minGutter, readDirection)...)
+		}
+		return panels
+	}
+
+	columns := splitSegments(region.Min.X, region.Max.X, minGutter.X, func(x int) bool {
+		return grid.isColumnGutter(region, x)
+	})
+	if len(columns) == 1 {
+		return []image.Rectangle{region}
+	}
+
+	if readDirection == inktypes.ReadRightToLeft {
+		slices.Reverse(columns)
+	}
+	var panels []image.Rectangle
+	for _, column := range columns {
+		band := image.Rect(column[0], region.Min.Y, column[1], region.Max.Y)
+		panels = append(panels, grid.cut(band, minGutter, readDirection)...)
+	}
+	return panels
+}
+
+// DetectPanels finds the comic panels of the page, splitting it on the gutters between them.
+// The panels are returned in reading order: top to bottom, and then following the
+// read direction. When less than two panels are found, nil is returned.
+func DetectPanels(img image.Image, readDirection inktypes.ReadDirection) []image.Rectangle {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil
+	}
+
+	grid := newPanelGrid(img)
+	minGutter := image.Point{
+		X: max(1, int(float64(width)*panelMinGutterRatio)),
+		Y: max(1, int(float64(height)*panelMinGutterRatio)),
+	}
+	regions := grid.cut(image.Rect(0, 0, width, height), minGutter, readDirection)
+
+	panels := make([]image.Rectangle, 0, len(regions))
+	for _, region := range regions {
+		if float64(region.Dx()) < float64(width)*panelMinSizeRatio ||
+			float64(region.Dy()) < float64(height)*panelMinSizeRatio {
+			continue
+		}
+		panels = append(panels, region.Add(bounds.Min))
+	}
+
+	if len(panels) < 2 {
+		return nil
+	}
+	return panels
+}
@@ -0,0 +1,94 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+// newPanelPage draws framed panels with some content inside over a white page.
+func newPanelPage(bounds image.Rectangle, panels ...image.Rectangle) image.Image {
+	img := image.NewGray(bounds)
+	draw.Draw(img, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	for _, panel := range panels {
+		draw.Draw(img, panel, image.NewUniform(color.Black), image.Point{}, draw.Src)
+		draw.Draw(img, panel.Inset(2), image.NewUniform(color.White), image.Point{}, draw.Src)
+		art := image.Rect(panel.Min.X+10, panel.Min.Y+10, panel.Min.X+20, panel.Min.Y+20)
+		draw.Draw(img, art, image.NewUniform(color.Gray{Y: 90}), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func TestDetectPanels(t *testing.T) {
+	var (
+		pageBounds  = image.Rect(0, 0, 200, 300)
+		topPanel    = image.Rect(10, 10, 190, 140)
+		bottomLeft  = image.Rect(10, 150, 95, 290)
+		bottomRight = image.Rect(105, 150, 190, 290)
+		pageNumber  = image.Rect(98, 292, 102, 297)
+	)
+	threePanels := newPanelPage(pageBounds, topPanel, bottomLeft, bottomRight, pageNumber)
+
+	tests := []struct {
+		name      string
+		img       image.Image
+		direction inktypes.ReadDirection
+		expected  []image.Rectangle
+	}{
+		{
+			name:      "left to right reading order",
+			img:       threePanels,
+			direction: inktypes.ReadLeftToRight,
+			expected:  []image.Rectangle{topPanel, bottomLeft, bottomRight},
+		},
+		{
+			name:      "right to left reading order",
+			img:       threePanels,
+			direction: inktypes.ReadRightToLeft,
+			expected:  []image.Rectangle{topPanel, bottomRight, bottomLeft},
+		},
+		{
+			name: "offset image bounds",
+			img: newPanelPage(
+				pageBounds.Add(image.Pt(50, 50)),
+				topPanel.Add(image.Pt(50, 50)), bottomLeft.Add(image.Pt(50, 50)),
+			),
+			direction: inktypes.ReadLeftToRight,
+			expected: []image.Rectangle{
+				topPanel.Add(image.Pt(50, 50)), bottomLeft.Add(image.Pt(50, 50)),
+			},
+		},
+		{
+			name:      "single panel page",
+			img:       newPanelPage(pageBounds, image.Rect(10, 10, 190, 290)),
+			direction: inktypes.ReadLeftToRight,
+			expected:  nil,
+		},
+		{
+			name:      "blank page",
+			img:       testimgs.NewSolidImage(pageBounds, color.White),
+			direction: inktypes.ReadLeftToRight,
+			expected:  nil,
+		},
+		{
+			name:      "empty image",
+			img:       image.NewGray(image.Rectangle{}),
+			direction: inktypes.ReadLeftToRight,
+			expected:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panels := DetectPanels(tt.img, tt.direction)
+			if !reflect.DeepEqual(panels, tt.expected) {
+				t.Errorf("DetectPanels() = %v, want %v", panels, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package imgutils
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"slices"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// hashSide is the side of the grid of pixels compared by the aHash and the dHash
+	hashSide = 8
+	// phashSampleSize is the side of the grayscale thumbnail transformed by the DCT
+	phashSampleSize = 32
+	// phashFrequencies is the side of the block of low frequencies kept on the hash
+	phashFrequencies = 8
+)
+
+// ImageHash is a 64 bits fingerprint of an image. Similar images have hashes with
+// a small Hamming distance, even after being rescaled or re-encoded.
+type ImageHash uint64
+
+// Distance returns the Hamming distance between both hashes, the number of different bits.
+func (hash ImageHash) Distance(other ImageHash) int {
+	return bits.OnesCount64(uint64(hash ^ other))
+}
+
+// AverageHash computes the aHash of the image: each bit tells if a pixel of a grayscale
+// 8x8 thumbnail is brighter than the thumbnail mean. It's the fastest hash, but
+// a change on the brightness or the contrast also changes it.
+func AverageHash(img image.Image) ImageHash {
+	if img.Bounds().Empty() {
+		return 0
+	}
+
+	thumbnail := grayThumbnail(img, hashSide, hashSide)
+	var total int
+	for _, pixel := range thumbnail.Pix {
+		total += int(pixel)
+	}
+
+	var hash ImageHash
+	for index, pixel := range thumbnail.Pix {
+		if int(pixel)*len(thumbnail.Pix) > total {
+			hash |= 1 << index
+		}
+	}
+	return hash
+}
+
+// DifferenceHash computes the dHash of the image: each bit tells if a pixel of a
+// grayscale 9x8 thumbnail is brighter than its right neighbor. It follows the gradients,
+// so it isn't affected by the brightness changes.
+func DifferenceHash(img image.Image) ImageHash {
+	if img.Bounds().Empty() {
+		return 0
+	}
+
+	thumbnail := grayThumbnail(img, hashSide+1, hashSide)
+	var hash ImageHash
+	for y := range hashSide {
+		for x := range hashSide {
+			if thumbnail.GrayAt(x, y).Y > thumbnail.GrayAt(x+1, y).Y {
+				hash |= 1 << (y*hashSide + x)
+			}
+		}
+	}
+	return hash
+}
+
+// PerceptualHash computes the pHash of the image: the signs, around their median, of the
+// lowest frequencies of the discrete cosine transform of a grayscale thumbnail.
+// It ignores the details, the compression artifacts and small color changes.
+func PerceptualHash(img image.Image) ImageHash {
+	if img.Bounds().Empty() {
+		return 0
+	}
+
+	thumbnail := grayThumbnail(img, phashSampleSize, phashSampleSize)
+	var pixels [phashSampleSize][phashSampleSize]float64
+	for x, y := range Iterator(thumbnail) {
+		pixels[y][x] = float64(thumbnail.GrayAt(x, y).Y)
+	}
+
+	// Separable DCT-II, computed only for the kept frequencies
+	var cosines [phashFrequencies][phashSampleSize]float64
+	for freq := range phashFrequencies {
+		for index := range phashSampleSize {
+			angle := math.Pi * float64(freq) * (2*float64(index) + 1) / (2 * phashSampleSize)
+			cosines[freq][index] = math.Cos(angle)
+		}
+	}
+	var rowsDCT [phashSampleSize][phashFrequencies]float64
+	for y := range phashSampleSize {
+		for freq := range phashFrequencies {
+			for x := range phashSampleSize {
+				rowsDCT[y][freq] += pixels[y][x] * cosines[freq][x]
+			}
+		}
+	}
+	coefficients := make([]float64, 0, phashFrequencies*phashFrequencies)
+	for freqY := range phashFrequencies {
+		for freqX := range phashFrequencies {
+			var coefficient float64
+			for y := range phashSampleSize {
+				coefficient += rowsDCT[y][freqX] * cosines[freqY][y]
+			}
+			coefficients = append(coefficients, coefficient)
+		}
+	}
+
+	// The DC coefficient is the mean brightness, which would skew the median
+	sorted := slices.Clone(coefficients[1:])
+	slices.Sort(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash ImageHash
+	for index, coefficient := range coefficients {
+		if coefficient > median {
+			hash |= 1 << index
+		}
+	}
+	return hash
+}
+
+func grayThumbnail(img image.Image, width, height int) *image.Gray {
+	thumbnail := image.NewGray(image.Rect(0, 0, width, height))
+	draw.BiLinear.Scale(thumbnail, thumbnail.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return thumbnail
+}
@@ -0,0 +1,96 @@
+package imgutils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"golang.org/x/image/draw"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+)
+
+func TestImageHash_Distance(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     ImageHash
+		expected int
+	}{
+		{name: "Same hash", a: 0xf0f0, b: 0xf0f0, expected: 0},
+		{name: "One bit", a: 0b1000, b: 0b0000, expected: 1},
+		{name: "All bits", a: 0, b: ^ImageHash(0), expected: 64},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			if result := tCase.a.Distance(tCase.b); result != tCase.expected {
+				t.Errorf("expected: %d, actual: %d", tCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestImageHashes(t *testing.T) {
+	mangaPage := testimgs.ImageGenericMangaPage()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, mangaPage, &jpeg.Options{Quality: 40}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	reencodedPage, err := jpeg.Decode(&encoded)
+	if err != nil {
+		t.Fatalf("jpeg.Decode: %v", err)
+	}
+
+	pageBounds := mangaPage.Bounds()
+	scaledPage := image.NewRGBA(image.Rect(0, 0, pageBounds.Dx()/3, pageBounds.Dy()/3))
+	draw.CatmullRom.Scale(scaledPage, scaledPage.Bounds(), mangaPage, pageBounds, draw.Src, nil)
+
+	hashFuncs := []struct {
+		name string
+		hash func(image.Image) ImageHash
+	}{
+		{name: "aHash", hash: AverageHash},
+		{name: "dHash", hash: DifferenceHash},
+		{name: "pHash", hash: PerceptualHash},
+	}
+	testCases := []struct {
+		name        string
+		other       image.Image
+		maxDistance int
+		minDistance int
+	}{
+		{name: "Same image", other: mangaPage, maxDistance: 0},
+		{name: "Re-encoded image", other: reencodedPage, maxDistance: 4},
+		{name: "Downscaled image", other: scaledPage, maxDistance: 6},
+		{
+			name:        "Different image",
+			other:       testimgs.ImageBlackSquareWhiteMargin(),
+			minDistance: 16,
+			maxDistance: 64,
+		},
+		{
+			name:        "Solid image",
+			other:       testimgs.NewSolidImage(pageBounds, color.White),
+			minDistance: 16,
+			maxDistance: 64,
+		},
+	}
+
+	for _, hashFunc := range hashFuncs {
+		pageHash := hashFunc.hash(mangaPage)
+		for _, tCase := range testCases {
+			t.Run(hashFunc.name+"/"+tCase.name, func(t *testing.T) {
+				distance := pageHash.Distance(hashFunc.hash(tCase.other))
+				if distance < tCase.minDistance || distance > tCase.maxDistance {
+					t.Errorf(
+						"expected distance in [%d, %d], actual: %d",
+						tCase.minDistance, tCase.maxDistance, distance,
+					)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+)
+
+// PixelArtMaxColors is the maximum number of distinct colors found on a pixel-art image.
+const PixelArtMaxColors = 64
+
+// CountColors returns the number of distinct colors in the image, stopping the count once
+// the limit is reached.
+func CountColors(img image.Image, limit int) int {
+	colorsSet := make(map[color.RGBA64]struct{}, min(limit, 256))
+	for x, y := range Iterator(img) {
+		colorsSet[color.RGBA64Model.Convert(img.At(x, y)).(color.RGBA64)] = struct{}{}
+		if len(colorsSet) >= limit {
+			break
+		}
+	}
+
+	return len(colorsSet)
+}
+
+// IsPixelArt reports whether the image looks like pixel art, a small set of flat colors
+// without the anti-aliased gradients found on drawings, scans and photos.
+// Bi-level images are not taken as pixel art, as they usually are binarized line art,
+// neither are the images with only gray levels, as the scans are often saved with a
+// small gray palette, like 16 grays.
+func IsPixelArt(img image.Image) bool {
+	totalColors := CountColors(img, PixelArtMaxColors+1)
+	return totalColors > 2 && totalColors <= PixelArtMaxColors && hasChromaticColor(img)
+}
+
+// hasChromaticColor tells if any pixel of the image is not a gray level.
+func hasChromaticColor(img image.Image) bool {
+	for x, y := range Iterator(img) {
+		r, g, b, _ := img.At(x, y).RGBA()
+		if r != g || g != b {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+)
+
+func TestIsPixelArt(t *testing.T) {
+	paletteImage := func(totalColors int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+		for x, y := range Iterator(img) {
+			index := ((y/4)*4 + x/4) % totalColors
+			img.Set(x, y, color.RGBA{R: uint8(index * 3), G: 0x40, B: uint8(index), A: 0xff})
+		}
+		return img
+	}
+
+	grayLevelsImage := image.NewGray(image.Rect(0, 0, 16, 16))
+	for x, y := range Iterator(grayLevelsImage) {
+		grayLevelsImage.SetGray(x, y, color.Gray{Y: uint8(((y/4)*4 + x/4) * 0x11)})
+	}
+
+	testCases := []struct {
+		name           string
+		img            image.Image
+		expectedColors int
+		expected       bool
+	}{
+		{
+			name:           "Solid image",
+			img:            testimgs.NewSolidImage(image.Rect(0, 0, 8, 8), color.White),
+			expectedColors: 1,
+			expected:       false,
+		},
+		{
+			name:           "Bi-level line art",
+			img:            paletteImage(2),
+			expectedColors: 2,
+			expected:       false,
+		},
+		{
+			name:           "Few flat colors",
+			img:            paletteImage(12),
+			expectedColors: 12,
+			expected:       true,
+		},
+		{
+			name:           "Few gray levels",
+			img:            grayLevelsImage,
+			expectedColors: 16,
+			expected:       false,
+		},
+		{
+			name:           "Random noise",
+			img:            testimgs.ImageFixtures(1, []byte("TestIsPixelArt"))[0],
+			expectedColors: PixelArtMaxColors + 1,
+			expected:       false,
+		},
+		{
+			name:           "Manga page",
+			img:            testimgs.ImageGenericMangaPage(),
+			expectedColors: PixelArtMaxColors + 1,
+			expected:       false,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			totalColors := CountColors(tCase.img, PixelArtMaxColors+1)
+			if totalColors != tCase.expectedColors {
+				t.Errorf("expected %d colors, got %d", tCase.expectedColors, totalColors)
+			}
+			if result := IsPixelArt(tCase.img); result != tCase.expected {
+				t.Errorf("expected %v, got %v", tCase.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// skewSamplePixels is the approximate number of pixels analysed to estimate the skew
+	skewSamplePixels = 250_000
+	// skewDarkThreshold is the luminance under which a pixel is taken as ink
+	skewDarkThreshold = 0x80
+	// skewMinGain is the minimum score improvement over the unrotated image that
+	// is required to report a skew, which avoids rotating pages without text lines.
+	skewMinGain = 1.1
+)
+
+// EstimateSkewAngle estimates, in degrees, the clockwise rotation of the page content,
+// searching in the range [-maxAngle, maxAngle]. It uses the projection profile of the
+// dark pixels: when the rotation is undone, text lines and panel borders concentrate
+// the ink on fewer and sharper rows. To straighten the page, rotate it by the negated angle.
+func EstimateSkewAngle(img image.Image, maxAngle float64) float64 {
+	bounds := img.Bounds()
+	if bounds.Empty() || maxAngle <= 0 {
+		return 0
+	}
+
+	stride := max(1, int(math.Sqrt(float64(bounds.Dx()*bounds.Dy())/skewSamplePixels)))
+	var darkPixels []image.Point
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stride {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stride {
+			pixel := img.At(x, y)
+			if _, _, _, alpha := pixel.RGBA(); alpha < 0x8000 {
+				continue // Transparent pixels are taken as paper
+			}
+			if color.GrayModel.Convert(pixel).(color.Gray).Y < skewDarkThreshold {
+				darkPixels = append(darkPixels, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	if len(darkPixels) == 0 {
+		return 0
+	}
+
+	center := image.Point{
+		X: (bounds.Min.X + bounds.Max.X) / 2,
+		Y: (bounds.Min.Y + bounds.Max.Y) / 2,
+	}
+	// Rows of the profile go from -radius to radius, whatever the rotation angle is
+	radius := int(math.Hypot(float64(bounds.Dx()), float64(bounds.Dy()))/float64(2*stride)) + 1
+	projectionScore := func(angle float64) float64 {
+		return skewProjectionScore(darkPixels, center, stride, radius, angle)
+	}
+	zeroScore := projectionScore(0)
+	bestAngle, bestScore := 0.0, zeroScore
+
+	// Search first with coarse steps, then refine around the best coarse angle
+	searchAngles := func(from, to, step float64) {
+		for angle := from; angle <= to+1e-9; angle += step {
+			if score := projectionScore(angle); score > bestScore {
+				bestAngle, bestScore = angle, score
+			}
+		}
+	}
+	searchAngles(-maxAngle, maxAngle, 0.5)
+	searchAngles(bestAngle-0.4, bestAngle+0.4, 0.1)
+
+	if bestScore < zeroScore*skewMinGain {
+		return 0
+	}
+	return math.Round(bestAngle*10) / 10
+}
+
+// skewProjectionScore builds the horizontal projection profile of the points after undoing
+// a clockwise rotation by the given angle. The score is the sum of the squared differences
+// between neighbor rows, which peaks when the rows change sharply from paper to ink.
+func skewProjectionScore(
+	points []image.Point, center image.Point, binSize, radius int, angle float64,
+) float64 {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	profile := make([]float64, 2*radius+1)
+	for _, point := range points {
+		deltaX, deltaY := float64(point.X-center.X), float64(point.Y-center.Y)
+		row := int(math.Floor((-deltaX*sin + deltaY*cos) / float64(binSize)))
+		profile[min(max(row+radius, 0), len(profile)-1)]++
+	}
+
+	var score float64
+	for index := 1; index < len(profile); index++ {
+		diff := profile[index] - profile[index-1]
+		score += diff * diff
+	}
+	return score
+}
@@ -0,0 +1,68 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+)
+
+func TestEstimateSkewAngle(t *testing.T) {
+	// Page with text-like horizontal lines
+	textPage := image.NewGray(image.Rect(0, 0, 600, 400))
+	draw.Draw(textPage, textPage.Bounds(), image.White, image.Point{}, draw.Src)
+	for lineY := 40; lineY < 360; lineY += 20 {
+		line := image.Rect(30, lineY, 570, lineY+4)
+		draw.Draw(textPage, line, image.Black, image.Point{}, draw.Src)
+	}
+
+	testCases := []struct {
+		name     string
+		img      image.Image
+		maxAngle float64
+		expected float64
+	}{
+		{
+			name:     "Straight page",
+			img:      textPage,
+			maxAngle: 3,
+			expected: 0,
+		},
+		{
+			name:     "Clockwise skew",
+			img:      RotateImageAngle(textPage, 2, color.White),
+			maxAngle: 3,
+			expected: 2,
+		},
+		{
+			name:     "Counterclockwise skew",
+			img:      RotateImageAngle(textPage, -1.3, color.White),
+			maxAngle: 3,
+			expected: -1.3,
+		},
+		{
+			name:     "Page without lines",
+			img:      testimgs.ImageBlackCircleWithTransparentBackground(),
+			maxAngle: 3,
+			expected: 0,
+		},
+		{
+			name:     "Blank page",
+			img:      testimgs.NewSolidImage(image.Rect(0, 0, 50, 80), color.White),
+			maxAngle: 3,
+			expected: 0,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			result := EstimateSkewAngle(tCase.img, tCase.maxAngle)
+			if math.Abs(result-tCase.expected) > 0.15 {
+				t.Errorf("expected angle %.1f, got %.1f", tCase.expected, result)
+			}
+		})
+	}
+}
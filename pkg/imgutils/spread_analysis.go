@@ -0,0 +1,105 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+const (
+	// gutterSearchBand is the fraction of the spread, around its center, searched for the gutter
+	gutterSearchBand = 0.3
+	// flatLineMaxSamples is the maximum number of pixels sampled on each line
+	flatLineMaxSamples = 400
+	// flatLineTolerance is the luminance difference still taken as the same flat color
+	flatLineTolerance = 0x20
+	// flatLineMaxInk is the maximum fraction of pixels that may differ from a flat line
+	flatLineMaxInk = 0.02
+)
+
+type gutterRun struct{ start, size int }
+
+// FindGutter searches the gutter of a double-page spread, returning the position where
+// the spread must be split: a column on landscape images and a row on portrait ones.
+// The gutter is the widest run of flat lines, without any art, near the spread center.
+// When the art bleeds across the spread, no gutter exists and false is returned.
+func FindGutter(
+	img image.Image, imgOrientation inktypes.ImageOrientation,
+) (position int, found bool) {
+	bounds := img.Bounds()
+	lineStart, lineEnd, lineLength := bounds.Min.X, bounds.Max.X, bounds.Dy()
+	pixelAt := func(line, offset int) color.Color { return img.At(line, bounds.Min.Y+offset) }
+	if imgOrientation == inktypes.OrientationPortrait {
+		lineStart, lineEnd, lineLength = bounds.Min.Y, bounds.Max.Y, bounds.Dx()
+		pixelAt = func(line, offset int) color.Color { return img.At(bounds.Min.X+offset, line) }
+	}
+	if lineLength == 0 || lineEnd <= lineStart {
+		return 0, false
+	}
+
+	var (
+		center     = lineStart + (lineEnd-lineStart)/2
+		halfBand   = int(float64(lineEnd-lineStart) * gutterSearchBand / 2)
+		bestRun    gutterRun
+		currentRun gutterRun
+	)
+	distanceToCenter := func(run gutterRun) int {
+		diff := run.start + run.size/2 - center
+		return max(diff, -diff)
+	}
+	closeRun := func() {
+		// Keep the widest run, or the one closer to the center when both have the same width
+		isCloser := currentRun.size == bestRun.size &&
+			distanceToCenter(currentRun) < distanceToCenter(bestRun)
+		if currentRun.size > bestRun.size || (currentRun.size > 0 && isCloser) {
+			bestRun = currentRun
+		}
+		currentRun.size = 0
+	}
+
+	lastLine := min(center+halfBand, lineEnd-1)
+	for line := max(center-halfBand, lineStart); line <= lastLine; line++ {
+		if !isFlatLine(func(offset int) color.Color { return pixelAt(line, offset) }, lineLength) {
+			closeRun()
+			continue
+		}
+		if currentRun.size == 0 {
+			currentRun.start = line
+		}
+		currentRun.size++
+	}
+	closeRun()
+
+	if bestRun.size == 0 {
+		return 0, false
+	}
+	return bestRun.start + bestRun.size/2, true
+}
+
+// isFlatLine tells if the pixels of a line, sampled from the given function, have all the
+// same flat color, without any art. A small fraction of ink pixels is allowed, for the
+// scan specks and the compression artifacts.
+func isFlatLine(pixelAt func(offset int) color.Color, lineLength int) bool {
+	if lineLength <= 0 {
+		return false
+	}
+
+	sampleStep := max(1, lineLength/flatLineMaxSamples)
+	luminances := make([]uint8, 0, lineLength/sampleStep+1)
+	var total int
+	for offset := 0; offset < lineLength; offset += sampleStep {
+		luminance := color.GrayModel.Convert(pixelAt(offset)).(color.Gray).Y
+		luminances = append(luminances, luminance)
+		total += int(luminance)
+	}
+
+	mean := total / len(luminances)
+	var inkPixels int
+	for _, luminance := range luminances {
+		if diff := int(luminance) - mean; max(diff, -diff) > flatLineTolerance {
+			inkPixels++
+		}
+	}
+	return float64(inkPixels) <= flatLineMaxInk*float64(len(luminances))
+}
@@ -0,0 +1,133 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Jictyvoo/ink_stream/pkg/imgutils/testimgs"
+	"github.com/Jictyvoo/ink_stream/pkg/inktypes"
+)
+
+func TestFindGutter(t *testing.T) {
+	// spreadImage draws art everywhere, except on the given gutter lines
+	spreadImage := func(bounds image.Rectangle, gutter [2]int, vertical bool) image.Image {
+		img := image.NewGray(bounds)
+		for x, y := range Iterator(img) {
+			line := x
+			if !vertical {
+				line = y
+			}
+			luminance := uint8((x*37 + y*91) % 256)
+			if line >= gutter[0] && line < gutter[1] {
+				luminance = 0xff
+			}
+			img.SetGray(x, y, color.Gray{Y: luminance})
+		}
+		return img
+	}
+
+	testCases := []struct {
+		name             string
+		img              image.Image
+		orientation      inktypes.ImageOrientation
+		expectedPosition int
+		expectedFound    bool
+	}{
+		{
+			name:             "Off-center gutter",
+			img:              spreadImage(image.Rect(0, 0, 200, 100), [2]int{82, 90}, true),
+			orientation:      inktypes.OrientationLandscape,
+			expectedPosition: 86,
+			expectedFound:    true,
+		},
+		{
+			name:             "Gutter on a portrait image",
+			img:              spreadImage(image.Rect(0, 0, 100, 200), [2]int{104, 110}, false),
+			orientation:      inktypes.OrientationPortrait,
+			expectedPosition: 107,
+			expectedFound:    true,
+		},
+		{
+			name:          "Art bleeding across the spread",
+			img:           spreadImage(image.Rect(0, 0, 200, 100), [2]int{}, true),
+			orientation:   inktypes.OrientationLandscape,
+			expectedFound: false,
+		},
+		{
+			name:          "Gutter outside the search band",
+			img:           spreadImage(image.Rect(0, 0, 200, 100), [2]int{20, 30}, true),
+			orientation:   inktypes.OrientationLandscape,
+			expectedFound: false,
+		},
+		{
+			name:             "Solid image is split at the center",
+			img:              testimgs.NewSolidImage(image.Rect(0, 0, 200, 100), color.White),
+			orientation:      inktypes.OrientationLandscape,
+			expectedPosition: 100,
+			expectedFound:    true,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			position, found := FindGutter(tCase.img, tCase.orientation)
+			if found != tCase.expectedFound {
+				t.Fatalf("expected found %v, got %v", tCase.expectedFound, found)
+			}
+			if found && position != tCase.expectedPosition {
+				t.Errorf("expected gutter at %d, got %d", tCase.expectedPosition, position)
+			}
+		})
+	}
+}
+
+func TestSplitAt(t *testing.T) {
+	tests := []struct {
+		name        string
+		inputRect   image.Rectangle
+		orientation inktypes.ImageOrientation
+		position    int
+		expected    Margins[image.Rectangle]
+	}{
+		{
+			name:        "Landscape split",
+			inputRect:   image.Rect(0, 0, 100, 50),
+			orientation: inktypes.OrientationLandscape,
+			position:    40,
+			expected: Margins[image.Rectangle]{
+				Left:  image.Rect(0, 0, 40, 50),
+				Right: image.Rect(40, 0, 100, 50),
+			},
+		},
+		{
+			name:        "Portrait split",
+			inputRect:   image.Rect(0, 10, 50, 110),
+			orientation: inktypes.OrientationPortrait,
+			position:    70,
+			expected: Margins[image.Rectangle]{
+				Top:    image.Rect(0, 10, 50, 70),
+				Bottom: image.Rect(0, 70, 50, 110),
+			},
+		},
+		{
+			name:        "Position outside the bounds",
+			inputRect:   image.Rect(0, 0, 100, 50),
+			orientation: inktypes.OrientationLandscape,
+			position:    120,
+			expected: Margins[image.Rectangle]{
+				Left:  image.Rect(0, 0, 100, 50),
+				Right: image.Rect(100, 0, 100, 50),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitAt(tt.inputRect, tt.orientation, tt.position)
+			if got != tt.expected {
+				t.Errorf("SplitAt() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+)
+
+const (
+	// StripMinPageFraction is the shortest page, as a fraction of the page height, that
+	// may be cut from a strip to end it on the whitespace between two panels
+	StripMinPageFraction = 0.5
+	// StripMaxPageFraction is the tallest page, as a fraction of the page height, that
+	// may be cut from a strip to not cut through a panel
+	StripMaxPageFraction = 1.5
+)
+
+// FindStripCut returns the row where the first page of a vertical strip ends, for pages
+// of the given height. The cut is placed on a flat row, the whitespace or the solid color
+// between two panels, the closest to the page height, preferring the shorter pages.
+// The strip is only cut through a panel when no flat row is found near the page height.
+func FindStripCut(img image.Image, pageHeight int) int {
+	bounds := img.Bounds()
+	if pageHeight <= 0 || bounds.Dy() <= pageHeight {
+		return bounds.Max.Y
+	}
+
+	isFlatRow := func(row int) bool {
+		return isFlatLine(
+			func(offset int) color.Color { return img.At(bounds.Min.X+offset, row) },
+			bounds.Dx(),
+		)
+	}
+	target := bounds.Min.Y + pageHeight
+	shortest := bounds.Min.Y + max(1, int(float64(pageHeight)*StripMinPageFraction))
+	for row := target; row >= shortest; row-- {
+		if isFlatRow(row) {
+			return row
+		}
+	}
+
+	tallest := min(bounds.Min.Y+int(float64(pageHeight)*StripMaxPageFraction), bounds.Max.Y-1)
+	for row := target + 1; row <= tallest; row++ {
+		if isFlatRow(row) {
+			return row
+		}
+	}
+
+	return target
+}
@@ -0,0 +1,82 @@
+package imgutils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestFindStripCut(t *testing.T) {
+	// newStripOn draws a strip with black panels, which are separated by the background
+	newStripOn := func(background color.Gray, height int, panels ...image.Rectangle) image.Image {
+		img := image.NewGray(image.Rect(0, 0, 100, height))
+		draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+		for _, panel := range panels {
+			draw.Draw(img, panel, image.Black, image.Point{}, draw.Src)
+		}
+		return img
+	}
+	newStrip := func(height int, panels ...image.Rectangle) image.Image {
+		return newStripOn(color.Gray{Y: 0xff}, height, panels...)
+	}
+
+	testCases := []struct {
+		name       string
+		img        image.Image
+		pageHeight int
+		expected   int
+	}{
+		{
+			name:       "Strip shorter than a page",
+			img:        newStrip(120, image.Rect(10, 10, 90, 110)),
+			pageHeight: 150,
+			expected:   120,
+		},
+		{
+			name:       "Cut on the page height whitespace",
+			img:        newStrip(400, image.Rect(10, 0, 90, 90), image.Rect(10, 110, 90, 400)),
+			pageHeight: 100,
+			expected:   100,
+		},
+		{
+			name: "Cut before the panel crossing the page height",
+			img: newStrip(
+				400, image.Rect(10, 0, 90, 60), image.Rect(10, 70, 90, 130),
+				image.Rect(10, 140, 90, 400),
+			),
+			pageHeight: 100,
+			expected:   69,
+		},
+		{
+			name:       "Taller page to not cut the panel",
+			img:        newStrip(400, image.Rect(10, 0, 90, 120), image.Rect(10, 130, 90, 400)),
+			pageHeight: 100,
+			expected:   120,
+		},
+		{
+			name: "Cut through a panel taller than the pages",
+			img: newStrip(
+				400, image.Rect(10, 0, 90, 30), image.Rect(10, 40, 90, 400),
+			),
+			pageHeight: 100,
+			expected:   100,
+		},
+		{
+			name: "Cut on a solid color row",
+			img: newStripOn(
+				color.Gray{Y: 0x80}, 300, image.Rect(10, 0, 90, 95), image.Rect(10, 105, 90, 300),
+			),
+			pageHeight: 100,
+			expected:   100,
+		},
+	}
+
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			if result := FindStripCut(tCase.img, tCase.pageHeight); result != tCase.expected {
+				t.Errorf("expected: %d, actual: %d", tCase.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package inktypes
+
+import (
+	"path"
+	"strings"
+)
+
+type PageType string
+
+const (
+	PageFrontCover PageType = "FrontCover"
+	PageStory      PageType = "Story"
+	PageDeleted    PageType = "Deleted"
+)
+
+type (
+	PageInfo struct {
+		Type PageType
+		// Bookmark is the name of the chapter starting on this page
+		Bookmark string
+	}
+	// BookMetadata holds the book information found along the source pages.
+	BookMetadata struct {
+		Title, Series, Number string
+		Writers               []string
+		Language              string
+		Summary               string
+		Publisher             string
+		// PublishDate is an ISO 8601 date, which may be partial as `2006` or `2006-01`
+		PublishDate string
+		// CoverImage is the path of an image file to be used as the book cover
+		CoverImage    string
+		ReadDirection ReadDirection
+		// Pages holds the page information keyed by the source page name, without extension.
+		Pages map[string]PageInfo
+	}
+)
+
+// PageKey returns the key used by BookMetadata.Pages for the source page filename.
+func PageKey(filename string) string {
+	return strings.TrimSuffix(filename, path.Ext(filename))
+}
+
+// FullTitle returns the book title, built from the series and number when no title is set.
+func (bm BookMetadata) FullTitle() string {
+	switch {
+	case bm.Title != "":
+		return bm.Title
+	case bm.Series != "" && bm.Number != "":
+		return bm.Series + " #" + bm.Number
+	}
+	return bm.Series
+}
+
+// InferReadDirection returns the read direction set on the metadata,
+// falling back to the one usual for the book language.
+func (bm BookMetadata) InferReadDirection() ReadDirection {
+	if bm.ReadDirection != ReadUnknown {
+		return bm.ReadDirection
+	}
+	return NewReadDirectionFromLanguage(bm.Language)
+}
+
+// Page returns the information of the page with the given source filename.
+func (bm BookMetadata) Page(filename string) PageInfo {
+	return bm.Pages[PageKey(filename)]
+}
+
+// Merge fills the empty fields with the values from the other metadata.
+func (bm *BookMetadata) Merge(other BookMetadata) {
+	if bm.Title == "" {
+		bm.Title = other.Title
+	}
+	if bm.Series == "" {
+		bm.Series = other.Series
+	}
+	if bm.Number == "" {
+		bm.Number = other.Number
+	}
+	if bm.Language == "" {
+		bm.Language = other.Language
+	}
+	if bm.Summary == "" {
+		bm.Summary = other.Summary
+	}
+	if bm.Publisher == "" {
+		bm.Publisher = other.Publisher
+	}
+	if bm.PublishDate == "" {
+		bm.PublishDate = other.PublishDate
+	}
+	if bm.CoverImage == "" {
+		bm.CoverImage = other.CoverImage
+	}
+	if len(bm.Writers) == 0 {
+		bm.Writers = other.Writers
+	}
+	if bm.ReadDirection == ReadUnknown {
+		bm.ReadDirection = other.ReadDirection
+	}
+	if len(bm.Pages) == 0 {
+		bm.Pages = other.Pages
+	}
+}
@@ -0,0 +1,122 @@
+package inktypes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBookMetadata_FullTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata BookMetadata
+		expected string
+	}{
+		{
+			name:     "title has priority",
+			metadata: BookMetadata{Title: "The Title", Series: "Series", Number: "2"},
+			expected: "The Title",
+		},
+		{
+			name:     "series with number",
+			metadata: BookMetadata{Series: "Series", Number: "2"},
+			expected: "Series #2",
+		},
+		{name: "series only", metadata: BookMetadata{Series: "Series"}, expected: "Series"},
+		{name: "empty", metadata: BookMetadata{}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.metadata.FullTitle(); result != tt.expected {
+				t.Errorf("FullTitle() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBookMetadata_Page(t *testing.T) {
+	metadata := BookMetadata{
+		Pages: map[string]PageInfo{
+			"chapter/001": {Type: PageFrontCover},
+			"chapter/002": {Type: PageStory, Bookmark: "Chapter 1"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		expected PageInfo
+	}{
+		{name: "cover page", filename: "chapter/001.jpg", expected: PageInfo{Type: PageFrontCover}},
+		{
+			name:     "bookmarked page",
+			filename: "chapter/002.png",
+			expected: PageInfo{Type: PageStory, Bookmark: "Chapter 1"},
+		},
+		{name: "unknown page", filename: "chapter/003.jpg", expected: PageInfo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := metadata.Page(tt.filename); result != tt.expected {
+				t.Errorf("Page(%q) = %+v, want %+v", tt.filename, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBookMetadata_Merge(t *testing.T) {
+	metadata := BookMetadata{Title: "Kept", Writers: []string{"Someone"}, Publisher: "Kept"}
+	metadata.Merge(BookMetadata{
+		Title:         "Ignored",
+		Series:        "Series",
+		Writers:       []string{"Ignored"},
+		Language:      "ja",
+		Publisher:     "Ignored",
+		PublishDate:   "2020-02",
+		CoverImage:    "cover.jpg",
+		ReadDirection: ReadRightToLeft,
+	})
+
+	expected := BookMetadata{
+		Title:         "Kept",
+		Series:        "Series",
+		Writers:       []string{"Someone"},
+		Language:      "ja",
+		Publisher:     "Kept",
+		PublishDate:   "2020-02",
+		CoverImage:    "cover.jpg",
+		ReadDirection: ReadRightToLeft,
+	}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("Merge() = %+v, want %+v", metadata, expected)
+	}
+}
+
+func TestBookMetadata_InferReadDirection(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata BookMetadata
+		expected ReadDirection
+	}{
+		{
+			name:     "explicit direction has priority",
+			metadata: BookMetadata{ReadDirection: ReadLeftToRight, Language: "ja"},
+			expected: ReadLeftToRight,
+		},
+		{
+			name:     "from language",
+			metadata: BookMetadata{Language: "ja"},
+			expected: ReadRightToLeft,
+		},
+		{name: "unknown", metadata: BookMetadata{Language: "en"}, expected: ReadUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.metadata.InferReadDirection(); result != tt.expected {
+				t.Errorf("InferReadDirection() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
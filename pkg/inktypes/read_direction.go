@@ -8,6 +8,8 @@ const (
 	ReadUnknown ReadDirection = iota
 	ReadLeftToRight
 	ReadRightToLeft
+	// ReadVertical is the top to bottom scroll of webtoons, made of tall strips
+	ReadVertical
 )
 
 func NewReadDirection(value string) ReadDirection {
@@ -16,6 +18,8 @@ func NewReadDirection(value string) ReadDirection {
 		return ReadRightToLeft
 	case ReadLeftToRight.String():
 		return ReadLeftToRight
+	case ReadVertical.String():
+		return ReadVertical
 	}
 
 	return ReadUnknown
@@ -25,7 +29,37 @@ func (rd ReadDirection) String() string {
 	switch rd {
 	case ReadRightToLeft:
 		return "rtl"
+	case ReadVertical:
+		return "vertical"
 	default: // ReadLeftToRight
 		return "ltr"
 	}
 }
+
+// PageProgression returns the direction the pages of the book are turned, as the
+// vertical strips are sliced into pages read from left to right.
+func (rd ReadDirection) PageProgression() ReadDirection {
+	if rd == ReadVertical {
+		return ReadLeftToRight
+	}
+	return rd
+}
+
+// NewReadDirectionFromLanguage returns the read direction usual for books in the language,
+// given either as an ISO 639 code or as its English name.
+// For languages written from left to right it returns ReadUnknown, as western comics
+// and translated manga both use them.
+func NewReadDirectionFromLanguage(language string) ReadDirection {
+	language, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(language)), "-")
+	language, _, _ = strings.Cut(language, "_")
+	switch language {
+	case "ja", "jpn", "japanese",
+		"ar", "ara", "arabic",
+		"he", "heb", "hebrew",
+		"fa", "fas", "per", "persian",
+		"ur", "urd", "urdu":
+		return ReadRightToLeft
+	}
+
+	return ReadUnknown
+}
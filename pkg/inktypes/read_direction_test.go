@@ -38,6 +38,11 @@ func TestNewReadDirection(t *testing.T) {
 			value:    "LtR",
 			expected: ReadLeftToRight,
 		},
+		{
+			name:     "vertical",
+			value:    "Vertical",
+			expected: ReadVertical,
+		},
 		{
 			name:     "unknown value",
 			value:    "unknown",
@@ -81,6 +86,11 @@ func TestReadDirectionString(t *testing.T) {
 			direction: ReadLeftToRight,
 			expected:  "ltr",
 		},
+		{
+			name:      "ReadVertical",
+			direction: ReadVertical,
+			expected:  "vertical",
+		},
 		{
 			name:      "ReadUnknown",
 			direction: ReadUnknown,
@@ -112,6 +122,10 @@ func TestReadDirectionStringConsistency(t *testing.T) {
 			name:      "ReadLeftToRight",
 			direction: ReadLeftToRight,
 		},
+		{
+			name:      "ReadVertical",
+			direction: ReadVertical,
+		},
 	}
 
 	for _, tt := range tests {
@@ -125,3 +139,51 @@ func TestReadDirectionStringConsistency(t *testing.T) {
 		})
 	}
 }
+
+func TestNewReadDirectionFromLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		expected ReadDirection
+	}{
+		{name: "japanese code", language: "ja", expected: ReadRightToLeft},
+		{name: "japanese with region", language: "ja-JP", expected: ReadRightToLeft},
+		{name: "japanese name", language: "Japanese", expected: ReadRightToLeft},
+		{name: "arabic three letters code", language: "ara", expected: ReadRightToLeft},
+		{name: "hebrew with underscore region", language: "he_IL", expected: ReadRightToLeft},
+		{name: "english", language: "en", expected: ReadUnknown},
+		{name: "korean", language: "ko", expected: ReadUnknown},
+		{name: "empty", language: "", expected: ReadUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewReadDirectionFromLanguage(tt.language)
+			if result != tt.expected {
+				t.Errorf(
+					"NewReadDirectionFromLanguage(%q) = %v, want %v",
+					tt.language, result, tt.expected,
+				)
+			}
+		})
+	}
+}
+
+func TestReadDirectionPageProgression(t *testing.T) {
+	tests := []struct {
+		direction ReadDirection
+		expected  ReadDirection
+	}{
+		{direction: ReadLeftToRight, expected: ReadLeftToRight},
+		{direction: ReadRightToLeft, expected: ReadRightToLeft},
+		{direction: ReadVertical, expected: ReadLeftToRight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.direction.String(), func(t *testing.T) {
+			if result := tt.direction.PageProgression(); result != tt.expected {
+				t.Errorf("%v.PageProgression() = %v, want %v", tt.direction, result, tt.expected)
+			}
+		})
+	}
+}